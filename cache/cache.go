@@ -0,0 +1,295 @@
+// Package cache 提供基于 db.KVClient 的类型化旁路缓存
+//
+// GetOrLoad 未命中时调用 loader 加载并回填，并发的同键加载通过 singleflight 合并为一次；
+// loader 返回 errors.ErrNotFound 时缓存空结果（负缓存），避免不存在的键反复击穿到数据源；
+// 写入时为 TTL 增加随机抖动，避免大量键同时过期：
+//
+//	c := cache.New(redisClient, cache.WithCodec(cache.Msgpack))
+//	user, err := cache.GetOrLoad(ctx, c, "user:"+id, 10*time.Minute, func(ctx context.Context) (*User, error) {
+//		return repo.Find(ctx, id)
+//	})
+package cache
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+// 缓存默认配置
+const (
+	DefaultNegativeTTL = 30 * time.Second // 空结果的默认缓存时间
+	DefaultJitter      = 0.1              // TTL 默认随机增加的比例上限
+	DefaultLoadTimeout = 10 * time.Second // loader 的默认超时时间
+)
+
+// 缓存值头部标记
+const (
+	flagValue    = 'v' // 正常值
+	flagNotFound = 'n' // 空结果
+)
+
+// Loader 缓存未命中时加载数据，返回 errors.ErrNotFound 表示数据不存在
+type Loader[T any] func(ctx context.Context) (T, error)
+
+// Option 缓存配置选项函数
+type Option func(*Cache)
+
+// WithCodec 设置序列化方式，默认 JSON
+func WithCodec(codec Codec) Option {
+	return func(c *Cache) {
+		c.codec = codec
+	}
+}
+
+// WithNegativeTTL 设置空结果的缓存时间，0 表示不缓存空结果
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.negativeTTL = ttl
+	}
+}
+
+// WithJitter 设置 TTL 随机增加的比例上限，如 0.1 表示增加 0~10%，0 表示不抖动
+func WithJitter(fraction float64) Option {
+	return func(c *Cache) {
+		c.jitter = fraction
+	}
+}
+
+// WithLoadTimeout 设置 loader 及回填的超时时间，0 表示不限制
+func WithLoadTimeout(timeout time.Duration) Option {
+	return func(c *Cache) {
+		c.loadTimeout = timeout
+	}
+}
+
+// Cache 类型化旁路缓存，并发安全
+type Cache struct {
+	client      db.KVClient
+	codec       Codec
+	negativeTTL time.Duration
+	jitter      float64
+	loadTimeout time.Duration
+	group       singleflight.Group
+}
+
+// New 创建缓存
+func New(client db.KVClient, opts ...Option) *Cache {
+	c := &Cache{
+		client:      client,
+		codec:       JSON,
+		negativeTTL: DefaultNegativeTTL,
+		jitter:      DefaultJitter,
+		loadTimeout: DefaultLoadTimeout,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get 读取缓存到 dest（指针）
+// 键不存在、已缓存为空结果或无法解码时返回 errors.ErrNotFound
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) error {
+	st, err := c.lookup(ctx, key, dest)
+	if err != nil {
+		return err
+	}
+	if st != hit {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// Set 写入缓存，ttl 会按配置增加随机抖动；ttl 为 0 表示不过期
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c.client == nil {
+		return errors.ErrNilClient
+	}
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		return errors.Wrap(err, "cache: marshal "+key)
+	}
+	return c.write(ctx, key, c.header(flagValue)+string(data), ttl)
+}
+
+// SetNotFound 将键缓存为空结果，持续负缓存时间
+func (c *Cache) SetNotFound(ctx context.Context, key string) error {
+	if c.client == nil {
+		return errors.ErrNilClient
+	}
+	if c.negativeTTL <= 0 {
+		return nil
+	}
+	return c.write(ctx, key, c.header(flagNotFound), c.negativeTTL)
+}
+
+// Delete 删除缓存，数据变更后调用使缓存失效
+func (c *Cache) Delete(ctx context.Context, keys ...string) error {
+	if c.client == nil {
+		return errors.ErrNilClient
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.client.Del(ctx, keys...)
+}
+
+// GetOrLoad 读取缓存，未命中时调用 loader 加载并回填
+//
+// 同一 Cache 上并发的同键加载只执行一次 loader，其余调用等待共享结果；
+// loader 使用脱离首个调用方取消信号的 ctx（保留其中的值），超时由 WithLoadTimeout 控制，
+// 任一调用方的 ctx 取消时仅自身提前返回，不影响其他等待者。
+// loader 返回 errors.ErrNotFound 时缓存空结果并返回 errors.ErrNotFound；
+// 其他错误不缓存。回填失败只记录日志，不影响返回值。
+// 同键的并发加载若使用了不同的 T，类型不符的调用方返回 errors.ErrInvalidParameter。
+func GetOrLoad[T any](ctx context.Context, c *Cache, key string, ttl time.Duration, loader Loader[T]) (T, error) {
+	var v T
+	st, err := c.lookup(ctx, key, &v)
+	switch {
+	case errors.Is(err, errors.ErrNilClient):
+		return v, err
+	case err != nil:
+		// 缓存不可用时降级为直接加载
+		logger.Warnf("%v, loading from source", err)
+	case st == hit:
+		return v, nil
+	case st == negative:
+		return v, errors.ErrNotFound
+	}
+
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		loadCtx := context.Context(detachedContext{ctx})
+		if c.loadTimeout > 0 {
+			var cancel context.CancelFunc
+			loadCtx, cancel = context.WithTimeout(loadCtx, c.loadTimeout)
+			defer cancel()
+		}
+
+		loaded, err := loader(loadCtx)
+		if errors.Is(err, errors.ErrNotFound) {
+			if werr := c.SetNotFound(loadCtx, key); werr != nil {
+				logger.Warnf("cache: failed to store negative result of %s: %v", key, werr)
+			}
+			return loaded, errors.ErrNotFound
+		}
+		if err != nil {
+			return loaded, err
+		}
+		if werr := c.Set(loadCtx, key, loaded, ttl); werr != nil {
+			logger.Warnf("cache: failed to store %s: %v", key, werr)
+		}
+		return loaded, nil
+	})
+
+	var zero T
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		if res.Val == nil {
+			return zero, nil
+		}
+		loaded, ok := res.Val.(T)
+		if !ok {
+			return zero, errors.Wrapf(errors.ErrInvalidParameter, "cache: shared load of %s returned %T, want %T", key, res.Val, zero)
+		}
+		return loaded, nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// detachedContext 保留父 ctx 的值但不继承其取消和截止时间，等同于 Go 1.21 的 context.WithoutCancel
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool)         { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}               { return nil }
+func (detachedContext) Err() error                          { return nil }
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// lookupState 缓存读取结果
+type lookupState int
+
+const (
+	miss     lookupState = iota // 未命中
+	hit                         // 命中，值已写入 dest
+	negative                    // 命中空结果
+)
+
+// lookup 读取并解码缓存值，无法解码的旧数据按未命中处理
+func (c *Cache) lookup(ctx context.Context, key string, dest interface{}) (lookupState, error) {
+	if c.client == nil {
+		return miss, errors.ErrNilClient
+	}
+	raw, err := c.client.Get(ctx, key)
+	if err != nil {
+		if isMiss(err) {
+			return miss, nil
+		}
+		return miss, errors.Wrap(err, "cache: get "+key)
+	}
+	st, err := c.decode(raw, dest)
+	if err != nil {
+		// 类型变更或编码切换后的旧数据，由调用方回填覆盖
+		logger.Warnf("cache: discarding undecodable value of %s: %v", key, err)
+		return miss, nil
+	}
+	return st, nil
+}
+
+// write 按抖动后的 TTL 写入
+func (c *Cache) write(ctx context.Context, key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return c.client.Set(ctx, key, value)
+	}
+	return c.client.SetWithTTL(ctx, key, value, c.ttlSeconds(ttl))
+}
+
+// ttlSeconds 返回增加抖动后的 TTL，按秒向上取整
+func (c *Cache) ttlSeconds(ttl time.Duration) int {
+	if c.jitter > 0 {
+		ttl += time.Duration(rand.Float64() * c.jitter * float64(ttl))
+	}
+	return int(math.Ceil(ttl.Seconds()))
+}
+
+// header 返回缓存值头部：编码名、分隔符和标记
+func (c *Cache) header(flag byte) string {
+	return c.codec.Name() + "|" + string(flag)
+}
+
+// decode 解析缓存值
+func (c *Cache) decode(raw string, dest interface{}) (lookupState, error) {
+	prefix := c.codec.Name() + "|"
+	if !strings.HasPrefix(raw, prefix) || len(raw) == len(prefix) {
+		return miss, errors.New("unexpected cache value format")
+	}
+	switch raw[len(prefix)] {
+	case flagNotFound:
+		return negative, nil
+	case flagValue:
+		if err := c.codec.Unmarshal([]byte(raw[len(prefix)+1:]), dest); err != nil {
+			return miss, err
+		}
+		return hit, nil
+	default:
+		return miss, errors.New("unexpected cache value flag")
+	}
+}
+
+// isMiss 判断读取错误是否表示键不存在
+func isMiss(err error) bool {
+	return errors.Is(err, redis.Nil) || errors.Is(err, errors.ErrNotFound)
+}
@@ -0,0 +1,563 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hyperits/gosuite/db"
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeKV 记录写入 TTL 的内存 KVClient
+type fakeKV struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]int
+	err  error
+}
+
+func newFakeKV() *fakeKV {
+	return &fakeKV{data: map[string]string{}, ttls: map[string]int{}}
+}
+
+func (f *fakeKV) Close() error                   { return nil }
+func (f *fakeKV) Ping(ctx context.Context) error { return nil }
+func (f *fakeKV) IsConnected() bool              { return true }
+func (f *fakeKV) Stats() db.Stats                { return db.Stats{} }
+
+func (f *fakeKV) Get(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return "", f.err
+	}
+	v, ok := f.data[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return v, nil
+}
+
+func (f *fakeKV) Set(ctx context.Context, key string, value interface{}) error {
+	return f.SetWithTTL(ctx, key, value, 0)
+}
+
+func (f *fakeKV) SetWithTTL(ctx context.Context, key string, value interface{}, ttlSeconds int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = value.(string)
+	f.ttls[key] = ttlSeconds
+	return nil
+}
+
+func (f *fakeKV) Del(ctx context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, k := range keys {
+		delete(f.data, k)
+	}
+	return nil
+}
+
+func (f *fakeKV) Exists(ctx context.Context, keys ...string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var n int64
+	for _, k := range keys {
+		if _, ok := f.data[k]; ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func TestCodecs(t *testing.T) {
+	ctx := context.Background()
+	for _, codec := range []Codec{JSON, Msgpack, Gob} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			c := New(newFakeKV(), WithCodec(codec))
+			want := user{ID: 1, Name: "alice"}
+			if err := c.Set(ctx, "u", want, time.Minute); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			var got user
+			if err := c.Get(ctx, "u", &got); err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got != want {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestGetOrLoad(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	c := New(kv, WithJitter(0))
+
+	var calls int32
+	loader := func(ctx context.Context) (*user, error) {
+		atomic.AddInt32(&calls, 1)
+		return &user{ID: 7, Name: "bob"}, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		u, err := GetOrLoad(ctx, c, "user:7", 90*time.Second, loader)
+		if err != nil || u == nil || u.Name != "bob" {
+			t.Fatalf("GetOrLoad = %+v, %v", u, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+	if kv.ttls["user:7"] != 90 {
+		t.Errorf("ttl = %d, want 90", kv.ttls["user:7"])
+	}
+
+	// 编码切换后旧数据按未命中处理
+	c2 := New(kv, WithCodec(Gob))
+	if _, err := GetOrLoad(ctx, c2, "user:7", time.Minute, loader); err != nil {
+		t.Fatalf("GetOrLoad after codec change: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("loader calls = %d, want 2", calls)
+	}
+}
+
+func TestGetOrLoadSingleflight(t *testing.T) {
+	ctx := context.Background()
+	c := New(newFakeKV())
+
+	var calls int32
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return 42, nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	results := make(chan int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := GetOrLoad(ctx, c, "answer", time.Minute, loader)
+			if err != nil {
+				t.Errorf("GetOrLoad: %v", err)
+			}
+			results <- v
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != 42 {
+			t.Errorf("result = %d", v)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+}
+
+func TestGetOrLoadDetachedContext(t *testing.T) {
+	type ctxKey struct{}
+	c := New(newFakeKV(), WithLoadTimeout(time.Second))
+
+	// 首个调用方取消后 loader 继续执行，其他等待者仍拿到结果
+	first, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "trace"))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	loader := func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		if _, ok := ctx.Deadline(); !ok || ctx.Value(ctxKey{}) != "trace" {
+			return 0, errors.New("loader ctx lost deadline or values")
+		}
+		return 42, nil
+	}
+
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := GetOrLoad(first, c, "answer", time.Minute, loader)
+		firstErr <- err
+	}()
+	<-started
+	second := make(chan error, 1)
+	go func() {
+		v, err := GetOrLoad(context.Background(), c, "answer", time.Minute, loader)
+		if err == nil && v != 42 {
+			err = errors.New("unexpected value")
+		}
+		second <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller err = %v", err)
+	}
+	close(release)
+	if err := <-second; err != nil {
+		t.Errorf("waiting caller err = %v", err)
+	}
+
+	// loader 超时
+	c = New(newFakeKV(), WithLoadTimeout(20*time.Millisecond))
+	_, err := GetOrLoad(context.Background(), c, "slow", time.Minute, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("timeout err = %v", err)
+	}
+}
+
+func TestGetOrLoadTypeMismatch(t *testing.T) {
+	ctx := context.Background()
+	c := New(newFakeKV())
+
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = GetOrLoad(ctx, c, "k", time.Minute, func(ctx context.Context) (int, error) {
+			<-release
+			return 1, nil
+		})
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// 同键加载进行中，以不同类型加入时共享到 int 结果
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	_, err := GetOrLoad(ctx, c, "k", time.Minute, func(ctx context.Context) (string, error) {
+		return "x", nil
+	})
+	if !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("type mismatch err = %v", err)
+	}
+	<-done
+}
+
+func TestGetOrLoadNegative(t *testing.T) {
+	ctx := context.Background()
+	kv := newFakeKV()
+	c := New(kv, WithNegativeTTL(5*time.Second), WithJitter(0))
+
+	var calls int32
+	loader := func(ctx context.Context) (*user, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, errors.ErrNotFound
+	}
+
+	for i := 0; i < 2; i++ {
+		u, err := GetOrLoad(ctx, c, "user:404", time.Minute, loader)
+		if !errors.Is(err, errors.ErrNotFound) || u != nil {
+			t.Fatalf("GetOrLoad = %+v, %v", u, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("loader calls = %d, want 1", calls)
+	}
+	if kv.ttls["user:404"] != 5 {
+		t.Errorf("negative ttl = %d, want 5", kv.ttls["user:404"])
+	}
+
+	var u user
+	if err := c.Get(ctx, "user:404", &u); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("Get negative = %v", err)
+	}
+
+	// 其他错误不缓存
+	boom := errors.New("boom")
+	for i := 0; i < 2; i++ {
+		if _, err := GetOrLoad(ctx, c, "user:500", time.Minute, func(ctx context.Context) (int, error) {
+			atomic.AddInt32(&calls, 1)
+			return 0, boom
+		}); !errors.Is(err, boom) {
+			t.Fatalf("GetOrLoad err = %v", err)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("loader calls = %d, want 3", calls)
+	}
+}
+
+func TestGetOrLoadCacheDown(t *testing.T) {
+	kv := newFakeKV()
+	kv.err = errors.New("connection refused")
+	c := New(kv)
+
+	v, err := GetOrLoad(context.Background(), c, "k", time.Minute, func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	})
+	if err != nil || v != "fresh" {
+		t.Fatalf("GetOrLoad = %q, %v", v, err)
+	}
+	if err := c.Get(context.Background(), "k", &v); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Get err = %v", err)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	c := New(newFakeKV(), WithJitter(0.5))
+	for i := 0; i < 100; i++ {
+		if s := c.ttlSeconds(10 * time.Second); s < 10 || s > 15 {
+			t.Fatalf("ttl = %d, want [10, 15]", s)
+		}
+	}
+	c = New(newFakeKV(), WithJitter(0))
+	if s := c.ttlSeconds(1500 * time.Millisecond); s != 2 {
+		t.Errorf("ttl = %d, want 2", s)
+	}
+}
+
+func TestLRU(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	c := newLRU(2, time.Minute, func() time.Time { return now })
+
+	c.add("a", "1", 0, c.currentEpoch())
+	c.add("b", "2", 0, c.currentEpoch())
+	if _, ok := c.get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	// b 最久未使用，被淘汰
+	if evicted := c.add("c", "3", 0, c.currentEpoch()); evicted != 1 {
+		t.Errorf("evicted = %d, want 1", evicted)
+	}
+	if _, ok := c.get("b"); ok {
+		t.Error("b not evicted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := c.get("a"); ok {
+		t.Error("a not expired")
+	}
+	if c.len() != 1 {
+		t.Errorf("len = %d, want 1", c.len())
+	}
+
+	if n := c.remove("c", "missing"); n != 1 {
+		t.Errorf("removed = %d, want 1", n)
+	}
+	// 读取代数后发生删除，回填被丢弃
+	epoch := c.currentEpoch()
+	c.remove("missing")
+	c.add("d", "4", 0, epoch)
+	if _, ok := c.get("d"); ok {
+		t.Error("stale fill after remove")
+	}
+	c.add("d", "4", 0, c.currentEpoch())
+	c.purge()
+	if c.len() != 0 {
+		t.Errorf("len after purge = %d", c.len())
+	}
+}
+
+// newTestNear 创建连接 miniredis、不启动订阅的 Near
+func newTestNear(t *testing.T) (*Near, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client, err := gredis.NewClient(&gredis.Config{Address: mr.Addr(), HealthCheckInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	n := &Near{client: client, ttl: time.Minute, local: newLRU(DefaultNearSize, time.Minute, time.Now)}
+	n.subscribed.Store(true)
+	return n, mr
+}
+
+func TestNearGet(t *testing.T) {
+	ctx := context.Background()
+	n, mr := newTestNear(t)
+	mr.Set("k", "v1")
+
+	for i := 0; i < 3; i++ {
+		if v, err := n.Get(ctx, "k"); err != nil || v != "v1" {
+			t.Fatalf("Get = %q, %v", v, err)
+		}
+	}
+	if _, err := n.Get(ctx, "missing"); !errors.Is(err, redis.Nil) {
+		t.Errorf("Get missing err = %v", err)
+	}
+
+	st := n.NearStats()
+	if st.Hits != 2 || st.Misses != 2 || st.Size != 1 {
+		t.Errorf("stats = %+v", st)
+	}
+	if r := st.HitRatio(); r != 0.5 {
+		t.Errorf("hit ratio = %v", r)
+	}
+
+	// 其他节点广播失效后重新读取 Redis
+	mr.Set("k", "v2")
+	n.handle(`["k"]`)
+	if v, _ := n.Get(ctx, "k"); v != "v2" {
+		t.Errorf("Get after invalidation = %q", v)
+	}
+	if st := n.NearStats(); st.Invalidations != 1 {
+		t.Errorf("invalidations = %d", st.Invalidations)
+	}
+	n.handle(`not json`)
+}
+
+func TestNearTTLCappedByRedis(t *testing.T) {
+	ctx := context.Background()
+	n, mr := newTestNear(t)
+	now := time.Unix(1700000000, 0)
+	n.local = newLRU(DefaultNearSize, time.Minute, func() time.Time { return now })
+
+	mr.Set("short", "v")
+	mr.SetTTL("short", 10*time.Second)
+	mr.Set("long", "v")
+	mr.SetTTL("long", time.Hour)
+	mr.Set("forever", "v")
+	for _, key := range []string{"short", "long", "forever"} {
+		if _, err := n.Get(ctx, key); err != nil {
+			t.Fatalf("Get %s: %v", key, err)
+		}
+	}
+
+	// short 的本地条目随 Redis 中的键一起过期，其余使用本地存活时间
+	now = now.Add(10 * time.Second)
+	if _, ok := n.local.get("short"); ok {
+		t.Error("local entry outlived redis ttl")
+	}
+	if _, ok := n.local.get("long"); !ok {
+		t.Error("long expired early")
+	}
+	now = now.Add(50 * time.Second)
+	if _, ok := n.local.get("long"); ok {
+		t.Error("long outlived local ttl")
+	}
+	if _, ok := n.local.get("forever"); ok {
+		t.Error("forever outlived local ttl")
+	}
+}
+
+func TestNearNoFillWhileUnsubscribed(t *testing.T) {
+	ctx := context.Background()
+	n, mr := newTestNear(t)
+	mr.Set("k", "v")
+	n.subscribed.Store(false)
+
+	n.Get(ctx, "k")
+	if st := n.NearStats(); st.Size != 0 {
+		t.Errorf("filled while unsubscribed: %+v", st)
+	}
+}
+
+// afterPipeline 在管道执行后调用 fn 的 go-redis 钩子
+type afterPipeline func()
+
+func (h afterPipeline) DialHook(next redis.DialHook) redis.DialHook          { return next }
+func (h afterPipeline) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+func (h afterPipeline) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		h()
+		return err
+	}
+}
+
+func TestNearSkipsStaleFill(t *testing.T) {
+	n, mr := newTestNear(t)
+	mr.Set("k", "old")
+	// 读取 Redis 期间收到失效广播
+	n.client.UniversalClient().AddHook(afterPipeline(func() { n.handle(`["k"]`) }))
+
+	if v, _ := n.Get(context.Background(), "k"); v != "old" {
+		t.Fatalf("Get = %q", v)
+	}
+	if st := n.NearStats(); st.Size != 0 {
+		t.Errorf("stale value cached: %+v", st)
+	}
+}
+
+// waitNear 等待 cond 成立，超时则测试失败
+func waitNear(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNearInvalidationAcrossNodes(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	newNode := func() *Near {
+		client, err := gredis.NewClient(&gredis.Config{Address: mr.Addr(), HealthCheckInterval: time.Hour})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		t.Cleanup(func() { client.Close() })
+		n, err := NewNear(client)
+		if err != nil {
+			t.Fatalf("NewNear: %v", err)
+		}
+		t.Cleanup(func() { n.Close() })
+		waitNear(t, "subscribe", n.subscribed.Load)
+		return n
+	}
+	a, b := newNode(), newNode()
+
+	mr.Set("k", "v1")
+	if v, err := b.Get(ctx, "k"); err != nil || v != "v1" {
+		t.Fatalf("b.Get = %q, %v", v, err)
+	}
+	if _, ok := b.local.get("k"); !ok {
+		t.Fatal("b did not cache k")
+	}
+
+	// A 写入后广播失效，B 删除本地条目并读到新值
+	if err := a.Set(ctx, "k", "v2"); err != nil {
+		t.Fatalf("a.Set: %v", err)
+	}
+	waitNear(t, "invalidation", func() bool { _, ok := b.local.get("k"); return !ok })
+	if v, _ := b.Get(ctx, "k"); v != "v2" {
+		t.Errorf("b.Get after a.Set = %q, want v2", v)
+	}
+
+	// 订阅连接断开期间可能错过广播，go-redis 重连并重新订阅后清空本地缓存
+	if _, ok := b.local.get("k"); !ok {
+		t.Fatal("b did not cache k")
+	}
+	mr.Set("k", "v3")
+	mr.Close()
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	waitNear(t, "resubscribe reset", func() bool { _, ok := b.local.get("k"); return !ok })
+	if v, _ := b.Get(ctx, "k"); v != "v3" {
+		t.Errorf("b.Get after resubscribe = %q, want v3", v)
+	}
+}
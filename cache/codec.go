@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 缓存值的序列化方式
+type Codec interface {
+	// Name 返回编码名称，写入缓存值头部，读取时编码不一致视为未命中
+	Name() string
+
+	// Marshal 序列化
+	Marshal(v interface{}) ([]byte, error)
+
+	// Unmarshal 反序列化到 v，v 为指针
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// 内置编码
+var (
+	// JSON 使用 encoding/json，可读性好，默认编码
+	JSON Codec = jsonCodec{}
+
+	// Msgpack 使用 MessagePack，体积小、速度快
+	Msgpack Codec = msgpackCodec{}
+
+	// Gob 使用 encoding/gob，仅适用于 Go 服务之间
+	Gob Codec = gobCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                               { return "msgpack" }
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpack.Unmarshal(data, v) }
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruEntry 本地缓存条目
+type lruEntry struct {
+	key      string
+	value    string
+	expireAt time.Time
+}
+
+// lru 带过期时间的进程内 LRU 缓存，并发安全
+type lru struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	ll       *list.List // 队首为最近使用
+	items    map[string]*list.Element
+	epoch    uint64 // 每次删除或清空时递增，使进行中的回填失效
+}
+
+// newLRU 创建容量为 capacity、条目存活 ttl 的 LRU 缓存
+func newLRU(capacity int, ttl time.Duration, now func() time.Time) *lru {
+	return &lru{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      now,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// get 返回未过期的值，过期条目会被删除
+func (c *lru) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return "", false
+	}
+	e := el.Value.(*lruEntry)
+	if !c.now().Before(e.expireAt) {
+		c.ll.Remove(el)
+		delete(c.items, key)
+		return "", false
+	}
+	c.ll.MoveToFront(el)
+	return e.value, true
+}
+
+// currentEpoch 返回当前失效代数，回填前读取并传给 add
+func (c *lru) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.epoch
+}
+
+// add 写入值，ttl 大于 0 时以其作为条目存活时间，否则使用默认值
+// epoch 与当前失效代数不一致（读取后发生过删除或清空）时不写入，比较与写入在同一把锁内完成
+// 超出容量时淘汰最久未使用的条目，返回淘汰数量
+func (c *lru) add(key, value string, ttl time.Duration, epoch uint64) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if epoch != c.epoch {
+		return 0
+	}
+
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	expireAt := c.now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*lruEntry)
+		e.value, e.expireAt = value, expireAt
+		c.ll.MoveToFront(el)
+		return 0
+	}
+	c.items[key] = c.ll.PushFront(&lruEntry{key: key, value: value, expireAt: expireAt})
+
+	evicted := 0
+	for c.ll.Len() > c.capacity {
+		el := c.ll.Back()
+		c.ll.Remove(el)
+		delete(c.items, el.Value.(*lruEntry).key)
+		evicted++
+	}
+	return evicted
+}
+
+// remove 删除条目并递增失效代数，返回实际删除的数量
+func (c *lru) remove(keys ...string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+
+	n := 0
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.ll.Remove(el)
+			delete(c.items, key)
+			n++
+		}
+	}
+	return n
+}
+
+// purge 清空缓存并递增失效代数
+func (c *lru) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epoch++
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// len 返回条目数量，包含尚未清理的过期条目
+func (c *lru) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
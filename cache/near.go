@@ -0,0 +1,313 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// 确保 Near 实现 KVClient 接口
+var _ db.KVClient = (*Near)(nil)
+
+// 近端缓存默认配置
+const (
+	DefaultNearSize    = 10000                      // 本地缓存默认最大条目数
+	DefaultNearTTL     = time.Minute                // 本地条目默认存活时间，限制失效消息丢失时的最长不一致时间
+	DefaultNearChannel = "gosuite:cache:invalidate" // 默认失效广播频道
+
+	nearResubscribeDelay = time.Second // 订阅断开后重新订阅的等待时间
+)
+
+// NearClient 近端缓存使用的 Redis 客户端，db/redis.Client 满足
+type NearClient interface {
+	db.KVClient
+	gredis.UniversalClientProvider
+}
+
+// NearStats 近端缓存统计
+type NearStats struct {
+	Hits          uint64 // 本地命中次数
+	Misses        uint64 // 本地未命中、读取 Redis 的次数
+	Evictions     uint64 // 因容量淘汰的条目数
+	Invalidations uint64 // 因写入或失效广播删除的条目数
+	Size          int    // 当前条目数
+}
+
+// HitRatio 返回本地命中率
+func (s NearStats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// NearOption 近端缓存配置选项函数
+type NearOption func(*Near)
+
+// WithNearSize 设置本地缓存最大条目数，默认 10000
+func WithNearSize(size int) NearOption {
+	return func(n *Near) {
+		if size > 0 {
+			n.size = size
+		}
+	}
+}
+
+// WithNearTTL 设置本地条目存活时间，默认 1 分钟
+func WithNearTTL(ttl time.Duration) NearOption {
+	return func(n *Near) {
+		if ttl > 0 {
+			n.ttl = ttl
+		}
+	}
+}
+
+// WithNearChannel 设置失效广播频道，共享同一批键的节点必须使用相同频道
+func WithNearChannel(channel string) NearOption {
+	return func(n *Near) {
+		n.channel = channel
+	}
+}
+
+// Near 近端缓存：在 Redis 前增加进程内 LRU 缓存，减少热点键对 Redis 的访问
+//
+// 通过 Near 写入或删除键时，先写 Redis，再删除本地条目并通过 Redis pub/sub 广播，
+// 所有节点收到广播后删除对应的本地条目。订阅建立前以及断线重连后会清空本地缓存，
+// 广播丢失时本地条目最多在存活时间后过期。直接写 Redis 的场景需调用 Invalidate。
+//
+// Near 实现 db.KVClient，可作为 cache.New 的存储组成两级缓存。
+type Near struct {
+	client  NearClient
+	size    int
+	ttl     time.Duration
+	channel string
+	local   *lru
+
+	subscribed atomic.Bool
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	evictions     atomic.Uint64
+	invalidations atomic.Uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewNear 创建近端缓存并在后台订阅失效广播，Close 时停止订阅
+func NewNear(client NearClient, opts ...NearOption) (*Near, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+	n := &Near{
+		client:  client,
+		size:    DefaultNearSize,
+		ttl:     DefaultNearTTL,
+		channel: DefaultNearChannel,
+		done:    make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	if n.channel == "" {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "cache.near: channel is required")
+	}
+	n.local = newLRU(n.size, n.ttl, time.Now)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+	go n.run(ctx)
+	return n, nil
+}
+
+// Get 优先读取本地缓存，未命中时读取 Redis 并写入本地
+// GET 与 PTTL 在同一管道中执行，本地条目不会比 Redis 中的键更晚过期
+func (n *Near) Get(ctx context.Context, key string) (string, error) {
+	if v, ok := n.local.get(key); ok {
+		n.hits.Add(1)
+		return v, nil
+	}
+	n.misses.Add(1)
+
+	// 读取 Redis 期间发生失效则不写入本地，避免回填旧值
+	epoch := n.local.currentEpoch()
+	var get *redis.StringCmd
+	var pttl *redis.DurationCmd
+	_, _ = n.client.UniversalClient().Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.Get(ctx, key)
+		pttl = pipe.PTTL(ctx, key)
+		return nil
+	})
+	v, err := get.Result()
+	if err != nil {
+		return v, err
+	}
+
+	ttl := n.ttl
+	switch remaining := pttl.Val(); {
+	case pttl.Err() != nil || remaining == -2:
+		// 无法获取剩余时间，或 GET 之后键已过期
+		return v, nil
+	case remaining > 0 && remaining < ttl:
+		ttl = remaining
+	}
+	if n.subscribed.Load() {
+		if evicted := n.local.add(key, v, ttl, epoch); evicted > 0 {
+			n.evictions.Add(uint64(evicted))
+		}
+	}
+	return v, nil
+}
+
+// Set 写入 Redis 并广播失效
+func (n *Near) Set(ctx context.Context, key string, value interface{}) error {
+	if err := n.client.Set(ctx, key, value); err != nil {
+		return err
+	}
+	return n.Invalidate(ctx, key)
+}
+
+// SetWithTTL 写入 Redis 并指定过期时间（秒），然后广播失效
+func (n *Near) SetWithTTL(ctx context.Context, key string, value interface{}, ttlSeconds int) error {
+	if err := n.client.SetWithTTL(ctx, key, value, ttlSeconds); err != nil {
+		return err
+	}
+	return n.Invalidate(ctx, key)
+}
+
+// Del 删除 Redis 中的键并广播失效
+func (n *Near) Del(ctx context.Context, keys ...string) error {
+	if err := n.client.Del(ctx, keys...); err != nil {
+		return err
+	}
+	return n.Invalidate(ctx, keys...)
+}
+
+// Exists 检查键是否存在，直接读取 Redis
+func (n *Near) Exists(ctx context.Context, keys ...string) (int64, error) {
+	return n.client.Exists(ctx, keys...)
+}
+
+// Invalidate 删除本地条目并通知所有节点删除，用于绕过 Near 直接修改 Redis 的场景
+func (n *Near) Invalidate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	n.evict(keys)
+
+	payload, err := json.Marshal(keys)
+	if err != nil {
+		return errors.Wrap(err, "cache.near: encode invalidation")
+	}
+	if err := n.client.UniversalClient().Publish(ctx, n.channel, payload).Err(); err != nil {
+		return errors.Wrap(err, "cache.near: publish invalidation")
+	}
+	return nil
+}
+
+// NearStats 返回本地缓存统计
+func (n *Near) NearStats() NearStats {
+	return NearStats{
+		Hits:          n.hits.Load(),
+		Misses:        n.misses.Load(),
+		Evictions:     n.evictions.Load(),
+		Invalidations: n.invalidations.Load(),
+		Size:          n.local.len(),
+	}
+}
+
+// Ping 测试 Redis 连接
+func (n *Near) Ping(ctx context.Context) error {
+	return n.client.Ping(ctx)
+}
+
+// IsConnected 检查 Redis 是否已连接
+func (n *Near) IsConnected() bool {
+	return n.client.IsConnected()
+}
+
+// Stats 返回 Redis 连接池统计
+func (n *Near) Stats() db.Stats {
+	return n.client.Stats()
+}
+
+// Close 停止订阅并清空本地缓存，不关闭底层 Redis 客户端
+func (n *Near) Close() error {
+	n.cancel()
+	<-n.done
+	n.local.purge()
+	return nil
+}
+
+// evict 删除本地条目并使进行中的回填失效
+func (n *Near) evict(keys []string) {
+	if removed := n.local.remove(keys...); removed > 0 {
+		n.invalidations.Add(uint64(removed))
+	}
+}
+
+// reset 清空本地缓存并使进行中的回填失效
+func (n *Near) reset() {
+	n.local.purge()
+}
+
+// run 持续订阅失效广播，底层客户端重连导致订阅关闭时重新订阅
+func (n *Near) run(ctx context.Context) {
+	defer close(n.done)
+
+	for {
+		pubsub := n.client.UniversalClient().Subscribe(ctx, n.channel)
+		n.consume(ctx, pubsub.ChannelWithSubscriptions())
+		n.subscribed.Store(false)
+		n.reset()
+		_ = pubsub.Close()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(nearResubscribeDelay):
+		}
+	}
+}
+
+// consume 处理订阅消息，直到 ctx 取消或订阅关闭
+func (n *Near) consume(ctx context.Context, ch <-chan interface{}) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				if m.Kind == "subscribe" {
+					// 首次订阅或重连后重新订阅，期间可能错过广播
+					n.reset()
+					n.subscribed.Store(true)
+				}
+			case *redis.Message:
+				n.handle(m.Payload)
+			}
+		}
+	}
+}
+
+// handle 处理一条失效广播
+func (n *Near) handle(payload string) {
+	var keys []string
+	if err := json.Unmarshal([]byte(payload), &keys); err != nil {
+		logger.Warnf("cache.near: ignoring malformed invalidation %q: %v", payload, err)
+		return
+	}
+	n.evict(keys)
+}
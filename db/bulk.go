@@ -0,0 +1,299 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// 批量写入默认配置
+const (
+	DefaultBulkBatchSize = 1000 // 每条 INSERT 语句的默认行数
+)
+
+// maxBulkPlaceholders 返回单条语句的绑定参数上限
+// MySQL 和 PostgreSQL 为 65535，SQLite 默认 SQLITE_MAX_VARIABLE_NUMBER 为 32766
+func maxBulkPlaceholders(dialect Dialect) int {
+	if dialect == DialectSQLite {
+		return 32766
+	}
+	return 65535
+}
+
+// RowSource 批量写入的数据源，逐行读取，内存占用与总行数无关
+// 方法集与 pgx.CopyFromSource 一致，可直接用于 PostgreSQL COPY
+type RowSource interface {
+	// Next 移动到下一行，没有更多行或出错时返回 false
+	Next() bool
+
+	// Values 返回当前行的列值，顺序与列名一致
+	Values() ([]interface{}, error)
+
+	// Err 返回迭代过程中的错误
+	Err() error
+}
+
+// RowsFromSlice 返回遍历内存中各行的 RowSource
+func RowsFromSlice(rows [][]interface{}) RowSource {
+	return &sliceSource{rows: rows, idx: -1}
+}
+
+// RowsFromFunc 返回逐行调用 next 的 RowSource，next 返回 io.EOF 表示结束
+func RowsFromFunc(next func() ([]interface{}, error)) RowSource {
+	return &funcSource{next: next}
+}
+
+// sliceSource 遍历切片的 RowSource
+type sliceSource struct {
+	rows [][]interface{}
+	idx  int
+}
+
+func (s *sliceSource) Next() bool {
+	s.idx++
+	return s.idx < len(s.rows)
+}
+
+func (s *sliceSource) Values() ([]interface{}, error) {
+	return s.rows[s.idx], nil
+}
+
+func (s *sliceSource) Err() error {
+	return nil
+}
+
+// funcSource 调用函数逐行读取的 RowSource
+type funcSource struct {
+	next func() ([]interface{}, error)
+	cur  []interface{}
+	err  error
+}
+
+func (s *funcSource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	s.cur, s.err = s.next()
+	if s.err == io.EOF {
+		s.err = nil
+		s.next = func() ([]interface{}, error) { return nil, io.EOF }
+		return false
+	}
+	return s.err == nil
+}
+
+func (s *funcSource) Values() ([]interface{}, error) {
+	return s.cur, nil
+}
+
+func (s *funcSource) Err() error {
+	return s.err
+}
+
+// Execer 可执行语句的对象，SQLClient 和 Tx 均满足
+type Execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) error
+}
+
+// BulkOptions 批量写入配置
+type BulkOptions struct {
+	BatchSize       int      // 每条语句的行数，默认 1000，并受单条语句 65535 个绑定参数的限制
+	ConflictColumns []string // Upsert 判断冲突的列（唯一键），PostgreSQL 和 SQLite 必须指定，MySQL 按表上的唯一键判断
+	UpdateColumns   []string // Upsert 冲突时更新的列，nil 表示除冲突列外的全部列，空切片表示冲突行保持不变
+}
+
+// BulkOption 批量写入配置选项函数
+type BulkOption func(*BulkOptions)
+
+// WithBatchSize 设置每条语句的行数
+func WithBatchSize(n int) BulkOption {
+	return func(opts *BulkOptions) {
+		opts.BatchSize = n
+	}
+}
+
+// WithConflictColumns 设置 Upsert 判断冲突的列
+func WithConflictColumns(columns ...string) BulkOption {
+	return func(opts *BulkOptions) {
+		opts.ConflictColumns = columns
+	}
+}
+
+// WithUpdateColumns 设置 Upsert 冲突时更新的列，不传参数时冲突行保持不变
+func WithUpdateColumns(columns ...string) BulkOption {
+	return func(opts *BulkOptions) {
+		opts.UpdateColumns = append([]string{}, columns...)
+	}
+}
+
+// BulkInsert 将 rows 按批拼接为多行 INSERT 语句写入 table，返回写入的行数
+// 各批次分别执行，需要原子性时在事务中调用（e 传入 Tx）
+func BulkInsert(ctx context.Context, e Execer, dialect Dialect, table string, columns []string, rows RowSource, opts ...BulkOption) (int64, error) {
+	return bulkWrite(ctx, e, dialect, table, columns, rows, false, opts)
+}
+
+// Upsert 按批写入 rows，与已有记录冲突时更新，返回写入的行数
+// MySQL 生成 ON DUPLICATE KEY UPDATE col = VALUES(col)，
+// PostgreSQL 和 SQLite 生成 ON CONFLICT (...) DO UPDATE SET col = excluded.col
+func Upsert(ctx context.Context, e Execer, dialect Dialect, table string, columns []string, rows RowSource, opts ...BulkOption) (int64, error) {
+	return bulkWrite(ctx, e, dialect, table, columns, rows, true, opts)
+}
+
+// bulkWrite 按批读取 rows 并执行 INSERT 或 Upsert
+func bulkWrite(ctx context.Context, e Execer, dialect Dialect, table string, columns []string, rows RowSource, upsert bool, opts []BulkOption) (int64, error) {
+	if e == nil {
+		return 0, errors.ErrNilClient
+	}
+	if table == "" || len(columns) == 0 || rows == nil {
+		return 0, errors.Wrap(errors.ErrInvalidParameter, "db.bulk: table, columns and rows are required")
+	}
+
+	o := BulkOptions{BatchSize: DefaultBulkBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultBulkBatchSize
+	}
+	if limit := maxBulkPlaceholders(dialect) / len(columns); o.BatchSize > limit {
+		o.BatchSize = limit
+	}
+
+	var suffix string
+	if upsert {
+		if o.UpdateColumns == nil {
+			o.UpdateColumns = excludeColumns(columns, o.ConflictColumns)
+		}
+		var err error
+		if suffix, err = upsertClause(dialect, columns, o); err != nil {
+			return 0, err
+		}
+	}
+
+	prefix := insertPrefix(dialect, table, columns)
+	var (
+		total int64
+		args  = make([]interface{}, 0, o.BatchSize*len(columns))
+		n     int
+	)
+	flush := func() error {
+		if n == 0 {
+			return nil
+		}
+		query := prefix + valuesList(dialect, len(columns), n) + suffix
+		if err := e.Exec(ctx, query, args...); err != nil {
+			return errors.Wrapf(err, "db.bulk: rows %d-%d", total+1, total+int64(n))
+		}
+		total += int64(n)
+		args, n = args[:0], 0
+		return nil
+	}
+
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return total, errors.Wrap(err, "db.bulk: read row")
+		}
+		if len(values) != len(columns) {
+			return total, errors.Wrap(errors.ErrInvalidParameter,
+				fmt.Sprintf("db.bulk: row %d has %d values, want %d", total+int64(n)+1, len(values), len(columns)))
+		}
+		args = append(args, values...)
+		n++
+		if n == o.BatchSize {
+			if err := flush(); err != nil {
+				return total, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return total, errors.Wrap(err, "db.bulk: read row")
+	}
+	return total, flush()
+}
+
+// insertPrefix 返回 INSERT INTO table (columns) VALUES
+func insertPrefix(dialect Dialect, table string, columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = dialect.QuoteIdent(c)
+	}
+	return "INSERT INTO " + dialect.QuoteIdent(table) + " (" + strings.Join(quoted, ", ") + ") VALUES "
+}
+
+// valuesList 返回 rows 行、每行 cols 个占位符的 VALUES 列表
+func valuesList(dialect Dialect, cols, rows int) string {
+	var b strings.Builder
+	b.Grow(rows * cols * 4)
+	p := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(dialect.Placeholder(p))
+			p++
+		}
+		b.WriteByte(')')
+	}
+	return b.String()
+}
+
+// upsertClause 返回方言对应的冲突处理子句
+func upsertClause(dialect Dialect, columns []string, o BulkOptions) (string, error) {
+	set := make([]string, len(o.UpdateColumns))
+	switch dialect {
+	case DialectMySQL:
+		for i, c := range o.UpdateColumns {
+			q := dialect.QuoteIdent(c)
+			set[i] = q + " = VALUES(" + q + ")"
+		}
+		if len(set) == 0 {
+			// 冲突行保持不变
+			q := dialect.QuoteIdent(columns[0])
+			set = append(set, q+" = "+q)
+		}
+		return " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", "), nil
+	case DialectPostgres, DialectSQLite:
+		if len(o.ConflictColumns) == 0 {
+			return "", errors.Wrap(errors.ErrInvalidParameter, "db.bulk: conflict columns are required for "+string(dialect))
+		}
+		conflict := make([]string, len(o.ConflictColumns))
+		for i, c := range o.ConflictColumns {
+			conflict[i] = dialect.QuoteIdent(c)
+		}
+		target := " ON CONFLICT (" + strings.Join(conflict, ", ") + ")"
+		if len(set) == 0 {
+			return target + " DO NOTHING", nil
+		}
+		for i, c := range o.UpdateColumns {
+			q := dialect.QuoteIdent(c)
+			set[i] = q + " = excluded." + q
+		}
+		return target + " DO UPDATE SET " + strings.Join(set, ", "), nil
+	default:
+		return "", errors.Wrap(errors.ErrInvalidParameter, "db.bulk: unsupported dialect "+string(dialect))
+	}
+}
+
+// excludeColumns 返回 columns 中不在 exclude 内的列
+func excludeColumns(columns, exclude []string) []string {
+	skip := make(map[string]struct{}, len(exclude))
+	for _, c := range exclude {
+		skip[c] = struct{}{}
+	}
+	var out []string
+	for _, c := range columns {
+		if _, ok := skip[c]; !ok {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,161 @@
+package db_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// execRecorder 记录执行的语句和参数个数
+type execRecorder struct {
+	queries []string
+	args    []int
+}
+
+func (r *execRecorder) Exec(ctx context.Context, query string, args ...interface{}) error {
+	r.queries = append(r.queries, query)
+	r.args = append(r.args, len(args))
+	return nil
+}
+
+func TestBulkInsertBatches(t *testing.T) {
+	i := 0
+	rows := db.RowsFromFunc(func() ([]interface{}, error) {
+		if i == 5 {
+			return nil, io.EOF
+		}
+		i++
+		return []interface{}{i, "n"}, nil
+	})
+
+	rec := &execRecorder{}
+	n, err := db.BulkInsert(context.Background(), rec, db.DialectPostgres, "app.users", []string{"id", "name"}, rows, db.WithBatchSize(2))
+	if err != nil || n != 5 {
+		t.Fatalf("BulkInsert() = %d, %v", n, err)
+	}
+	if len(rec.queries) != 3 || rec.args[0] != 4 || rec.args[2] != 2 {
+		t.Fatalf("batches = %v, args = %v", rec.queries, rec.args)
+	}
+	want := `INSERT INTO "app"."users" ("id", "name") VALUES ($1, $2), ($3, $4)`
+	if rec.queries[0] != want {
+		t.Errorf("query = %s, want %s", rec.queries[0], want)
+	}
+}
+
+func TestUpsertClauses(t *testing.T) {
+	rows := func() db.RowSource { return db.RowsFromSlice([][]interface{}{{1, "a", 10}}) }
+	cols := []string{"id", "name", "score"}
+	ctx := context.Background()
+
+	tests := []struct {
+		dialect db.Dialect
+		opts    []db.BulkOption
+		suffix  string
+	}{
+		{db.DialectMySQL, nil, " ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`), `score` = VALUES(`score`)"},
+		{db.DialectMySQL, []db.BulkOption{db.WithUpdateColumns()}, " ON DUPLICATE KEY UPDATE `id` = `id`"},
+		{db.DialectPostgres, []db.BulkOption{db.WithConflictColumns("id")}, ` ON CONFLICT ("id") DO UPDATE SET "name" = excluded."name", "score" = excluded."score"`},
+		{db.DialectPostgres, []db.BulkOption{db.WithConflictColumns("id"), db.WithUpdateColumns("score")}, ` ON CONFLICT ("id") DO UPDATE SET "score" = excluded."score"`},
+		{db.DialectPostgres, []db.BulkOption{db.WithConflictColumns("id"), db.WithUpdateColumns()}, ` ON CONFLICT ("id") DO NOTHING`},
+	}
+	for _, tt := range tests {
+		rec := &execRecorder{}
+		if _, err := db.Upsert(ctx, rec, tt.dialect, "t", cols, rows(), tt.opts...); err != nil {
+			t.Fatalf("Upsert(%s) returned error: %v", tt.dialect, err)
+		}
+		if !strings.HasSuffix(rec.queries[0], tt.suffix) {
+			t.Errorf("Upsert(%s) = %s, want suffix %s", tt.dialect, rec.queries[0], tt.suffix)
+		}
+	}
+
+	if _, err := db.Upsert(ctx, &execRecorder{}, db.DialectPostgres, "t", cols, rows()); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("Upsert() without conflict columns = %v", err)
+	}
+	if _, err := db.BulkInsert(ctx, &execRecorder{}, db.DialectMySQL, "t", cols, db.RowsFromSlice([][]interface{}{{1}})); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("BulkInsert() with short row = %v", err)
+	}
+}
+
+func TestUpsertSQLite(t *testing.T) {
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Exec(ctx, "CREATE TABLE score (id INTEGER PRIMARY KEY, name TEXT, points INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	cols := []string{"id", "name", "points"}
+	if _, err := db.BulkInsert(ctx, client, db.DialectSQLite, "score", cols, db.RowsFromSlice([][]interface{}{{1, "a", 1}, {2, "b", 2}, {3, "c", 3}})); err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.WithTx(ctx, client, func(tx db.Tx) error {
+		_, err := db.Upsert(ctx, tx, db.DialectSQLite, "score", cols,
+			db.RowsFromSlice([][]interface{}{{2, "b", 20}, {4, "d", 4}}),
+			db.WithConflictColumns("id"), db.WithUpdateColumns("points"), db.WithBatchSize(1))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count, points int
+	if err := client.QueryRow(ctx, "SELECT COUNT(*), SUM(points) FROM score").Scan(&count, &points); err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 || points != 1+20+3+4 {
+		t.Errorf("count = %d, points = %d", count, points)
+	}
+}
+
+func TestBulkInsertSQLitePlaceholderLimit(t *testing.T) {
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Exec(ctx, "CREATE TABLE pair (a INTEGER, b INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	// 单批 20000 行共 40000 个参数，超过 SQLite 的 32766 上限，需按方言拆分
+	const total = 20000
+	newRows := func() db.RowSource {
+		i := 0
+		return db.RowsFromFunc(func() ([]interface{}, error) {
+			if i == total {
+				return nil, io.EOF
+			}
+			i++
+			return []interface{}{i, i}, nil
+		})
+	}
+	n, err := db.BulkInsert(ctx, client, db.DialectSQLite, "pair", []string{"a", "b"}, newRows(), db.WithBatchSize(total))
+	if err != nil || n != total {
+		t.Fatalf("BulkInsert() = %d, %v", n, err)
+	}
+	var count int
+	if err := client.QueryRow(ctx, "SELECT COUNT(*) FROM pair").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != total {
+		t.Errorf("count = %d, want %d", count, total)
+	}
+
+	rec := &execRecorder{}
+	if _, err := db.BulkInsert(ctx, rec, db.DialectSQLite, "pair", []string{"a", "b"}, newRows(), db.WithBatchSize(total)); err != nil {
+		t.Fatal(err)
+	}
+	if len(rec.args) != 2 || rec.args[0] != 32766 {
+		t.Errorf("args = %v", rec.args)
+	}
+}
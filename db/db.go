@@ -16,6 +16,9 @@ type Client interface {
 
 	// IsConnected 检查是否已连接
 	IsConnected() bool
+
+	// Stats 返回连接池统计
+	Stats() Stats
 }
 
 // SQLClient SQL 数据库客户端接口
@@ -53,6 +56,7 @@ type Rows interface {
 type Tx interface {
 	Commit() error
 	Rollback() error
+
 	Exec(ctx context.Context, sql string, args ...interface{}) error
 	QueryRow(ctx context.Context, sql string, args ...interface{}) Row
 	Query(ctx context.Context, sql string, args ...interface{}) (Rows, error)
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect SQL 方言
+type Dialect string
+
+const (
+	// DialectMySQL MySQL 方言
+	DialectMySQL Dialect = "mysql"
+	// DialectPostgres PostgreSQL 方言
+	DialectPostgres Dialect = "postgres"
+	// DialectSQLite SQLite 方言
+	DialectSQLite Dialect = "sqlite"
+)
+
+// DialectProvider 可报告自身 SQL 方言的客户端
+type DialectProvider interface {
+	Dialect() Dialect
+}
+
+// Placeholder 返回第 n 个（从 1 开始）绑定参数占位符
+// PostgreSQL 为 $n，其他方言为 ?
+func (d Dialect) Placeholder(n int) string {
+	if d == DialectPostgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// QuoteIdent 引用标识符（表名、列名），支持 schema.table 形式
+func (d Dialect) QuoteIdent(name string) string {
+	quote := `"`
+	if d == DialectMySQL {
+		quote = "`"
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,140 @@
+// Package gormlog 提供 gorm 日志适配器，通过 gosuite logger 输出 SQL 日志
+//
+// 每条 SQL 记录为结构化日志，包含 SQL 语句、影响行数、耗时和业务代码调用位置：
+// 执行出错记为 error，超过慢查询阈值记为 warn，LogLevel 为 Info 时其余语句记为 info。
+package gormlog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// 确保 Logger 实现 gorm 日志接口和参数过滤接口
+var (
+	_ gormlogger.Interface = (*Logger)(nil)
+	_ gorm.ParamsFilter    = (*Logger)(nil)
+)
+
+// DefaultSlowThreshold 默认慢查询阈值
+const DefaultSlowThreshold = 200 * time.Millisecond
+
+// Config 日志适配器配置
+type Config struct {
+	SlowThreshold             time.Duration       // 慢查询阈值，默认 200ms，负数表示不记录慢查询
+	LogLevel                  gormlogger.LogLevel // 日志级别，默认 Warn（记录错误和慢查询）
+	IgnoreRecordNotFoundError bool                // 是否忽略 gorm.ErrRecordNotFound 错误
+	Redact                    bool                // 是否隐藏参数值，开启后 SQL 只保留占位符
+}
+
+// Logger gorm 日志适配器
+type Logger struct {
+	conf   Config
+	output func() zerolog.Logger
+}
+
+// New 创建 gorm 日志适配器，conf 为 nil 时使用默认配置
+func New(conf *Config) *Logger {
+	l := &Logger{output: logger.Logger}
+	if conf != nil {
+		l.conf = *conf
+	}
+	if l.conf.SlowThreshold == 0 {
+		l.conf.SlowThreshold = DefaultSlowThreshold
+	}
+	if l.conf.LogLevel == 0 {
+		l.conf.LogLevel = gormlogger.Warn
+	}
+	return l
+}
+
+// LogMode 返回指定日志级别的副本
+func (l *Logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.conf.LogLevel = level
+	return &clone
+}
+
+// Info 输出 info 级别日志
+func (l *Logger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.conf.LogLevel >= gormlogger.Info {
+		l.event(zerolog.InfoLevel).Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn 输出 warn 级别日志
+func (l *Logger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.conf.LogLevel >= gormlogger.Warn {
+		l.event(zerolog.WarnLevel).Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error 输出 error 级别日志
+func (l *Logger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.conf.LogLevel >= gormlogger.Error {
+		l.event(zerolog.ErrorLevel).Msg(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace 记录一条 SQL 的执行结果
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.conf.LogLevel <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	slow := l.conf.SlowThreshold > 0 && elapsed > l.conf.SlowThreshold
+
+	var (
+		level zerolog.Level
+		msg   string
+	)
+	switch {
+	case err != nil && l.conf.LogLevel >= gormlogger.Error &&
+		!(l.conf.IgnoreRecordNotFoundError && errors.Is(err, gorm.ErrRecordNotFound)):
+		level, msg = zerolog.ErrorLevel, "gorm query failed"
+	case slow && l.conf.LogLevel >= gormlogger.Warn:
+		level, msg = zerolog.WarnLevel, "gorm slow query"
+	case l.conf.LogLevel >= gormlogger.Info:
+		level, msg = zerolog.InfoLevel, "gorm query"
+	default:
+		return
+	}
+
+	sql, rows := fc()
+	event := l.event(level).
+		Str("sql", sql).
+		Float64("elapsed_ms", float64(elapsed.Nanoseconds())/1e6).
+		Str("caller", utils.FileWithLineNum())
+	if rows >= 0 {
+		event = event.Int64("rows", rows)
+	}
+	if level == zerolog.ErrorLevel {
+		event = event.Err(err)
+	}
+	if slow {
+		event = event.Dur("slow_threshold", l.conf.SlowThreshold)
+	}
+	event.Msg(msg)
+}
+
+// ParamsFilter 开启 Redact 时丢弃参数值，日志中的 SQL 保留占位符
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.conf.Redact {
+		return sql, nil
+	}
+	return sql, params
+}
+
+// event 创建指定级别的日志事件
+func (l *Logger) event(level zerolog.Level) *zerolog.Event {
+	out := l.output()
+	return out.WithLevel(level)
+}
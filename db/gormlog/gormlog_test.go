@@ -0,0 +1,112 @@
+package gormlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestLogger(conf *Config) (*Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l := New(conf)
+	l.output = func() zerolog.Logger { return zerolog.New(buf) }
+	return l, buf
+}
+
+func decode(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	if buf.Len() == 0 {
+		return nil
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+	}
+	buf.Reset()
+	return entry
+}
+
+func TestTraceLevels(t *testing.T) {
+	fc := func() (string, int64) { return "SELECT * FROM user WHERE id = 1", 1 }
+
+	tests := []struct {
+		name    string
+		conf    *Config
+		elapsed time.Duration
+		err     error
+		level   string
+	}{
+		{"fast query at warn", nil, 0, nil, ""},
+		{"fast query at info", &Config{LogLevel: gormlogger.Info}, 0, nil, "info"},
+		{"slow query", &Config{SlowThreshold: time.Millisecond}, 10 * time.Millisecond, nil, "warn"},
+		{"slow query disabled", &Config{SlowThreshold: -1}, 10 * time.Millisecond, nil, ""},
+		{"error", nil, 0, errors.New("boom"), "error"},
+		{"ignored not found", &Config{IgnoreRecordNotFoundError: true}, 0, gorm.ErrRecordNotFound, ""},
+		{"silent", &Config{LogLevel: gormlogger.Silent}, 0, errors.New("boom"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, buf := newTestLogger(tt.conf)
+			l.Trace(context.Background(), time.Now().Add(-tt.elapsed), fc, tt.err)
+
+			entry := decode(t, buf)
+			if tt.level == "" {
+				if entry != nil {
+					t.Fatalf("expected no log, got %v", entry)
+				}
+				return
+			}
+			if entry == nil {
+				t.Fatalf("expected %s log, got none", tt.level)
+			}
+			if entry["level"] != tt.level {
+				t.Errorf("level = %v, want %s", entry["level"], tt.level)
+			}
+			if entry["sql"] != "SELECT * FROM user WHERE id = 1" {
+				t.Errorf("sql = %v", entry["sql"])
+			}
+			if entry["rows"] != float64(1) {
+				t.Errorf("rows = %v", entry["rows"])
+			}
+			if !strings.Contains(entry["caller"].(string), ":") {
+				t.Errorf("caller = %v", entry["caller"])
+			}
+		})
+	}
+}
+
+func TestLogMode(t *testing.T) {
+	l, buf := newTestLogger(nil)
+
+	l.Info(context.Background(), "hello %s", "gorm")
+	if entry := decode(t, buf); entry != nil {
+		t.Fatalf("expected info suppressed at warn level, got %v", entry)
+	}
+
+	l.LogMode(gormlogger.Info).Info(context.Background(), "hello %s", "gorm")
+	entry := decode(t, buf)
+	if entry == nil || entry["message"] != "hello gorm" {
+		t.Fatalf("unexpected entry %v", entry)
+	}
+}
+
+func TestParamsFilter(t *testing.T) {
+	l := New(nil)
+	if _, params := l.ParamsFilter(context.Background(), "SELECT ?", 1); len(params) != 1 {
+		t.Errorf("expected params kept, got %v", params)
+	}
+
+	l = New(&Config{Redact: true})
+	if _, params := l.ParamsFilter(context.Background(), "SELECT ?", "secret"); params != nil {
+		t.Errorf("expected params redacted, got %v", params)
+	}
+}
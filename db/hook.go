@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// QueryEvent 一次数据库操作的信息
+type QueryEvent struct {
+	System    string        // 数据库类型，如 "mysql"、"postgres"、"sqlite"、"redis"
+	Operation string        // 操作名，SQL 为 "exec"、"query"、"tx.commit" 等，Redis 为命令名或 "pipeline"
+	Statement string        // SQL 语句或 Redis 命令
+	Args      []interface{} // SQL 参数，Redis 命令的参数已包含在 Statement 中
+	Start     time.Time     // 开始时间
+	Duration  time.Duration // 耗时，After 中有效
+	Err       error         // 操作错误，After 中有效；redis.Nil 等表示"无结果"的错误不记录
+}
+
+// Hook 数据库操作钩子，在 SQL 客户端的 Exec/Query/QueryRow/Begin、事务内操作和每条 Redis 命令前后调用
+// 通过 DB() 获取的 gorm 连接上的操作不经过钩子
+type Hook interface {
+	// Before 在操作执行前调用，返回的 ctx 用于执行操作和调用同一钩子的 After
+	Before(ctx context.Context, e *QueryEvent) context.Context
+
+	// After 在操作完成后调用
+	After(ctx context.Context, e *QueryEvent)
+}
+
+// RunHooks 按顺序调用钩子的 Before，执行 fn，再按逆序调用 After
+func RunHooks(ctx context.Context, hooks []Hook, e *QueryEvent, fn func(ctx context.Context) error) error {
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+
+	ctxs := make([]context.Context, len(hooks))
+	e.Start = time.Now()
+	for i, h := range hooks {
+		ctx = h.Before(ctx, e)
+		ctxs[i] = ctx
+	}
+
+	err := fn(ctx)
+
+	e.Duration = time.Since(e.Start)
+	e.Err = err
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].After(ctxs[i], e)
+	}
+	return err
+}
@@ -0,0 +1,227 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/rs/zerolog"
+)
+
+// fakeSpan 记录属性和错误的片段
+type fakeSpan struct {
+	name  string
+	attrs map[string]interface{}
+	err   error
+	ended bool
+}
+
+func (s *fakeSpan) SetAttribute(key string, value interface{}) { s.attrs[key] = value }
+func (s *fakeSpan) RecordError(err error)                      { s.err = err }
+func (s *fakeSpan) End()                                       { s.ended = true }
+
+// fakeTracer 记录创建的片段
+type fakeTracer struct {
+	mu    sync.Mutex
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := &fakeSpan{name: name, attrs: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTracingSQLite(t *testing.T) {
+	tracer := &fakeTracer{}
+	client, err := sqlite.NewClient(&sqlite.Config{Hooks: []db.Hook{Tracing(tracer)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Exec(ctx, "CREATE TABLE kv (k TEXT PRIMARY KEY, v TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return tx.Exec(ctx, "INSERT INTO kv (k, v) VALUES (?, ?)", "a", "1")
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var v string
+	if err := client.QueryRow(ctx, "SELECT v FROM kv WHERE k = ?", "a").Scan(&v); err != nil || v != "1" {
+		t.Fatalf("QueryRow() = %q, %v", v, err)
+	}
+	if err := client.Exec(ctx, "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Fatal("expected error for missing table")
+	}
+
+	want := []string{"sqlite.exec", "sqlite.begin", "sqlite.tx.exec", "sqlite.tx.commit", "sqlite.query_row", "sqlite.exec"}
+	if len(tracer.spans) != len(want) {
+		names := make([]string, len(tracer.spans))
+		for i, s := range tracer.spans {
+			names[i] = s.name
+		}
+		t.Fatalf("spans = %v, want %v", names, want)
+	}
+	for i, s := range tracer.spans {
+		if s.name != want[i] || !s.ended {
+			t.Errorf("span %d = %s (ended %v), want %s", i, s.name, s.ended, want[i])
+		}
+		if s.attrs[AttrSystem] != "sqlite" {
+			t.Errorf("span %s system = %v", s.name, s.attrs[AttrSystem])
+		}
+	}
+	if got := tracer.spans[4].attrs[AttrStatement]; got != "SELECT v FROM kv WHERE k = ?" {
+		t.Errorf("statement = %v", got)
+	}
+	if tracer.spans[5].err == nil {
+		t.Error("expected error recorded on failed exec span")
+	}
+}
+
+func TestTxHooksContext(t *testing.T) {
+	seen := map[string]interface{}{}
+	h := hookFunc{after: func(ctx context.Context, e *db.QueryEvent) { seen[e.Operation] = ctx.Value(ctxKey("req")) }}
+	client, err := sqlite.NewClient(&sqlite.Config{Hooks: []db.Hook{h}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// 提交、回滚和保存点操作的钩子收到开启事务或保存点时的 ctx
+	ctx := context.WithValue(context.Background(), ctxKey("req"), "r1")
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		_ = db.WithTx(ctx, tx, func(inner db.Tx) error { return errors.New("rollback") })
+		return db.WithTx(ctx, tx, func(inner db.Tx) error { return nil })
+	}); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.WithTx(ctx, client, func(tx db.Tx) error { return errors.New("rollback") })
+
+	for _, op := range []string{"begin", "tx.savepoint", "tx.rollback_savepoint", "tx.release_savepoint", "tx.commit", "tx.rollback"} {
+		if seen[op] != "r1" {
+			t.Errorf("%s hook ctx value = %v, want r1", op, seen[op])
+		}
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []SlowQueryOption
+		duration time.Duration
+		err      error
+		level    string
+	}{
+		{"fast", nil, time.Millisecond, nil, ""},
+		{"slow", nil, time.Second, nil, "warn"},
+		{"error ignored by default", nil, time.Millisecond, errors.New("boom"), ""},
+		{"error logged", []SlowQueryOption{WithErrorLogging()}, time.Millisecond, errors.New("boom"), "error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			h := SlowQuery(100*time.Millisecond, tt.opts...)
+			h.output = func() zerolog.Logger { return zerolog.New(buf) }
+
+			e := &db.QueryEvent{System: "redis", Operation: "get", Statement: "get k", Duration: tt.duration, Err: tt.err}
+			h.After(h.Before(context.Background(), e), e)
+
+			if tt.level == "" {
+				if buf.Len() != 0 {
+					t.Errorf("unexpected log %s", buf.String())
+				}
+				return
+			}
+			var entry map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+			}
+			if entry["level"] != tt.level || entry["system"] != "redis" || entry["operation"] != "get" {
+				t.Errorf("unexpected entry %v", entry)
+			}
+		})
+	}
+}
+
+func TestSlowQueryTruncate(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := SlowQuery(time.Millisecond, WithStatementLimit(8))
+	h.output = func() zerolog.Logger { return zerolog.New(buf) }
+
+	h.After(context.Background(), &db.QueryEvent{Statement: strings.Repeat("x", 100), Duration: time.Second})
+	if !strings.Contains(buf.String(), `"statement":"xxxxxxxx..."`) {
+		t.Errorf("statement not truncated: %s", buf.String())
+	}
+	// 不在多字节字符中间截断
+	if got := truncate("中文语句", 7); got != "中文..." {
+		t.Errorf("truncate() = %q, want %q", got, "中文...")
+	}
+}
+
+func TestRunHooksOrder(t *testing.T) {
+	var calls []string
+	mk := func(name string) db.Hook {
+		return hookFunc{
+			before: func(ctx context.Context) context.Context {
+				calls = append(calls, "before "+name)
+				return context.WithValue(ctx, ctxKey(name), name)
+			},
+			after: func(ctx context.Context, e *db.QueryEvent) {
+				calls = append(calls, "after "+name+" "+ctx.Value(ctxKey(name)).(string))
+			},
+		}
+	}
+
+	boom := errors.New("boom")
+	var seen *db.QueryEvent
+	err := db.RunHooks(context.Background(), []db.Hook{mk("a"), mk("b"), hookFunc{after: func(ctx context.Context, e *db.QueryEvent) { seen = e }}},
+		&db.QueryEvent{Operation: "exec"}, func(ctx context.Context) error {
+			if ctx.Value(ctxKey("a")) == nil || ctx.Value(ctxKey("b")) == nil {
+				t.Error("operation ctx should carry values from Before")
+			}
+			return boom
+		})
+	if !errors.Is(err, boom) || seen == nil || !errors.Is(seen.Err, boom) || seen.Start.IsZero() {
+		t.Fatalf("RunHooks() = %v, event %+v", err, seen)
+	}
+	want := "before a,before b,after b b,after a a"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+// ctxKey 测试用的 ctx 键
+type ctxKey string
+
+// hookFunc 由函数构成的钩子
+type hookFunc struct {
+	before func(ctx context.Context) context.Context
+	after  func(ctx context.Context, e *db.QueryEvent)
+}
+
+func (h hookFunc) Before(ctx context.Context, e *db.QueryEvent) context.Context {
+	if h.before == nil {
+		return ctx
+	}
+	return h.before(ctx)
+}
+
+func (h hookFunc) After(ctx context.Context, e *db.QueryEvent) {
+	if h.after != nil {
+		h.after(ctx, e)
+	}
+}
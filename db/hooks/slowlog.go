@@ -0,0 +1,112 @@
+// Package hooks 提供内置的数据库操作钩子：慢查询日志和链路追踪
+//
+// 钩子通过各客户端配置的 Hooks 字段或 AddHook 方法注册，对 SQL 客户端和 Redis 客户端均生效：
+//
+//	client.AddHook(hooks.SlowQuery(100 * time.Millisecond))
+//	client.AddHook(hooks.Tracing(tracer))
+package hooks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/rs/zerolog"
+)
+
+// 确保 SlowQueryHook 实现 db.Hook 接口
+var _ db.Hook = (*SlowQueryHook)(nil)
+
+const (
+	// DefaultSlowThreshold 默认慢查询阈值
+	DefaultSlowThreshold = 200 * time.Millisecond
+
+	// DefaultStatementLimit 日志中语句的默认最大长度
+	DefaultStatementLimit = 1024
+)
+
+// SlowQueryOption 慢查询钩子选项
+type SlowQueryOption func(*SlowQueryHook)
+
+// WithStatementLimit 设置日志中语句的最大长度，超出部分截断，0 表示不截断
+func WithStatementLimit(n int) SlowQueryOption {
+	return func(h *SlowQueryHook) {
+		h.limit = n
+	}
+}
+
+// WithErrorLogging 同时以 error 级别记录执行失败的操作
+func WithErrorLogging() SlowQueryOption {
+	return func(h *SlowQueryHook) {
+		h.logErrors = true
+	}
+}
+
+// SlowQueryHook 通过 gosuite logger 记录耗时超过阈值的操作
+type SlowQueryHook struct {
+	threshold time.Duration
+	limit     int
+	logErrors bool
+	output    func() zerolog.Logger
+}
+
+// SlowQuery 创建慢查询日志钩子，threshold 不大于 0 时使用默认阈值 200ms
+func SlowQuery(threshold time.Duration, opts ...SlowQueryOption) *SlowQueryHook {
+	if threshold <= 0 {
+		threshold = DefaultSlowThreshold
+	}
+	h := &SlowQueryHook{
+		threshold: threshold,
+		limit:     DefaultStatementLimit,
+		output:    logger.Logger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Before 实现 db.Hook
+func (h *SlowQueryHook) Before(ctx context.Context, e *db.QueryEvent) context.Context {
+	return ctx
+}
+
+// After 耗时超过阈值时记为 warn，开启 WithErrorLogging 后失败的操作记为 error
+func (h *SlowQueryHook) After(ctx context.Context, e *db.QueryEvent) {
+	var (
+		level zerolog.Level
+		msg   string
+	)
+	switch {
+	case e.Err != nil && h.logErrors:
+		level, msg = zerolog.ErrorLevel, "db operation failed"
+	case e.Duration > h.threshold:
+		level, msg = zerolog.WarnLevel, "db slow operation"
+	default:
+		return
+	}
+
+	out := h.output()
+	event := out.WithLevel(level).
+		Str("system", e.System).
+		Str("operation", e.Operation).
+		Str("statement", truncate(e.Statement, h.limit)).
+		Float64("elapsed_ms", float64(e.Duration.Nanoseconds())/1e6)
+	if e.Err != nil {
+		event = event.Err(e.Err)
+	}
+	if e.Duration > h.threshold {
+		event = event.Dur("slow_threshold", h.threshold)
+	}
+	event.Msg(msg)
+}
+
+// truncate 截断超过 limit 字节的字符串，不会截断在多字节字符中间
+func truncate(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	return strings.ToValidUTF8(s[:limit], "") + "..."
+}
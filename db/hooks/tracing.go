@@ -0,0 +1,72 @@
+package hooks
+
+import (
+	"context"
+
+	"github.com/hyperits/gosuite/db"
+)
+
+// Span 一次操作对应的追踪片段
+type Span interface {
+	// SetAttribute 设置属性
+	SetAttribute(key string, value interface{})
+
+	// RecordError 记录错误并将片段标记为失败
+	RecordError(err error)
+
+	// End 结束片段
+	End()
+}
+
+// Tracer 链路追踪的抽象，可适配 OpenTelemetry 等实现
+type Tracer interface {
+	// Start 以 ctx 中的片段为父片段创建新片段，返回携带新片段的 ctx
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// 片段属性名，与 OpenTelemetry 数据库语义约定一致
+const (
+	AttrSystem    = "db.system"
+	AttrOperation = "db.operation"
+	AttrStatement = "db.statement"
+)
+
+// 确保 TracingHook 实现 db.Hook 接口
+var _ db.Hook = (*TracingHook)(nil)
+
+// spanKey 在 ctx 中保存当前钩子创建的片段
+type spanKey struct {
+	hook *TracingHook
+}
+
+// TracingHook 为每次操作创建追踪片段
+type TracingHook struct {
+	tracer Tracer
+	limit  int
+}
+
+// Tracing 创建链路追踪钩子，片段名为 "<system>.<operation>"，如 "mysql.query"、"redis.get"
+func Tracing(tracer Tracer) *TracingHook {
+	return &TracingHook{tracer: tracer, limit: DefaultStatementLimit}
+}
+
+// Before 创建片段并设置数据库属性
+func (h *TracingHook) Before(ctx context.Context, e *db.QueryEvent) context.Context {
+	ctx, span := h.tracer.Start(ctx, e.System+"."+e.Operation)
+	span.SetAttribute(AttrSystem, e.System)
+	span.SetAttribute(AttrOperation, e.Operation)
+	span.SetAttribute(AttrStatement, truncate(e.Statement, h.limit))
+	return context.WithValue(ctx, spanKey{h}, span)
+}
+
+// After 记录错误并结束片段
+func (h *TracingHook) After(ctx context.Context, e *db.QueryEvent) {
+	span, ok := ctx.Value(spanKey{h}).(Span)
+	if !ok {
+		return
+	}
+	if e.Err != nil {
+		span.RecordError(e.Err)
+	}
+	span.End()
+}
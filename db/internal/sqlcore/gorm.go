@@ -0,0 +1,38 @@
+package sqlcore
+
+import (
+	"github.com/hyperits/gosuite/db/gormlog"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// GormOptions 各 SQL 客户端共用的 gorm 配置项
+type GormOptions struct {
+	NamingStrategy         schema.Namer
+	PrepareStmt            bool
+	SkipDefaultTransaction bool
+	DryRun                 bool
+	Logger                 gormlogger.Interface
+}
+
+// Config 构建 gorm 配置，命名策略默认单数表名，日志默认通过 gormlog 输出
+func (o GormOptions) Config() *gorm.Config {
+	namer := o.NamingStrategy
+	if namer == nil {
+		namer = schema.NamingStrategy{SingularTable: true}
+	}
+
+	log := o.Logger
+	if log == nil {
+		log = gormlog.New(nil)
+	}
+
+	return &gorm.Config{
+		NamingStrategy:         namer,
+		PrepareStmt:            o.PrepareStmt,
+		SkipDefaultTransaction: o.SkipDefaultTransaction,
+		DryRun:                 o.DryRun,
+		Logger:                 log,
+	}
+}
@@ -0,0 +1,57 @@
+package sqlcore
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperits/gosuite/db"
+)
+
+// Hooks 客户端的钩子列表，SQL 与 Redis 客户端共用，并发安全，nil 表示无钩子
+type Hooks struct {
+	system string
+	mu     sync.RWMutex
+	hooks  []db.Hook
+}
+
+// NewHooks 创建钩子列表，system 为数据库类型，如 "mysql"
+func NewHooks(system string, hooks ...db.Hook) *Hooks {
+	h := &Hooks{system: system}
+	for _, hook := range hooks {
+		h.Add(hook)
+	}
+	return h
+}
+
+// Add 追加钩子
+func (h *Hooks) Add(hook db.Hook) {
+	if hook == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.hooks = append(h.hooks, hook)
+}
+
+// Run 在钩子包围下执行 fn
+func (h *Hooks) Run(ctx context.Context, operation, query string, args []interface{}, fn func(ctx context.Context) error) error {
+	if h == nil {
+		return fn(ctx)
+	}
+
+	h.mu.RLock()
+	hooks := h.hooks
+	h.mu.RUnlock()
+
+	if len(hooks) == 0 {
+		return fn(ctx)
+	}
+	return db.RunHooks(ctx, hooks, &db.QueryEvent{
+		System:    h.system,
+		Operation: operation,
+		Statement: query,
+		Args:      args,
+	}, fn)
+}
@@ -0,0 +1,273 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"gorm.io/gorm"
+)
+
+// 确保 Router 实现 gorm 连接池相关接口
+var (
+	_ gorm.ConnPool       = (*Router)(nil)
+	_ gorm.TxBeginner     = (*Router)(nil)
+	_ gorm.GetDBConnector = (*Router)(nil)
+)
+
+// 副本探测默认配置
+const (
+	DefaultReplicaProbeInterval = 10 * time.Second
+	DefaultReplicaProbeTimeout  = 3 * time.Second
+)
+
+// Replica 只读副本连接
+type Replica struct {
+	Name    string  // 副本标识，如 "host:port"
+	DB      *sql.DB // 副本连接池
+	healthy atomic.Bool
+}
+
+// Router 读写分离路由
+// 写操作和事务走主库，SELECT 查询按策略路由到健康副本，无健康副本时回退到主库
+type Router struct {
+	op       string
+	primary  *sql.DB
+	replicas []*Replica
+	policy   db.ReplicaPolicy
+	next     atomic.Uint32
+
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewRouter 创建读写分离路由，并启动后台副本探测
+func NewRouter(op string, primary *sql.DB, replicas []*Replica, policy db.ReplicaPolicy, interval time.Duration) *Router {
+	if policy == "" {
+		policy = db.ReplicaRoundRobin
+	}
+	if interval <= 0 {
+		interval = DefaultReplicaProbeInterval
+	}
+
+	r := &Router{
+		op:       op,
+		primary:  primary,
+		replicas: replicas,
+		policy:   policy,
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+	for _, replica := range replicas {
+		replica.healthy.Store(true)
+	}
+	r.probe()
+
+	r.wg.Add(1)
+	go r.loop()
+
+	return r
+}
+
+// Primary 返回主库连接池
+func (r *Router) Primary() *sql.DB {
+	return r.primary
+}
+
+// Replicas 返回全部副本
+func (r *Router) Replicas() []*Replica {
+	return r.replicas
+}
+
+// Reader 返回用于读查询的连接池
+func (r *Router) Reader(ctx context.Context) *sql.DB {
+	if db.IsPrimaryForced(ctx) {
+		return r.primary
+	}
+
+	var picked *Replica
+	switch r.policy {
+	case db.ReplicaLeastConn:
+		minInUse := -1
+		for _, replica := range r.replicas {
+			if !replica.healthy.Load() {
+				continue
+			}
+			if inUse := replica.DB.Stats().InUse; minInUse < 0 || inUse < minInUse {
+				picked, minInUse = replica, inUse
+			}
+		}
+	default:
+		n := len(r.replicas)
+		start := int(r.next.Add(1))
+		for i := 0; i < n; i++ {
+			replica := r.replicas[(start+i)%n]
+			if replica.healthy.Load() {
+				picked = replica
+				break
+			}
+		}
+	}
+
+	if picked == nil {
+		return r.primary
+	}
+	return picked.DB
+}
+
+// Stats 返回全部副本的连接池统计，按副本标识索引
+func (r *Router) Stats() map[string]sql.DBStats {
+	stats := make(map[string]sql.DBStats, len(r.replicas))
+	for _, replica := range r.replicas {
+		stats[replica.Name] = replica.DB.Stats()
+	}
+	return stats
+}
+
+// Close 停止副本探测并关闭全部副本连接池（不关闭主库）
+func (r *Router) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+
+	var errs []error
+	for _, replica := range r.replicas {
+		if err := replica.DB.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PrepareContext 在主库上预编译语句
+func (r *Router) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return r.primary.PrepareContext(ctx, query)
+}
+
+// ExecContext 在主库上执行语句
+func (r *Router) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return r.primary.ExecContext(ctx, query, args...)
+}
+
+// QueryContext 查询多行，只读 SELECT 路由到副本
+func (r *Router) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return r.Route(ctx, query).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext 查询单行，只读 SELECT 路由到副本
+func (r *Router) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return r.Route(ctx, query).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx 在主库上开启事务
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return r.primary.BeginTx(ctx, opts)
+}
+
+// GetDBConn 返回主库连接池
+func (r *Router) GetDBConn() (*sql.DB, error) {
+	return r.primary, nil
+}
+
+// Ping 测试主库连接
+func (r *Router) Ping() error {
+	return r.primary.Ping()
+}
+
+// Route 根据语句类型选择连接池
+// INSERT ... RETURNING 等写语句也会走 QueryContext，因此只有只读 SELECT 才路由到副本
+func (r *Router) Route(ctx context.Context, query string) *sql.DB {
+	if IsReadOnlyQuery(query) {
+		return r.Reader(ctx)
+	}
+	return r.primary
+}
+
+// loop 定期探测副本健康状态
+func (r *Router) loop() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.probe()
+		}
+	}
+}
+
+// probe 探测全部副本，Ping 失败的副本被剔除，恢复后重新加入
+func (r *Router) probe() {
+	for _, replica := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultReplicaProbeTimeout)
+		err := replica.DB.PingContext(ctx)
+		cancel()
+
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) != healthy {
+			if healthy {
+				logger.Infof("%s replica %s recovered", r.op, replica.Name)
+			} else {
+				logger.Warnf("%s replica %s ejected: %v", r.op, replica.Name, err)
+			}
+		}
+	}
+}
+
+// IsReadOnlyQuery 判断语句是否为可路由到副本的只读查询
+// 按词法单元匹配，不受换行、制表符等空白影响；加锁读取、SELECT INTO 和序列函数视为写操作
+func IsReadOnlyQuery(query string) bool {
+	tokens := sqlTokens(query)
+	if len(tokens) == 0 || tokens[0] != "SELECT" {
+		return false
+	}
+	for i, tok := range tokens {
+		switch tok {
+		case "FOR":
+			// FOR UPDATE / FOR SHARE / FOR NO KEY UPDATE / FOR KEY SHARE
+			if hasTokens(tokens[i+1:], "UPDATE") || hasTokens(tokens[i+1:], "SHARE") ||
+				hasTokens(tokens[i+1:], "NO", "KEY", "UPDATE") || hasTokens(tokens[i+1:], "KEY", "SHARE") {
+				return false
+			}
+		case "LOCK":
+			if hasTokens(tokens[i+1:], "IN", "SHARE", "MODE") {
+				return false
+			}
+		case "INTO", "NEXTVAL", "SETVAL", "CURRVAL", "LASTVAL":
+			// SELECT INTO 写入表或文件；序列函数修改或依赖会话内的序列状态
+			return false
+		}
+	}
+	return true
+}
+
+// sqlTokens 将语句按非标识符字符切分为大写的词法单元
+func sqlTokens(query string) []string {
+	return strings.FieldsFunc(strings.ToUpper(query), func(r rune) bool {
+		return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+}
+
+// hasTokens 判断 tokens 是否以 want 开头
+func hasTokens(tokens []string, want ...string) bool {
+	if len(tokens) < len(want) {
+		return false
+	}
+	for i, w := range want {
+		if tokens[i] != w {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,156 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+)
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM users", true},
+		{"  select id from users where id = ?", true},
+		{"SELECT * FROM users WHERE id = 1 FOR UPDATE", false},
+		{"SELECT * FROM users FOR SHARE", false},
+		{"SELECT * FROM users LOCK IN SHARE MODE", false},
+		{"INSERT INTO users (name) VALUES ($1) RETURNING id", false},
+		{"UPDATE users SET name = ?", false},
+		{"WITH t AS (DELETE FROM users RETURNING *) SELECT * FROM t", false},
+		{"SELECT *\nFROM users\nWHERE id = 1\nFOR UPDATE", false},
+		{"SELECT * FROM users\tFOR\tSHARE", false},
+		{"SELECT * FROM users WHERE id = $1 FOR NO KEY UPDATE", false},
+		{"SELECT * FROM users FOR KEY SHARE SKIP LOCKED", false},
+		{"SELECT * FROM users\nLOCK  IN\nSHARE MODE", false},
+		{"SELECT nextval('orders_id_seq')", false},
+		{"select setval('orders_id_seq', 42)", false},
+		{"SELECT * INTO archive FROM users", false},
+		{"SELECT id, updated_for FROM forms", true},
+		{"\n\tSELECT id\n\tFROM users\n\tORDER BY id", true},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsReadOnlyQuery(tt.query); got != tt.want {
+			t.Errorf("IsReadOnlyQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// newTestRouter 创建主库和 n 个副本均为内存 SQLite 的路由，后台探测间隔足够长，由测试显式调用 probe
+func newTestRouter(t *testing.T, policy db.ReplicaPolicy, n int) (*Router, *sql.DB, []*Replica) {
+	t.Helper()
+
+	primary := openSQLite(t)
+	replicas := make([]*Replica, n)
+	for i := range replicas {
+		replicas[i] = &Replica{Name: string(rune('a' + i)), DB: openSQLite(t)}
+	}
+	r := NewRouter("test", primary, replicas, policy, time.Hour)
+	t.Cleanup(func() { _ = r.Close() })
+	return r, primary, replicas
+}
+
+func TestRouterRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	r, _, replicas := newTestRouter(t, db.ReplicaRoundRobin, 3)
+
+	seen := map[*sql.DB]int{}
+	for i := 0; i < 6; i++ {
+		seen[r.Reader(ctx)]++
+	}
+	for _, replica := range replicas {
+		if seen[replica.DB] != 2 {
+			t.Errorf("replica %s picked %d times, want 2", replica.Name, seen[replica.DB])
+		}
+	}
+}
+
+func TestRouterLeastConn(t *testing.T) {
+	ctx := context.Background()
+	r, _, replicas := newTestRouter(t, db.ReplicaLeastConn, 2)
+
+	// 占用副本 a 的连接后选择副本 b
+	conn, err := replicas[0].DB.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Reader(ctx); got != replicas[1].DB {
+		t.Errorf("Reader() with a busy = %v, want replica b", got)
+	}
+	_ = conn.Close()
+	if got := r.Reader(ctx); got != replicas[0].DB {
+		t.Errorf("Reader() with both idle = %v, want first replica", got)
+	}
+}
+
+func TestRouterEjectAndRecover(t *testing.T) {
+	ctx := context.Background()
+	r, primary, replicas := newTestRouter(t, db.ReplicaRoundRobin, 2)
+
+	// Ping 失败的副本被剔除，读查询只路由到健康副本
+	_ = replicas[0].DB.Close()
+	r.probe()
+	for i := 0; i < 4; i++ {
+		if got := r.Reader(ctx); got != replicas[1].DB {
+			t.Fatalf("Reader() = %v, want healthy replica b", got)
+		}
+	}
+
+	// 全部副本不可用时回退到主库
+	_ = replicas[1].DB.Close()
+	r.probe()
+	if got := r.Reader(ctx); got != primary {
+		t.Errorf("Reader() with no healthy replica = %v, want primary", got)
+	}
+	if got := r.Route(ctx, "SELECT 1"); got != primary {
+		t.Errorf("Route() with no healthy replica = %v, want primary", got)
+	}
+
+	// 恢复后重新加入
+	replicas[0].DB = openSQLite(t)
+	r.probe()
+	if got := r.Reader(ctx); got != replicas[0].DB {
+		t.Errorf("Reader() after recovery = %v, want replica a", got)
+	}
+}
+
+func TestRouterPrimaryRouting(t *testing.T) {
+	ctx := context.Background()
+	r, primary, replicas := newTestRouter(t, db.ReplicaRoundRobin, 1)
+
+	if got := r.Reader(db.WithPrimary(ctx)); got != primary {
+		t.Errorf("Reader() with WithPrimary = %v, want primary", got)
+	}
+	if got := r.Route(db.WithPrimary(ctx), "SELECT 1"); got != primary {
+		t.Errorf("Route() with WithPrimary = %v, want primary", got)
+	}
+	if got := r.Route(ctx, "SELECT 1"); got != replicas[0].DB {
+		t.Errorf("Route(SELECT) = %v, want replica", got)
+	}
+	if got := r.Route(ctx, "UPDATE users SET name = 'x'"); got != primary {
+		t.Errorf("Route(UPDATE) = %v, want primary", got)
+	}
+
+	// 写操作和事务始终走主库
+	if _, err := r.ExecContext(ctx, "INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := r.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n); err != nil || n != 1 {
+		t.Errorf("primary rows = %d, %v, want 1", n, err)
+	}
+	_ = tx.Rollback()
+	if err := r.QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&n); err != nil || n != 0 {
+		t.Errorf("replica rows = %d, %v, want 0", n, err)
+	}
+}
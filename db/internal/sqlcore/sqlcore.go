@@ -0,0 +1,185 @@
+// Package sqlcore 提供 SQL 客户端（MySQL、PostgreSQL）共用的内部实现
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// 确保 Tx 实现 db.Tx 和 db.SavepointBeginner 接口
+var (
+	_ db.Tx                = (*Tx)(nil)
+	_ db.SavepointBeginner = (*Tx)(nil)
+)
+
+// Tx 基于 *sql.Tx 的事务实现
+type Tx struct {
+	tx    *sql.Tx
+	ctx   context.Context // 开启事务时的 ctx，传给提交和回滚的钩子
+	op    string          // 操作名前缀，如 "mysql"
+	hooks *Hooks
+	seq   int32 // 保存点序号
+}
+
+// NewTx 包装 *sql.Tx，ctx 为开启事务时的 ctx，hooks 可为 nil
+func NewTx(ctx context.Context, tx *sql.Tx, op string, hooks *Hooks) *Tx {
+	return &Tx{tx: tx, ctx: ctx, op: op, hooks: hooks}
+}
+
+// Raw 返回底层的 *sql.Tx
+func (t *Tx) Raw() *sql.Tx {
+	return t.tx
+}
+
+// Commit 提交事务
+func (t *Tx) Commit() error {
+	err := t.hooks.Run(t.ctx, "tx.commit", "COMMIT", nil, func(ctx context.Context) error {
+		return t.tx.Commit()
+	})
+	return errors.Wrap(err, t.op+".tx.commit")
+}
+
+// Rollback 回滚事务
+func (t *Tx) Rollback() error {
+	err := t.hooks.Run(t.ctx, "tx.rollback", "ROLLBACK", nil, func(ctx context.Context) error {
+		return t.tx.Rollback()
+	})
+	return errors.Wrap(err, t.op+".tx.rollback")
+}
+
+// Exec 在事务中执行 SQL 语句
+func (t *Tx) Exec(ctx context.Context, query string, args ...interface{}) error {
+	err := t.hooks.Run(ctx, "tx.exec", query, args, func(ctx context.Context) error {
+		_, err := t.tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	return errors.Wrap(err, t.op+".tx.exec")
+}
+
+// QueryRow 在事务中查询单行
+func (t *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) db.Row {
+	var row *sql.Row
+	_ = t.hooks.Run(ctx, "tx.query_row", query, args, func(ctx context.Context) error {
+		row = t.tx.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// Query 在事务中查询多行
+func (t *Tx) Query(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
+	var rows *sql.Rows
+	err := t.hooks.Run(ctx, "tx.query", query, args, func(ctx context.Context) (err error) {
+		rows, err = t.tx.QueryContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, t.op+".tx.query")
+	}
+	return rows, nil
+}
+
+// Begin 在事务内创建保存点，返回嵌套事务
+func (t *Tx) Begin(ctx context.Context) (db.Tx, error) {
+	name := fmt.Sprintf("sp_%d", atomic.AddInt32(&t.seq, 1))
+	if err := t.exec(ctx, "tx.savepoint", "SAVEPOINT "+name); err != nil {
+		return nil, errors.Wrap(err, t.op+".tx.savepoint")
+	}
+	return &Savepoint{Tx: t, ctx: ctx, name: name}, nil
+}
+
+// exec 在钩子包围下执行事务控制语句
+func (t *Tx) exec(ctx context.Context, operation, query string) error {
+	return t.hooks.Run(ctx, operation, query, nil, func(ctx context.Context) error {
+		_, err := t.tx.ExecContext(ctx, query)
+		return err
+	})
+}
+
+// Savepoint 基于保存点的嵌套事务
+// 语句在所属事务上执行，Commit 释放保存点，Rollback 回滚到保存点
+type Savepoint struct {
+	*Tx
+	ctx  context.Context // 创建保存点时的 ctx，传给释放和回滚的钩子
+	name string
+}
+
+// Commit 释放保存点
+func (s *Savepoint) Commit() error {
+	err := s.exec(s.ctx, "tx.release_savepoint", "RELEASE SAVEPOINT "+s.name)
+	return errors.Wrap(err, s.op+".tx.release_savepoint")
+}
+
+// Rollback 回滚到保存点
+func (s *Savepoint) Rollback() error {
+	err := s.exec(s.ctx, "tx.rollback_savepoint", "ROLLBACK TO SAVEPOINT "+s.name)
+	return errors.Wrap(err, s.op+".tx.rollback_savepoint")
+}
+
+// ErrRow 始终返回固定错误的 db.Row，用于客户端不可用时
+type ErrRow struct {
+	Err error
+}
+
+// Scan 返回构造时的错误
+func (r ErrRow) Scan(dest ...interface{}) error {
+	return r.Err
+}
+
+// Conn 执行语句的连接池或单个连接，*sql.DB 和 *sql.Conn 均满足
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Exec 在连接池上执行 SQL 语句，hooks 可为 nil
+func Exec(ctx context.Context, sqlDB Conn, hooks *Hooks, op, query string, args ...interface{}) error {
+	err := hooks.Run(ctx, "exec", query, args, func(ctx context.Context) error {
+		_, err := sqlDB.ExecContext(ctx, query, args...)
+		return err
+	})
+	return errors.Wrap(err, op+".exec")
+}
+
+// QueryRow 在连接池上查询单行，hooks 可为 nil
+func QueryRow(ctx context.Context, sqlDB Conn, hooks *Hooks, query string, args ...interface{}) db.Row {
+	var row *sql.Row
+	_ = hooks.Run(ctx, "query_row", query, args, func(ctx context.Context) error {
+		row = sqlDB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// Query 在连接池上查询多行，hooks 可为 nil
+func Query(ctx context.Context, sqlDB Conn, hooks *Hooks, op, query string, args ...interface{}) (db.Rows, error) {
+	var rows *sql.Rows
+	err := hooks.Run(ctx, "query", query, args, func(ctx context.Context) (err error) {
+		rows, err = sqlDB.QueryContext(ctx, query, args...)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, op+".query")
+	}
+	return rows, nil
+}
+
+// Begin 在连接池上开启事务，hooks 可为 nil
+func Begin(ctx context.Context, sqlDB Conn, hooks *Hooks, op string, opts *sql.TxOptions) (db.Tx, error) {
+	var tx *sql.Tx
+	err := hooks.Run(ctx, "begin", "BEGIN", nil, func(ctx context.Context) (err error) {
+		tx, err = sqlDB.BeginTx(ctx, opts)
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, op+".begin")
+	}
+	return NewTx(ctx, tx, op, hooks), nil
+}
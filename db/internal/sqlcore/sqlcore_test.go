@@ -0,0 +1,198 @@
+package sqlcore
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// openSQLite 打开单连接的内存 SQLite 连接池并创建 users 表
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	if _, err := sqlDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+	return sqlDB
+}
+
+// countRows 返回 users 表的行数
+func countRows(t *testing.T, sqlDB *sql.DB) int {
+	t.Helper()
+
+	var n int
+	if err := QueryRow(context.Background(), sqlDB, nil, "SELECT COUNT(*) FROM users").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestExecQuery(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := openSQLite(t)
+
+	if err := Exec(ctx, sqlDB, nil, "test", "INSERT INTO users (name) VALUES (?), (?)", "a", "b"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	var name string
+	if err := QueryRow(ctx, sqlDB, nil, "SELECT name FROM users WHERE id = ?", 2).Scan(&name); err != nil || name != "b" {
+		t.Errorf("QueryRow() = %q, %v", name, err)
+	}
+	if err := QueryRow(ctx, sqlDB, nil, "SELECT name FROM users WHERE id = ?", 9).Scan(&name); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("QueryRow() missing row error = %v", err)
+	}
+
+	rows, err := Query(ctx, sqlDB, nil, "test", "SELECT name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var names []string
+	for rows.Next() {
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil || len(names) != 2 || names[0] != "a" {
+		t.Errorf("Query() = %v, %v", names, err)
+	}
+	_ = rows.Close()
+
+	if err := Exec(ctx, sqlDB, nil, "test", "INSERT INTO missing VALUES (1)"); err == nil {
+		t.Error("Exec() on missing table should fail")
+	}
+	if _, err := Query(ctx, sqlDB, nil, "test", "SELECT * FROM missing"); err == nil {
+		t.Error("Query() on missing table should fail")
+	}
+}
+
+func TestTxCommitRollback(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := openSQLite(t)
+
+	tx, err := Begin(ctx, sqlDB, nil, "test", nil)
+	if err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	if err := tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := tx.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&n); err != nil || n != 1 {
+		t.Errorf("tx.QueryRow() = %d, %v", n, err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, sql.ErrTxDone) {
+		t.Errorf("second Commit() error = %v, want sql.ErrTxDone", err)
+	}
+	if got := countRows(t, sqlDB); got != 1 {
+		t.Fatalf("rows after commit = %d, want 1", got)
+	}
+
+	tx, err = Begin(ctx, sqlDB, nil, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "b"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := tx.Query(ctx, "SELECT name FROM users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = rows.Close()
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if got := countRows(t, sqlDB); got != 1 {
+		t.Fatalf("rows after rollback = %d, want 1", got)
+	}
+}
+
+func TestSavepoint(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := openSQLite(t)
+
+	tx, err := Begin(ctx, sqlDB, nil, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb := tx.(db.SavepointBeginner)
+
+	inner, err := sb.Begin(ctx)
+	if err != nil {
+		t.Fatalf("savepoint Begin() error = %v", err)
+	}
+	if err := inner.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "discarded"); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Rollback(); err != nil {
+		t.Fatalf("savepoint Rollback() error = %v", err)
+	}
+
+	inner, err = sb.Begin(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "kept"); err != nil {
+		t.Fatal(err)
+	}
+	if err := inner.Commit(); err != nil {
+		t.Fatalf("savepoint Commit() error = %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var name string
+	if err := QueryRow(ctx, sqlDB, nil, "SELECT name FROM users").Scan(&name); err != nil || name != "kept" || countRows(t, sqlDB) != 1 {
+		t.Errorf("rows after savepoints = %q, %v", name, err)
+	}
+}
+
+func TestHooksWrapOperations(t *testing.T) {
+	ctx := context.Background()
+	sqlDB := openSQLite(t)
+
+	var ops []string
+	hooks := NewHooks("test", (*recordOps)(&ops))
+	if err := Exec(ctx, sqlDB, hooks, "test", "INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := Begin(ctx, sqlDB, hooks, "test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = tx.Rollback()
+
+	want := []string{"exec", "begin", "tx.rollback"}
+	if len(ops) != len(want) {
+		t.Fatalf("ops = %v, want %v", ops, want)
+	}
+	for i := range want {
+		if ops[i] != want[i] {
+			t.Errorf("ops = %v, want %v", ops, want)
+			break
+		}
+	}
+}
+
+// recordOps 记录操作名的钩子
+type recordOps []string
+
+func (r *recordOps) Before(ctx context.Context, e *db.QueryEvent) context.Context { return ctx }
+func (r *recordOps) After(ctx context.Context, e *db.QueryEvent)                  { *r = append(*r, e.Operation) }
@@ -0,0 +1,52 @@
+package db
+
+import "strings"
+
+// KeySeparator 结构化键各部分之间的分隔符
+const KeySeparator = ":"
+
+// Key 以 KeySeparator 连接各部分构造结构化键，如 Key("user", "42", "profile") 返回 "user:42:profile"
+func Key(parts ...string) string {
+	return strings.Join(parts, KeySeparator)
+}
+
+// Namespace 键命名空间，用于构造同一业务下的键并按命名空间遍历或删除
+//
+//	users := db.Namespace("user")
+//	key := users.Key("42", "profile") // "user:42:profile"
+//	n, err := client.DelMatch(ctx, users.Match())
+type Namespace string
+
+// Key 构造命名空间下的键
+func (ns Namespace) Key(parts ...string) string {
+	if ns == "" {
+		return Key(parts...)
+	}
+	return Key(append([]string{string(ns)}, parts...)...)
+}
+
+// Sub 返回子命名空间
+func (ns Namespace) Sub(parts ...string) Namespace {
+	return Namespace(ns.Key(parts...))
+}
+
+// Match 返回匹配命名空间下全部键的 glob 模式，可用于 Scan 或 DelMatch
+func (ns Namespace) Match() string {
+	if ns == "" {
+		return "*"
+	}
+	return EscapeGlob(string(ns)) + KeySeparator + "*"
+}
+
+// EscapeGlob 转义 glob 特殊字符，使字符串在 SCAN/KEYS 模式中按字面匹配
+func EscapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,23 @@
+package db_test
+
+import (
+	"testing"
+
+	"github.com/hyperits/gosuite/db"
+)
+
+func TestNamespace(t *testing.T) {
+	users := db.Namespace("user")
+	if got := users.Key("42", "profile"); got != "user:42:profile" {
+		t.Errorf("Key = %q", got)
+	}
+	if got := users.Sub("42").Key("orders"); got != "user:42:orders" {
+		t.Errorf("Sub.Key = %q", got)
+	}
+	if got := db.Namespace("a*[b]").Match(); got != `a\*\[b\]:*` {
+		t.Errorf("Match = %q", got)
+	}
+	if got := db.Namespace("").Key("k"); got != "k" {
+		t.Errorf("empty namespace Key = %q", got)
+	}
+}
@@ -0,0 +1,172 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// NoExpiration TTL 返回值，表示键存在但没有过期时间
+const NoExpiration time.Duration = -1
+
+// KVStore 完整的键值存储接口，在 KVClient 基础上提供计数器、过期、批量读写和常用数据结构操作
+// 除 KVClient.Get 外，键、字段或成员不存在时返回的错误满足 errors.Is(err, errors.ErrNotFound)
+type KVStore interface {
+	KVClient
+	StringCommands
+	HashCommands
+	ListCommands
+	SetCommands
+	SortedSetCommands
+	KeyScanner
+}
+
+// StringCommands 字符串、计数器和过期时间操作
+type StringCommands interface {
+	// Incr 将整数值加一并返回新值，键不存在时视为 0
+	Incr(ctx context.Context, key string) (int64, error)
+
+	// IncrBy 将整数值增加 n 并返回新值
+	IncrBy(ctx context.Context, key string, n int64) (int64, error)
+
+	// Decr 将整数值减一并返回新值
+	Decr(ctx context.Context, key string) (int64, error)
+
+	// DecrBy 将整数值减少 n 并返回新值
+	DecrBy(ctx context.Context, key string, n int64) (int64, error)
+
+	// SetNX 键不存在时设置值，ttl 为 0 表示不过期，返回是否设置成功
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+
+	// MGet 批量获取值，结果只包含存在的键
+	MGet(ctx context.Context, keys ...string) (map[string]string, error)
+
+	// MSet 批量设置值（无过期时间）
+	MSet(ctx context.Context, values map[string]interface{}) error
+
+	// Expire 设置过期时间，返回键是否存在
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// TTL 返回剩余过期时间，没有过期时间时返回 NoExpiration
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
+
+// HashCommands 哈希操作
+type HashCommands interface {
+	// HGet 获取字段值
+	HGet(ctx context.Context, key, field string) (string, error)
+
+	// HSet 设置多个字段
+	HSet(ctx context.Context, key string, values map[string]interface{}) error
+
+	// HGetAll 获取全部字段，键不存在时返回空 map
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+
+	// HDel 删除字段，返回实际删除的数量
+	HDel(ctx context.Context, key string, fields ...string) (int64, error)
+
+	// HExists 检查字段是否存在
+	HExists(ctx context.Context, key, field string) (bool, error)
+
+	// HIncrBy 将字段的整数值增加 n 并返回新值
+	HIncrBy(ctx context.Context, key, field string, n int64) (int64, error)
+
+	// HLen 返回字段数量
+	HLen(ctx context.Context, key string) (int64, error)
+}
+
+// ListCommands 列表操作
+type ListCommands interface {
+	// LPush 从头部插入，返回插入后的长度
+	LPush(ctx context.Context, key string, values ...interface{}) (int64, error)
+
+	// RPush 从尾部插入，返回插入后的长度
+	RPush(ctx context.Context, key string, values ...interface{}) (int64, error)
+
+	// LPop 从头部弹出
+	LPop(ctx context.Context, key string) (string, error)
+
+	// RPop 从尾部弹出
+	RPop(ctx context.Context, key string) (string, error)
+
+	// LRange 返回下标 start 到 stop（包含）的元素，负数表示从尾部计数
+	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+
+	// LTrim 只保留下标 start 到 stop（包含）的元素
+	LTrim(ctx context.Context, key string, start, stop int64) error
+
+	// LLen 返回列表长度
+	LLen(ctx context.Context, key string) (int64, error)
+}
+
+// SetCommands 集合操作
+type SetCommands interface {
+	// SAdd 添加成员，返回新增的数量
+	SAdd(ctx context.Context, key string, members ...interface{}) (int64, error)
+
+	// SRem 删除成员，返回实际删除的数量
+	SRem(ctx context.Context, key string, members ...interface{}) (int64, error)
+
+	// SMembers 返回全部成员，顺序不确定
+	SMembers(ctx context.Context, key string) ([]string, error)
+
+	// SIsMember 检查是否为成员
+	SIsMember(ctx context.Context, key string, member interface{}) (bool, error)
+
+	// SCard 返回成员数量
+	SCard(ctx context.Context, key string) (int64, error)
+}
+
+// ZMember 有序集合成员
+type ZMember struct {
+	Member string
+	Score  float64
+}
+
+// SortedSetCommands 有序集合操作
+type SortedSetCommands interface {
+	// ZAdd 添加或更新成员，返回新增的数量
+	ZAdd(ctx context.Context, key string, members ...ZMember) (int64, error)
+
+	// ZRem 删除成员，返回实际删除的数量
+	ZRem(ctx context.Context, key string, members ...string) (int64, error)
+
+	// ZScore 返回成员分数
+	ZScore(ctx context.Context, key, member string) (float64, error)
+
+	// ZIncrBy 将成员分数增加 n 并返回新分数
+	ZIncrBy(ctx context.Context, key, member string, n float64) (float64, error)
+
+	// ZRange 按分数升序返回下标 start 到 stop（包含）的成员
+	ZRange(ctx context.Context, key string, start, stop int64) ([]ZMember, error)
+
+	// ZRangeByScore 按分数升序返回分数在 [min, max] 内的成员，count 为 0 时不限制数量
+	ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]ZMember, error)
+
+	// ZCard 返回成员数量
+	ZCard(ctx context.Context, key string) (int64, error)
+}
+
+// KeyScanner 键遍历
+type KeyScanner interface {
+	// Scan 返回匹配 match（glob 模式，空表示全部）的键迭代器，count 为每批数量提示
+	// 遍历期间修改的键可能被跳过或重复返回
+	Scan(ctx context.Context, match string, count int64) KeyIterator
+}
+
+// KeyIterator 键迭代器
+//
+//	it := store.Scan(ctx, "user:*", 100)
+//	for it.Next(ctx) {
+//		fmt.Println(it.Key())
+//	}
+//	if err := it.Err(); err != nil { ... }
+type KeyIterator interface {
+	// Next 移动到下一个键，没有更多键或出错时返回 false
+	Next(ctx context.Context) bool
+
+	// Key 返回当前键
+	Key() string
+
+	// Err 返回遍历过程中的错误
+	Err() error
+}
@@ -0,0 +1,766 @@
+package memkv
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// do 持锁执行操作，存储已关闭时返回 errors.ErrAlreadyClosed
+func (s *Store) do(fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.ErrAlreadyClosed
+	}
+	return fn()
+}
+
+// Incr 将整数值加一并返回新值
+func (s *Store) Incr(ctx context.Context, key string) (int64, error) {
+	return s.IncrBy(ctx, key, 1)
+}
+
+// IncrBy 将整数值增加 n 并返回新值
+func (s *Store) IncrBy(ctx context.Context, key string, n int64) (v int64, err error) {
+	err = s.do(func() error {
+		v, err = s.incrBy(key, n)
+		return err
+	})
+	return v, err
+}
+
+// Decr 将整数值减一并返回新值
+func (s *Store) Decr(ctx context.Context, key string) (int64, error) {
+	return s.IncrBy(ctx, key, -1)
+}
+
+// DecrBy 将整数值减少 n 并返回新值
+func (s *Store) DecrBy(ctx context.Context, key string, n int64) (int64, error) {
+	return s.IncrBy(ctx, key, -n)
+}
+
+// SetNX 键不存在时设置值，返回是否设置成功
+func (s *Store) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (ok bool, err error) {
+	v, err := format(value)
+	if err != nil {
+		return false, err
+	}
+	err = s.do(func() error {
+		if s.get(key) != nil {
+			return nil
+		}
+		s.set(key, v, ttl, false)
+		ok = true
+		return nil
+	})
+	return ok, err
+}
+
+// MGet 批量获取值，结果只包含存在的字符串键
+func (s *Store) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	err := s.do(func() error {
+		for _, key := range keys {
+			if e := s.get(key); e != nil && e.kind == kindString {
+				result[key] = e.str
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// MSet 批量设置值
+func (s *Store) MSet(ctx context.Context, values map[string]interface{}) error {
+	formatted := make(map[string]string, len(values))
+	for key, value := range values {
+		v, err := format(value)
+		if err != nil {
+			return err
+		}
+		formatted[key] = v
+	}
+	return s.do(func() error {
+		for key, v := range formatted {
+			s.set(key, v, 0, false)
+		}
+		return nil
+	})
+}
+
+// Expire 设置过期时间，返回键是否存在
+func (s *Store) Expire(ctx context.Context, key string, ttl time.Duration) (ok bool, err error) {
+	err = s.do(func() error {
+		ok = s.expire(key, ttl)
+		return nil
+	})
+	return ok, err
+}
+
+// TTL 返回剩余过期时间，没有过期时间时返回 db.NoExpiration
+func (s *Store) TTL(ctx context.Context, key string) (ttl time.Duration, err error) {
+	err = s.do(func() error {
+		ttl = s.ttl(key)
+		if ttl == -2 {
+			return notFound()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// HGet 获取字段值
+func (s *Store) HGet(ctx context.Context, key, field string) (v string, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindHash)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if e != nil {
+			v, ok = e.hash[field]
+		}
+		if !ok {
+			return notFound()
+		}
+		return nil
+	})
+	return v, err
+}
+
+// HSet 设置多个字段
+func (s *Store) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return nil
+	}
+	pairs := make([]string, 0, len(values)*2)
+	for field, value := range values {
+		v, err := format(value)
+		if err != nil {
+			return err
+		}
+		pairs = append(pairs, field, v)
+	}
+	return s.do(func() error {
+		_, err := s.hset(key, pairs)
+		return err
+	})
+}
+
+// HGetAll 获取全部字段，键不存在时返回空 map
+func (s *Store) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	result := make(map[string]string)
+	err := s.do(func() error {
+		e, err := s.lookup(key, kindHash)
+		if err != nil || e == nil {
+			return err
+		}
+		for field, v := range e.hash {
+			result[field] = v
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// HDel 删除字段，返回实际删除的数量
+func (s *Store) HDel(ctx context.Context, key string, fields ...string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindHash)
+		if err != nil || e == nil {
+			return err
+		}
+		for _, field := range fields {
+			if _, ok := e.hash[field]; ok {
+				delete(e.hash, field)
+				n++
+			}
+		}
+		s.cleanup(key, e)
+		return nil
+	})
+	return n, err
+}
+
+// HExists 检查字段是否存在
+func (s *Store) HExists(ctx context.Context, key, field string) (ok bool, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindHash)
+		if err != nil || e == nil {
+			return err
+		}
+		_, ok = e.hash[field]
+		return nil
+	})
+	return ok, err
+}
+
+// HIncrBy 将字段的整数值增加 n 并返回新值
+func (s *Store) HIncrBy(ctx context.Context, key, field string, n int64) (v int64, err error) {
+	err = s.do(func() error {
+		v, err = s.hincrBy(key, field, n)
+		return err
+	})
+	return v, err
+}
+
+// HLen 返回字段数量
+func (s *Store) HLen(ctx context.Context, key string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindHash)
+		if e != nil {
+			n = int64(len(e.hash))
+		}
+		return err
+	})
+	return n, err
+}
+
+// LPush 从头部插入，返回插入后的长度
+func (s *Store) LPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return s.push(key, values, true)
+}
+
+// RPush 从尾部插入，返回插入后的长度
+func (s *Store) RPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	return s.push(key, values, false)
+}
+
+// LPop 从头部弹出
+func (s *Store) LPop(ctx context.Context, key string) (string, error) {
+	return s.pop(key, true)
+}
+
+// RPop 从尾部弹出
+func (s *Store) RPop(ctx context.Context, key string) (string, error) {
+	return s.pop(key, false)
+}
+
+// LRange 返回下标 start 到 stop（包含）的元素，负数表示从尾部计数
+func (s *Store) LRange(ctx context.Context, key string, start, stop int64) (values []string, err error) {
+	values = []string{}
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindList)
+		if err != nil || e == nil {
+			return err
+		}
+		if lo, hi, ok := span(start, stop, len(e.list)); ok {
+			values = append(values, e.list[lo:hi]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// LTrim 只保留下标 start 到 stop（包含）的元素
+func (s *Store) LTrim(ctx context.Context, key string, start, stop int64) error {
+	return s.do(func() error {
+		e, err := s.lookup(key, kindList)
+		if err != nil || e == nil {
+			return err
+		}
+		if lo, hi, ok := span(start, stop, len(e.list)); ok {
+			e.list = append([]string(nil), e.list[lo:hi]...)
+		} else {
+			e.list = nil
+		}
+		s.cleanup(key, e)
+		return nil
+	})
+}
+
+// LLen 返回列表长度
+func (s *Store) LLen(ctx context.Context, key string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindList)
+		if e != nil {
+			n = int64(len(e.list))
+		}
+		return err
+	})
+	return n, err
+}
+
+// SAdd 添加成员，返回新增的数量
+func (s *Store) SAdd(ctx context.Context, key string, members ...interface{}) (n int64, err error) {
+	values, err := formatAll(members)
+	if err != nil {
+		return 0, err
+	}
+	err = s.do(func() error {
+		n, err = s.sadd(key, values)
+		return err
+	})
+	return n, err
+}
+
+// SRem 删除成员，返回实际删除的数量
+func (s *Store) SRem(ctx context.Context, key string, members ...interface{}) (n int64, err error) {
+	values, err := formatAll(members)
+	if err != nil {
+		return 0, err
+	}
+	err = s.do(func() error {
+		n, err = s.srem(key, values)
+		return err
+	})
+	return n, err
+}
+
+// SMembers 返回全部成员，按字典序排列
+func (s *Store) SMembers(ctx context.Context, key string) (members []string, err error) {
+	members = []string{}
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindSet)
+		if err != nil || e == nil {
+			return err
+		}
+		for m := range e.set {
+			members = append(members, m)
+		}
+		sort.Strings(members)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// SIsMember 检查是否为成员
+func (s *Store) SIsMember(ctx context.Context, key string, member interface{}) (ok bool, err error) {
+	m, err := format(member)
+	if err != nil {
+		return false, err
+	}
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindSet)
+		if err != nil || e == nil {
+			return err
+		}
+		_, ok = e.set[m]
+		return nil
+	})
+	return ok, err
+}
+
+// SCard 返回成员数量
+func (s *Store) SCard(ctx context.Context, key string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindSet)
+		if e != nil {
+			n = int64(len(e.set))
+		}
+		return err
+	})
+	return n, err
+}
+
+// ZAdd 添加或更新成员，返回新增的数量
+func (s *Store) ZAdd(ctx context.Context, key string, members ...db.ZMember) (n int64, err error) {
+	err = s.do(func() error {
+		n, err = s.zadd(key, members)
+		return err
+	})
+	return n, err
+}
+
+// ZRem 删除成员，返回实际删除的数量
+func (s *Store) ZRem(ctx context.Context, key string, members ...string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindZSet)
+		if err != nil || e == nil {
+			return err
+		}
+		for _, m := range members {
+			if _, ok := e.zset[m]; ok {
+				delete(e.zset, m)
+				n++
+			}
+		}
+		s.cleanup(key, e)
+		return nil
+	})
+	return n, err
+}
+
+// ZScore 返回成员分数
+func (s *Store) ZScore(ctx context.Context, key, member string) (score float64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindZSet)
+		if err != nil {
+			return err
+		}
+		var ok bool
+		if e != nil {
+			score, ok = e.zset[member]
+		}
+		if !ok {
+			return notFound()
+		}
+		return nil
+	})
+	return score, err
+}
+
+// ZIncrBy 将成员分数增加 n 并返回新分数
+func (s *Store) ZIncrBy(ctx context.Context, key, member string, n float64) (score float64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookupOrCreate(key, kindZSet)
+		if err != nil {
+			return err
+		}
+		score = e.zset[member] + n
+		if math.IsNaN(score) {
+			s.cleanup(key, e)
+			return ErrNotFloat
+		}
+		e.zset[member] = score
+		return nil
+	})
+	return score, err
+}
+
+// ZRange 按分数升序返回下标 start 到 stop（包含）的成员
+func (s *Store) ZRange(ctx context.Context, key string, start, stop int64) (members []db.ZMember, err error) {
+	members = []db.ZMember{}
+	err = s.do(func() error {
+		sorted, err := s.zsorted(key)
+		if err != nil {
+			return err
+		}
+		if lo, hi, ok := span(start, stop, len(sorted)); ok {
+			members = append(members, sorted[lo:hi]...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ZRangeByScore 按分数升序返回分数在 [min, max] 内的成员，count 为 0 时不限制数量
+func (s *Store) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) (members []db.ZMember, err error) {
+	err = s.do(func() error {
+		members, err = s.zrangeByScore(key, min, max, offset, count)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// ZCard 返回成员数量
+func (s *Store) ZCard(ctx context.Context, key string) (n int64, err error) {
+	err = s.do(func() error {
+		e, err := s.lookup(key, kindZSet)
+		if e != nil {
+			n = int64(len(e.zset))
+		}
+		return err
+	})
+	return n, err
+}
+
+// Scan 返回匹配 match 的键迭代器，遍历的是调用时的键快照（按字典序）
+func (s *Store) Scan(ctx context.Context, match string, count int64) db.KeyIterator {
+	it := &keyIterator{}
+	it.err = s.do(func() error {
+		it.keys = s.keys(match)
+		return nil
+	})
+	return it
+}
+
+// keyIterator 遍历键快照
+type keyIterator struct {
+	keys []string
+	key  string
+	err  error
+}
+
+func (it *keyIterator) Next(ctx context.Context) bool {
+	if it.err != nil || len(it.keys) == 0 {
+		return false
+	}
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	return true
+}
+
+func (it *keyIterator) Key() string {
+	return it.key
+}
+
+func (it *keyIterator) Err() error {
+	return it.err
+}
+
+// incrBy 将整数值增加 n，键不存在时视为 0，保留原过期时间
+func (s *Store) incrBy(key string, n int64) (int64, error) {
+	e, err := s.lookup(key, kindString)
+	if err != nil {
+		return 0, err
+	}
+	var cur int64
+	if e != nil {
+		if cur, err = strconv.ParseInt(e.str, 10, 64); err != nil {
+			return 0, ErrNotInteger
+		}
+	} else {
+		e = &entry{kind: kindString}
+		s.data[key] = e
+	}
+	v, ok := addInt(cur, n)
+	if !ok {
+		return 0, ErrNotInteger
+	}
+	e.str = strconv.FormatInt(v, 10)
+	return v, nil
+}
+
+// hset 按 field、value 交替的 pairs 设置字段，返回新增字段的数量
+func (s *Store) hset(key string, pairs []string) (int64, error) {
+	e, err := s.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if _, ok := e.hash[pairs[i]]; !ok {
+			n++
+		}
+		e.hash[pairs[i]] = pairs[i+1]
+	}
+	return n, nil
+}
+
+// hincrBy 将字段的整数值增加 n，字段不存在时视为 0
+func (s *Store) hincrBy(key, field string, n int64) (int64, error) {
+	e, err := s.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return 0, err
+	}
+	var cur int64
+	if old, ok := e.hash[field]; ok {
+		if cur, err = strconv.ParseInt(old, 10, 64); err != nil {
+			return 0, ErrNotInteger
+		}
+	}
+	v, ok := addInt(cur, n)
+	if !ok {
+		s.cleanup(key, e)
+		return 0, ErrNotInteger
+	}
+	e.hash[field] = strconv.FormatInt(v, 10)
+	return v, nil
+}
+
+// push 向列表插入元素，head 为 true 时从头部插入
+func (s *Store) push(key string, values []interface{}, head bool) (n int64, err error) {
+	items, err := formatAll(values)
+	if err != nil {
+		return 0, err
+	}
+	err = s.do(func() error {
+		n, err = s.pushStrings(key, items, head)
+		return err
+	})
+	return n, err
+}
+
+// pushStrings 向列表插入元素，从头部插入时与 Redis 一样逐个插入，结果顺序与参数相反
+func (s *Store) pushStrings(key string, items []string, head bool) (int64, error) {
+	e, err := s.lookupOrCreate(key, kindList)
+	if err != nil {
+		return 0, err
+	}
+	if head {
+		list := make([]string, 0, len(items)+len(e.list))
+		for i := len(items) - 1; i >= 0; i-- {
+			list = append(list, items[i])
+		}
+		e.list = append(list, e.list...)
+	} else {
+		e.list = append(e.list, items...)
+	}
+	s.cleanup(key, e)
+	return int64(len(e.list)), nil
+}
+
+// pop 从列表弹出元素，head 为 true 时从头部弹出
+func (s *Store) pop(key string, head bool) (v string, err error) {
+	err = s.do(func() error {
+		var ok bool
+		v, ok, err = s.popString(key, head)
+		if err == nil && !ok {
+			return notFound()
+		}
+		return err
+	})
+	return v, err
+}
+
+// popString 从列表弹出元素，列表不存在时返回 false
+func (s *Store) popString(key string, head bool) (string, bool, error) {
+	e, err := s.lookup(key, kindList)
+	if err != nil || e == nil {
+		return "", false, err
+	}
+	var v string
+	if head {
+		v, e.list = e.list[0], e.list[1:]
+	} else {
+		v, e.list = e.list[len(e.list)-1], e.list[:len(e.list)-1]
+	}
+	s.cleanup(key, e)
+	return v, true, nil
+}
+
+// sadd 向集合添加成员，返回新增的数量
+func (s *Store) sadd(key string, members []string) (int64, error) {
+	e, err := s.lookupOrCreate(key, kindSet)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, m := range members {
+		if _, ok := e.set[m]; !ok {
+			e.set[m] = struct{}{}
+			n++
+		}
+	}
+	s.cleanup(key, e)
+	return n, nil
+}
+
+// srem 从集合删除成员，返回实际删除的数量
+func (s *Store) srem(key string, members []string) (int64, error) {
+	e, err := s.lookup(key, kindSet)
+	if err != nil || e == nil {
+		return 0, err
+	}
+	var n int64
+	for _, m := range members {
+		if _, ok := e.set[m]; ok {
+			delete(e.set, m)
+			n++
+		}
+	}
+	s.cleanup(key, e)
+	return n, nil
+}
+
+// zadd 添加或更新有序集合成员，返回新增的数量
+func (s *Store) zadd(key string, members []db.ZMember) (int64, error) {
+	for _, m := range members {
+		if math.IsNaN(m.Score) {
+			return 0, ErrNotFloat
+		}
+	}
+	e, err := s.lookupOrCreate(key, kindZSet)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, m := range members {
+		if _, ok := e.zset[m.Member]; !ok {
+			n++
+		}
+		e.zset[m.Member] = m.Score
+	}
+	s.cleanup(key, e)
+	return n, nil
+}
+
+// zsorted 返回按分数升序、同分按成员字典序排列的成员
+func (s *Store) zsorted(key string) ([]db.ZMember, error) {
+	e, err := s.lookup(key, kindZSet)
+	if err != nil || e == nil {
+		return nil, err
+	}
+	members := make([]db.ZMember, 0, len(e.zset))
+	for m, score := range e.zset {
+		members = append(members, db.ZMember{Member: m, Score: score})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+	return members, nil
+}
+
+// zrangeByScore 返回分数在 [min, max] 内的成员，跳过 offset 个后最多返回 count 个
+func (s *Store) zrangeByScore(key string, min, max float64, offset, count int64) ([]db.ZMember, error) {
+	sorted, err := s.zsorted(key)
+	if err != nil {
+		return nil, err
+	}
+	members := []db.ZMember{}
+	for _, m := range sorted {
+		if m.Score < min || m.Score > max {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		if count > 0 && int64(len(members)) >= count {
+			break
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// span 将 Redis 风格的闭区间下标转换为切片区间，区间为空时返回 false
+func span(start, stop int64, n int) (int, int, bool) {
+	size := int64(n)
+	if start < 0 {
+		start += size
+	}
+	if stop < 0 {
+		stop += size
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= size {
+		stop = size - 1
+	}
+	if start > stop || start >= size {
+		return 0, 0, false
+	}
+	return int(start), int(stop) + 1, true
+}
+
+// addInt 带溢出检查的整数加法
+func addInt(a, b int64) (int64, bool) {
+	c := a + b
+	if (b > 0 && c < a) || (b < 0 && c > a) {
+		return 0, false
+	}
+	return c, true
+}
@@ -0,0 +1,524 @@
+// Package memkv 提供纯 Go 的内存键值存储，实现 db.KVStore 接口，用于单元测试
+//
+// Store 支持过期时间和可控时钟，过期在访问时惰性判断；
+// Server 以 RESP 协议在回环地址上暴露同一份数据，供依赖 redis.UniversalClient 的代码离线测试。
+// 不支持 Lua 脚本、事务（MULTI/EXEC）、pub/sub 和 Stream，因此不能替代 lock、ratelimit、
+// queue、eventbus 和 cache.Near 测试所需的 Redis，这些包的测试使用 miniredis。
+package memkv
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// 确保 Store 实现 db.KVStore 接口
+var _ db.KVStore = (*Store)(nil)
+
+// 与 Redis 错误信息一致的错误，经 Server 返回后 go-redis 可按原样识别
+var (
+	// ErrWrongType 键的类型与操作不匹配
+	ErrWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+	// ErrNotInteger 值不是整数或超出范围
+	ErrNotInteger = errors.New("ERR value is not an integer or out of range")
+
+	// ErrNotFloat 值不是合法的浮点数
+	ErrNotFloat = errors.New("ERR value is not a valid float")
+)
+
+// kind 值类型
+type kind int
+
+const (
+	kindString kind = iota
+	kindHash
+	kindList
+	kindSet
+	kindZSet
+)
+
+// String 返回与 Redis TYPE 命令一致的类型名
+func (k kind) String() string {
+	switch k {
+	case kindHash:
+		return "hash"
+	case kindList:
+		return "list"
+	case kindSet:
+		return "set"
+	case kindZSet:
+		return "zset"
+	}
+	return "string"
+}
+
+// entry 存储条目，按 kind 使用对应字段
+type entry struct {
+	kind     kind
+	str      string
+	hash     map[string]string
+	list     []string
+	set      map[string]struct{}
+	zset     map[string]float64
+	expireAt time.Time // 零值表示不过期
+}
+
+// empty 判断容器类型是否已无元素，Redis 会删除空容器
+func (e *entry) empty() bool {
+	switch e.kind {
+	case kindHash:
+		return len(e.hash) == 0
+	case kindList:
+		return len(e.list) == 0
+	case kindSet:
+		return len(e.set) == 0
+	case kindZSet:
+		return len(e.zset) == 0
+	}
+	return false
+}
+
+// Option Store 配置选项函数
+type Option func(*Store)
+
+// WithClock 设置时钟，默认 time.Now
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// Store 并发安全的内存键值存储
+//
+// 语义与 gosuite redis.Client 保持一致：Get 的键不存在时返回 redis.Nil，
+// 其他操作的键、字段或成员不存在时返回同时满足 errors.ErrNotFound 和 redis.Nil 的错误。
+type Store struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	offset time.Duration // FastForward 累计的时间偏移
+	data   map[string]*entry
+	closed bool
+}
+
+// New 创建内存键值存储
+func New(opts ...Option) *Store {
+	s := &Store{
+		now:  time.Now,
+		data: make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// FastForward 将存储的时钟向前拨动 d，用于测试过期
+func (s *Store) FastForward(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// FlushAll 清空全部数据
+func (s *Store) FlushAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[string]*entry)
+}
+
+// Close 关闭存储，之后的操作返回 errors.ErrAlreadyClosed
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.ErrAlreadyClosed
+	}
+	s.closed = true
+	return nil
+}
+
+// Ping 检查存储是否可用
+func (s *Store) Ping(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.ErrAlreadyClosed
+	}
+	return nil
+}
+
+// IsConnected 未关闭时返回 true
+func (s *Store) IsConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.closed
+}
+
+// Stats 内存存储没有连接池，返回空统计
+func (s *Store) Stats() db.Stats {
+	return db.Stats{}
+}
+
+// Get 获取值，键不存在时返回 redis.Nil
+func (s *Store) Get(ctx context.Context, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return "", errors.ErrAlreadyClosed
+	}
+	e, err := s.lookup(key, kindString)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "", redis.Nil
+	}
+	return e.str, nil
+}
+
+// Set 设置值（无过期时间）
+func (s *Store) Set(ctx context.Context, key string, value interface{}) error {
+	return s.SetWithTTL(ctx, key, value, 0)
+}
+
+// SetWithTTL 设置值并指定过期时间（秒），ttlSeconds 不大于 0 表示不过期
+func (s *Store) SetWithTTL(ctx context.Context, key string, value interface{}, ttlSeconds int) error {
+	v, err := format(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.ErrAlreadyClosed
+	}
+	s.set(key, v, time.Duration(ttlSeconds)*time.Second, false)
+	return nil
+}
+
+// Del 删除键
+func (s *Store) Del(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.ErrAlreadyClosed
+	}
+	s.del(keys...)
+	return nil
+}
+
+// Exists 返回存在的键数量，重复的键重复计数
+func (s *Store) Exists(ctx context.Context, keys ...string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, errors.ErrAlreadyClosed
+	}
+	var n int64
+	for _, key := range keys {
+		if s.get(key) != nil {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// clock 返回当前时间，调用方须持有锁
+func (s *Store) clock() time.Time {
+	return s.now().Add(s.offset)
+}
+
+// get 返回未过期的条目，过期条目会被删除，调用方须持有锁
+func (s *Store) get(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expireAt.IsZero() && !s.clock().Before(e.expireAt) {
+		delete(s.data, key)
+		return nil
+	}
+	return e
+}
+
+// lookup 返回指定类型的条目，键不存在时返回 nil，类型不匹配时返回 ErrWrongType
+func (s *Store) lookup(key string, k kind) (*entry, error) {
+	e := s.get(key)
+	if e != nil && e.kind != k {
+		return nil, ErrWrongType
+	}
+	return e, nil
+}
+
+// lookupOrCreate 返回指定类型的条目，键不存在时创建
+func (s *Store) lookupOrCreate(key string, k kind) (*entry, error) {
+	e, err := s.lookup(key, k)
+	if err != nil || e != nil {
+		return e, err
+	}
+	e = &entry{kind: k}
+	switch k {
+	case kindHash:
+		e.hash = make(map[string]string)
+	case kindSet:
+		e.set = make(map[string]struct{})
+	case kindZSet:
+		e.zset = make(map[string]float64)
+	}
+	s.data[key] = e
+	return e, nil
+}
+
+// cleanup 删除已无元素的容器
+func (s *Store) cleanup(key string, e *entry) {
+	if e.empty() {
+		delete(s.data, key)
+	}
+}
+
+// set 写入字符串值，ttl 不大于 0 且 keepTTL 为 false 时清除过期时间
+func (s *Store) set(key, value string, ttl time.Duration, keepTTL bool) {
+	e := &entry{kind: kindString, str: value}
+	if old := s.get(key); keepTTL && old != nil {
+		e.expireAt = old.expireAt
+	} else if ttl > 0 {
+		e.expireAt = s.clock().Add(ttl)
+	}
+	s.data[key] = e
+}
+
+// del 删除键，返回实际删除的数量
+func (s *Store) del(keys ...string) int64 {
+	var n int64
+	for _, key := range keys {
+		if s.get(key) != nil {
+			delete(s.data, key)
+			n++
+		}
+	}
+	return n
+}
+
+// expire 设置过期时间，ttl 不大于 0 时删除键，返回键是否存在
+func (s *Store) expire(key string, ttl time.Duration) bool {
+	e := s.get(key)
+	if e == nil {
+		return false
+	}
+	if ttl <= 0 {
+		delete(s.data, key)
+		return true
+	}
+	e.expireAt = s.clock().Add(ttl)
+	return true
+}
+
+// ttl 返回剩余过期时间，键不存在返回 -2，没有过期时间返回 NoExpiration
+func (s *Store) ttl(key string) time.Duration {
+	e := s.get(key)
+	if e == nil {
+		return -2
+	}
+	if e.expireAt.IsZero() {
+		return db.NoExpiration
+	}
+	return e.expireAt.Sub(s.clock())
+}
+
+// keys 返回匹配 match 的全部键，按字典序排列
+func (s *Store) keys(match string) []string {
+	var keys []string
+	for key := range s.data {
+		if s.get(key) == nil {
+			continue
+		}
+		if match == "" || globMatch(match, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// notFound 返回同时满足 errors.ErrNotFound 和 redis.Nil 的错误
+func notFound() error {
+	return fmt.Errorf("%w: %w", errors.ErrNotFound, redis.Nil)
+}
+
+// format 按 go-redis 的参数编码规则将值转换为字符串
+func format(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return strconv.FormatInt(v.Nanoseconds(), 10), nil
+	case encoding.BinaryMarshaler:
+		b, err := v.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	return "", errors.Wrapf(errors.ErrInvalidParameter, "memkv: can't marshal %T (implement encoding.BinaryMarshaler)", v)
+}
+
+// formatAll 转换多个值
+func formatAll(values []interface{}) ([]string, error) {
+	out := make([]string, len(values))
+	for i, v := range values {
+		s, err := format(v)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+// globMatch 按 Redis 的 glob 规则匹配，支持 *、?、[abc]、[^a-z] 和 \ 转义
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern, s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := classEnd(pattern)
+			if end < 0 {
+				// 没有闭合的 [ 按字面匹配
+				if s[0] != '[' {
+					return false
+				}
+				break
+			}
+			if !matchClass(pattern[1:end], s[0]) {
+				return false
+			}
+			pattern, s = pattern[end+1:], s[1:]
+			continue
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// classEnd 返回字符类结束 ] 的下标，没有时返回 -1
+func classEnd(pattern string) int {
+	for i := 1; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// matchClass 判断字符是否属于字符类（不含两侧的方括号）
+func matchClass(class string, c byte) bool {
+	negate := len(class) > 0 && class[0] == '^'
+	if negate {
+		class = class[1:]
+	}
+	matched := false
+	for i := 0; i < len(class); i++ {
+		lo := class[i]
+		if lo == '\\' && i+1 < len(class) {
+			i++
+			lo = class[i]
+		}
+		hi := lo
+		if i+2 < len(class) && class[i+1] == '-' {
+			hi = class[i+2]
+			i += 2
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+		}
+		if c >= lo && c <= hi {
+			matched = true
+		}
+	}
+	return matched != negate
+}
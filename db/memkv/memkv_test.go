@@ -0,0 +1,464 @@
+package memkv
+
+import (
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestStoreTTL(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	s := New(WithClock(func() time.Time { return now }))
+
+	if _, err := s.Get(ctx, "k"); err != redis.Nil {
+		t.Fatalf("Get missing err = %v, want redis.Nil", err)
+	}
+	s.SetWithTTL(ctx, "k", "v", 10)
+	s.Set(ctx, "forever", 1)
+	if ttl, _ := s.TTL(ctx, "k"); ttl != 10*time.Second {
+		t.Errorf("TTL = %v", ttl)
+	}
+	if ttl, _ := s.TTL(ctx, "forever"); ttl != db.NoExpiration {
+		t.Errorf("TTL without expiry = %v", ttl)
+	}
+
+	now = now.Add(9 * time.Second)
+	if v, err := s.Get(ctx, "k"); err != nil || v != "v" {
+		t.Fatalf("Get before expiry = %q, %v", v, err)
+	}
+	s.FastForward(time.Second)
+	if _, err := s.Get(ctx, "k"); err != redis.Nil {
+		t.Errorf("Get after expiry err = %v", err)
+	}
+	if _, err := s.TTL(ctx, "k"); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("TTL after expiry err = %v", err)
+	}
+	if n, _ := s.Exists(ctx, "k", "forever"); n != 1 {
+		t.Errorf("Exists = %d, want 1", n)
+	}
+
+	if ok, _ := s.SetNX(ctx, "lock", "a", time.Second); !ok {
+		t.Error("SetNX on missing key failed")
+	}
+	if ok, _ := s.SetNX(ctx, "lock", "b", time.Second); ok {
+		t.Error("SetNX on existing key succeeded")
+	}
+	s.FastForward(time.Second)
+	if ok, _ := s.SetNX(ctx, "lock", "c", 0); !ok {
+		t.Error("SetNX after expiry failed")
+	}
+}
+
+func TestStoreCommands(t *testing.T) {
+	ctx := context.Background()
+	s := New()
+
+	if v, _ := s.IncrBy(ctx, "n", 5); v != 5 {
+		t.Errorf("IncrBy = %d", v)
+	}
+	if v, _ := s.Decr(ctx, "n"); v != 4 {
+		t.Errorf("Decr = %d", v)
+	}
+	s.Set(ctx, "str", "x")
+	if _, err := s.Incr(ctx, "str"); !errors.Is(err, ErrNotInteger) {
+		t.Errorf("Incr non-integer err = %v", err)
+	}
+	if _, err := s.HGet(ctx, "str", "f"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("HGet on string err = %v", err)
+	}
+
+	s.HSet(ctx, "h", map[string]interface{}{"a": 1, "b": true})
+	if all, _ := s.HGetAll(ctx, "h"); all["a"] != "1" || all["b"] != "1" {
+		t.Errorf("HGetAll = %v", all)
+	}
+	if _, err := s.HGet(ctx, "h", "missing"); !errors.Is(err, errors.ErrNotFound) || !errors.Is(err, redis.Nil) {
+		t.Errorf("HGet missing err = %v", err)
+	}
+	s.HDel(ctx, "h", "a", "b")
+	if n, _ := s.Exists(ctx, "h"); n != 0 {
+		t.Error("empty hash not removed")
+	}
+
+	s.RPush(ctx, "l", "b", "c")
+	s.LPush(ctx, "l", "a", "z")
+	if got, _ := s.LRange(ctx, "l", 0, -1); len(got) != 4 || got[0] != "z" || got[1] != "a" || got[3] != "c" {
+		t.Errorf("LRange = %v", got)
+	}
+	s.LTrim(ctx, "l", 1, -2)
+	if v, _ := s.RPop(ctx, "l"); v != "b" {
+		t.Errorf("RPop = %q", v)
+	}
+	s.LPop(ctx, "l")
+	if _, err := s.LPop(ctx, "l"); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("LPop empty err = %v", err)
+	}
+
+	if n, _ := s.SAdd(ctx, "s", "a", "b", "a"); n != 2 {
+		t.Errorf("SAdd = %d", n)
+	}
+	if ok, _ := s.SIsMember(ctx, "s", "b"); !ok {
+		t.Error("SIsMember = false")
+	}
+
+	s.ZAdd(ctx, "z", db.ZMember{Member: "b", Score: 2}, db.ZMember{Member: "a", Score: 1}, db.ZMember{Member: "c", Score: 2})
+	s.ZIncrBy(ctx, "z", "a", 5)
+	got, _ := s.ZRange(ctx, "z", 0, -1)
+	if len(got) != 3 || got[0].Member != "b" || got[1].Member != "c" || got[2] != (db.ZMember{Member: "a", Score: 6}) {
+		t.Errorf("ZRange = %v", got)
+	}
+	got, _ = s.ZRangeByScore(ctx, "z", 2, 10, 1, 1)
+	if len(got) != 1 || got[0].Member != "c" {
+		t.Errorf("ZRangeByScore = %v", got)
+	}
+
+	var keys []string
+	for it := s.Scan(ctx, "[nz]", 0); it.Next(ctx); {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 2 || keys[0] != "n" || keys[1] != "z" {
+		t.Errorf("Scan = %v", keys)
+	}
+
+	s.Close()
+	if _, err := s.Get(ctx, "n"); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Get after Close err = %v", err)
+	}
+}
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"a/*", "a/b/c", true},
+		{"h?llo", "hello", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{`a\*`, "a*", true},
+		{`a\*`, "ab", false},
+		{"[", "[", true},
+	}
+	for _, c := range cases {
+		if got := globMatch(c.pattern, c.s); got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.s, got, c.want)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+	srv, err := NewServer(store)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+	rc := srv.NewClient()
+	defer rc.Close()
+
+	if err := rc.Ping(ctx).Err(); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+	if err := rc.Set(ctx, "code:1", "123456", 5*time.Minute).Err(); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if ttl := rc.TTL(ctx, "code:1").Val(); ttl != 5*time.Minute {
+		t.Errorf("TTL = %v", ttl)
+	}
+	store.FastForward(5 * time.Minute)
+	if err := rc.Get(ctx, "code:1").Err(); err != redis.Nil {
+		t.Errorf("Get expired err = %v", err)
+	}
+
+	// 通过客户端写入的数据可直接从 Store 读取
+	rc.HSet(ctx, "h", "f", "v")
+	if v, _ := store.HGet(ctx, "h", "f"); v != "v" {
+		t.Errorf("store.HGet = %q", v)
+	}
+	if err := rc.Incr(ctx, "h").Err(); err == nil || err.Error() != ErrWrongType.Error() {
+		t.Errorf("Incr on hash err = %v", err)
+	}
+
+	pipe := rc.Pipeline()
+	pipe.ZAdd(ctx, "z", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2.5, Member: "b"}, redis.Z{Score: 3, Member: "c"})
+	byScore := pipe.ZRangeByScoreWithScores(ctx, "z", &redis.ZRangeBy{Min: "(1", Max: "+inf"})
+	mget := pipe.MGet(ctx, "missing", "h")
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	if zs := byScore.Val(); len(zs) != 2 || zs[0].Member != "b" || zs[0].Score != 2.5 {
+		t.Errorf("ZRangeByScoreWithScores = %v", zs)
+	}
+	if vals := mget.Val(); len(vals) != 2 || vals[0] != nil || vals[1] != nil {
+		t.Errorf("MGet = %v", vals)
+	}
+
+	var keys []string
+	iter := rc.Scan(ctx, 0, "*", 1).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil || len(keys) != 2 {
+		t.Errorf("Scan = %v, %v", keys, err)
+	}
+
+	if err := rc.Do(ctx, "EVAL", "return 1", 0).Err(); err == nil {
+		t.Error("EVAL should be unsupported")
+	}
+}
+
+// newTestServer 启动使用可控时钟的 Server，返回 go-redis 客户端和时钟推进函数
+func newTestServer(t *testing.T) (*redis.Client, *Store, func(time.Duration)) {
+	t.Helper()
+	now := time.Unix(1700000000, 0)
+	store := New(WithClock(func() time.Time { return now }))
+	srv, err := NewServer(store)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	rc := srv.NewClient()
+	t.Cleanup(func() {
+		rc.Close()
+		srv.Close()
+	})
+	return rc, store, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestServerExpiry(t *testing.T) {
+	ctx := context.Background()
+	rc, _, advance := newTestServer(t)
+
+	rc.Set(ctx, "ex", "v", 10*time.Second)
+	rc.SetEx(ctx, "setex", "v", 2*time.Second)
+	rc.Set(ctx, "px", "v", 0)
+	rc.PExpire(ctx, "px", 1500*time.Millisecond)
+	rc.Set(ctx, "forever", "v", 0)
+
+	if ttl := rc.PTTL(ctx, "px").Val(); ttl != 1500*time.Millisecond {
+		t.Errorf("PTTL = %v", ttl)
+	}
+	if ttl := rc.TTL(ctx, "forever").Val(); ttl != -1 {
+		t.Errorf("TTL without expiry = %v, want -1", ttl)
+	}
+	if ttl := rc.TTL(ctx, "missing").Val(); ttl != -2 {
+		t.Errorf("TTL of missing key = %v, want -2", ttl)
+	}
+	if ok := rc.Expire(ctx, "missing", time.Second).Val(); ok {
+		t.Error("Expire on missing key returned true")
+	}
+
+	// 时钟推进后惰性过期
+	advance(1500 * time.Millisecond)
+	if err := rc.Get(ctx, "px").Err(); err != redis.Nil {
+		t.Errorf("Get px after expiry err = %v", err)
+	}
+	if v := rc.Get(ctx, "setex").Val(); v != "v" {
+		t.Errorf("Get setex before expiry = %q", v)
+	}
+	if ttl := rc.TTL(ctx, "ex").Val(); ttl != 9*time.Second {
+		t.Errorf("TTL after 1.5s = %v, want 9s", ttl)
+	}
+
+	// PERSIST 清除过期时间
+	if ok := rc.Persist(ctx, "ex").Val(); !ok {
+		t.Error("Persist returned false")
+	}
+	advance(time.Minute)
+	if n := rc.Exists(ctx, "ex", "setex", "forever").Val(); n != 2 {
+		t.Errorf("Exists after a minute = %d, want 2", n)
+	}
+	if n := rc.DBSize(ctx).Val(); n != 2 {
+		t.Errorf("DBSize = %d, want 2", n)
+	}
+
+	// SETNX 可覆盖已过期的键
+	rc.Set(ctx, "lock", "a", time.Second)
+	if ok := rc.SetNX(ctx, "lock", "b", time.Second).Val(); ok {
+		t.Error("SetNX on live key succeeded")
+	}
+	advance(time.Second)
+	if ok := rc.SetNX(ctx, "lock", "c", 0).Val(); !ok {
+		t.Error("SetNX on expired key failed")
+	}
+}
+
+func TestServerTypeErrors(t *testing.T) {
+	ctx := context.Background()
+	rc, _, _ := newTestServer(t)
+
+	rc.Set(ctx, "str", "x", 0)
+	rc.HSet(ctx, "hash", "f", "v")
+	rc.RPush(ctx, "list", "a")
+	rc.SAdd(ctx, "set", "a")
+	rc.ZAdd(ctx, "zset", redis.Z{Score: 1, Member: "a"})
+
+	for key, want := range map[string]string{"str": "string", "hash": "hash", "list": "list", "set": "set", "zset": "zset", "missing": "none"} {
+		if got := rc.Type(ctx, key).Val(); got != want {
+			t.Errorf("TYPE %s = %q, want %q", key, got, want)
+		}
+	}
+
+	isWrongType := func(err error) bool {
+		return err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE")
+	}
+	for name, err := range map[string]error{
+		"GET hash":    rc.Get(ctx, "hash").Err(),
+		"HGET str":    rc.HGet(ctx, "str", "f").Err(),
+		"LPUSH set":   rc.LPush(ctx, "set", "b").Err(),
+		"SADD list":   rc.SAdd(ctx, "list", "b").Err(),
+		"ZADD hash":   rc.ZAdd(ctx, "hash", redis.Z{Score: 1, Member: "b"}).Err(),
+		"LRANGE zset": rc.LRange(ctx, "zset", 0, -1).Err(),
+		"INCR list":   rc.Incr(ctx, "list").Err(),
+	} {
+		if !isWrongType(err) {
+			t.Errorf("%s err = %v, want WRONGTYPE", name, err)
+		}
+	}
+
+	if err := rc.Incr(ctx, "str").Err(); err == nil || err.Error() != ErrNotInteger.Error() {
+		t.Errorf("INCR non-integer err = %v", err)
+	}
+	if err := rc.Do(ctx, "ZINCRBY", "zset", "abc", "a").Err(); err == nil || err.Error() != ErrNotFloat.Error() {
+		t.Errorf("ZINCRBY non-float err = %v", err)
+	}
+	if err := rc.Do(ctx, "GET").Err(); err == nil || !strings.Contains(err.Error(), "wrong number of arguments") {
+		t.Errorf("GET without key err = %v", err)
+	}
+
+	// 类型错误不修改原值
+	if v := rc.Get(ctx, "str").Val(); v != "x" {
+		t.Errorf("str = %q after failed commands", v)
+	}
+}
+
+func TestServerScanCursor(t *testing.T) {
+	ctx := context.Background()
+	rc, _, advance := newTestServer(t)
+
+	for i := 0; i < 25; i++ {
+		rc.Set(ctx, "user:"+strconv.Itoa(i), "v", 0)
+	}
+	rc.HSet(ctx, "user:h", "f", "v")
+	rc.Set(ctx, "order:1", "v", 0)
+	rc.Set(ctx, "user:tmp", "v", time.Second)
+	advance(time.Second)
+
+	// 按游标手动遍历，每次最多检查 COUNT 个键，游标回到 0 时结束
+	scan := func(match string, typ string) ([]string, int) {
+		t.Helper()
+		var (
+			keys   []string
+			cursor uint64
+			calls  int
+		)
+		for {
+			var page []string
+			var err error
+			if typ != "" {
+				page, cursor, err = rc.ScanType(ctx, cursor, match, 10, typ).Result()
+			} else {
+				page, cursor, err = rc.Scan(ctx, cursor, match, 10).Result()
+			}
+			if err != nil {
+				t.Fatalf("SCAN: %v", err)
+			}
+			calls++
+			if len(page) > 10 {
+				t.Fatalf("SCAN returned %d keys with COUNT 10", len(page))
+			}
+			keys = append(keys, page...)
+			if cursor == 0 {
+				return keys, calls
+			}
+		}
+	}
+
+	keys, calls := scan("", "")
+	if len(keys) != 27 || calls != 3 {
+		t.Errorf("full scan = %d keys in %d calls, want 27 in 3", len(keys), calls)
+	}
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if seen[k] {
+			t.Errorf("key %s returned twice", k)
+		}
+		seen[k] = true
+	}
+	if seen["user:tmp"] {
+		t.Error("expired key returned by SCAN")
+	}
+
+	if keys, _ := scan("user:*", ""); len(keys) != 26 {
+		t.Errorf("MATCH user:* = %d keys, want 26", len(keys))
+	}
+	if keys, _ := scan("*", "hash"); len(keys) != 1 || keys[0] != "user:h" {
+		t.Errorf("TYPE hash = %v", keys)
+	}
+	if err := rc.Do(ctx, "SCAN", "abc").Err(); err == nil {
+		t.Error("SCAN with invalid cursor succeeded")
+	}
+}
+
+func TestServerUnsupported(t *testing.T) {
+	ctx := context.Background()
+	rc, _, _ := newTestServer(t)
+
+	// Lua 脚本、事务、pub/sub 和 Stream 不受支持，依赖它们的测试需使用真实 Redis 或 miniredis
+	for _, args := range [][]interface{}{
+		{"EVAL", "return 1", 0},
+		{"MULTI"},
+		{"PUBLISH", "ch", "msg"},
+		{"SUBSCRIBE", "ch"},
+		{"XADD", "s", "*", "f", "v"},
+	} {
+		err := rc.Do(ctx, args...).Err()
+		if err == nil || !strings.Contains(err.Error(), "unknown command") {
+			t.Errorf("%v err = %v, want unknown command", args[0], err)
+		}
+	}
+}
+
+func TestServerProtocolError(t *testing.T) {
+	srv, err := NewServer(New())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+
+	// 非法的数组和参数长度返回协议错误并断开连接，不会因分配内存而崩溃
+	for _, req := range []string{
+		"*-1\r\n",
+		"*abc\r\n",
+		"*" + strconv.Itoa(maxMultibulkLen+1) + "\r\n",
+		"*1\r\n$-1\r\n",
+		"*1\r\n$" + strconv.Itoa(maxBulkLen+1) + "\r\n",
+	} {
+		conn, err := net.Dial("tcp", srv.Addr())
+		if err != nil {
+			t.Fatalf("Dial: %v", err)
+		}
+		_ = conn.SetDeadline(time.Now().Add(3 * time.Second))
+		if _, err := conn.Write([]byte(req)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		reply, err := io.ReadAll(conn)
+		conn.Close()
+		if err != nil {
+			t.Fatalf("%q: ReadAll: %v", req, err)
+		}
+		if !strings.HasPrefix(string(reply), "-ERR Protocol error: invalid") {
+			t.Errorf("%q reply = %q, want protocol error", req, reply)
+		}
+	}
+}
@@ -0,0 +1,1072 @@
+package memkv
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Server 在回环地址上以 RESP2 协议暴露 Store 的最小 Redis 服务
+//
+// 支持字符串、计数器、过期、哈希、列表、集合、有序集合和 SCAN 等常用命令，
+// 不支持 Lua 脚本、事务、pub/sub 和 Stream。go-redis 客户端可直接连接：
+//
+//	srv, _ := memkv.NewServer(memkv.New())
+//	defer srv.Close()
+//	rc := srv.NewClient()
+type Server struct {
+	store *Store
+	ln    net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewServer 在 127.0.0.1 的随机端口上启动服务
+func NewServer(store *Store) (*Server, error) {
+	if store == nil {
+		return nil, errors.ErrNilClient
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "memkv: listen")
+	}
+	srv := &Server{
+		store: store,
+		ln:    ln,
+		conns: make(map[net.Conn]struct{}),
+	}
+	srv.wg.Add(1)
+	go srv.serve()
+	return srv, nil
+}
+
+// Addr 返回监听地址，格式为 host:port
+func (srv *Server) Addr() string {
+	return srv.ln.Addr().String()
+}
+
+// Store 返回服务使用的存储
+func (srv *Server) Store() *Store {
+	return srv.store
+}
+
+// NewClient 创建连接到本服务的 go-redis 客户端，由调用方关闭
+func (srv *Server) NewClient() *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: srv.Addr()})
+}
+
+// Close 停止监听并断开所有连接，不关闭 Store
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	if srv.closed {
+		srv.mu.Unlock()
+		return errors.ErrAlreadyClosed
+	}
+	srv.closed = true
+	err := srv.ln.Close()
+	for conn := range srv.conns {
+		_ = conn.Close()
+	}
+	srv.mu.Unlock()
+
+	srv.wg.Wait()
+	return err
+}
+
+// serve 接受连接
+func (srv *Server) serve() {
+	defer srv.wg.Done()
+
+	for {
+		conn, err := srv.ln.Accept()
+		if err != nil {
+			return
+		}
+		srv.mu.Lock()
+		if srv.closed {
+			srv.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		srv.conns[conn] = struct{}{}
+		srv.wg.Add(1)
+		srv.mu.Unlock()
+
+		go srv.handle(conn)
+	}
+}
+
+// handle 逐条读取命令并回复，直到连接关闭
+func (srv *Server) handle(conn net.Conn) {
+	defer srv.wg.Done()
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.conns, conn)
+		srv.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := &respWriter{w: bufio.NewWriter(conn)}
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if err != io.EOF {
+				w.err("ERR Protocol error: " + err.Error())
+				_ = w.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		name := strings.ToUpper(args[0])
+		srv.exec(w, name, args[1:])
+		// 管道中的命令已在缓冲区时延迟刷新，减少系统调用
+		if r.Buffered() == 0 {
+			if err := w.w.Flush(); err != nil {
+				return
+			}
+		}
+		if name == "QUIT" {
+			_ = w.w.Flush()
+			return
+		}
+	}
+}
+
+// 与 Redis 默认配置一致的请求长度上限
+const (
+	maxMultibulkLen = 1024 * 1024       // 单条命令的最大参数个数
+	maxBulkLen      = 512 * 1024 * 1024 // 单个参数的最大字节数
+)
+
+// readCommand 读取一条多块字符串数组格式的命令
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, nil
+	}
+	if line[0] != '*' {
+		// 内联命令，如 telnet 输入
+		return strings.Fields(line), nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxMultibulkLen {
+		return nil, fmt.Errorf("invalid multibulk length")
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("expected '$', got %q", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("invalid bulk length")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+// readLine 读取一行并去掉结尾的 \r\n
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// respWriter RESP2 回复编码
+type respWriter struct {
+	w *bufio.Writer
+}
+
+func (w *respWriter) simple(s string) {
+	w.w.WriteString("+" + s + "\r\n")
+}
+
+func (w *respWriter) err(msg string) {
+	w.w.WriteString("-" + msg + "\r\n")
+}
+
+func (w *respWriter) int(n int64) {
+	w.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+func (w *respWriter) bool(b bool) {
+	if b {
+		w.int(1)
+	} else {
+		w.int(0)
+	}
+}
+
+func (w *respWriter) bulk(s string) {
+	w.w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func (w *respWriter) null() {
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w *respWriter) array(n int) {
+	w.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (w *respWriter) strings(values []string) {
+	w.array(len(values))
+	for _, v := range values {
+		w.bulk(v)
+	}
+}
+
+// error 输出错误回复，非 Redis 格式的错误加上 ERR 前缀
+func (w *respWriter) error(err error) {
+	msg := err.Error()
+	switch {
+	case errors.Is(err, redis.Nil):
+		w.null()
+		return
+	case errors.Is(err, errors.ErrAlreadyClosed):
+		msg = "ERR memkv: store " + msg
+	case !errors.Is(err, ErrWrongType) && !errors.Is(err, ErrNotInteger) && !errors.Is(err, ErrNotFloat):
+		msg = "ERR " + msg
+	}
+	w.err(msg)
+}
+
+// command 命令定义，arity 为包含命令名的参数个数，负数表示至少 -arity 个
+type command struct {
+	arity int
+	fn    func(srv *Server, w *respWriter, args []string) error
+}
+
+// commands 支持的命令
+var commands = map[string]command{
+	"PING":     {-1, cmdPing},
+	"ECHO":     {2, cmdEcho},
+	"QUIT":     {1, cmdOK},
+	"SELECT":   {2, cmdOK},
+	"CLIENT":   {-2, cmdOK},
+	"READONLY": {1, cmdOK},
+
+	"GET":    {2, cmdGet},
+	"SET":    {-3, cmdSet},
+	"SETEX":  {4, cmdSetEX},
+	"SETNX":  {3, cmdSetNX},
+	"MGET":   {-2, cmdMGet},
+	"MSET":   {-3, cmdMSet},
+	"INCR":   {2, cmdIncrBy(1)},
+	"DECR":   {2, cmdIncrBy(-1)},
+	"INCRBY": {3, cmdIncrBy(1)},
+	"DECRBY": {3, cmdIncrBy(-1)},
+
+	"DEL":      {-2, cmdDel},
+	"UNLINK":   {-2, cmdDel},
+	"EXISTS":   {-2, cmdExists},
+	"EXPIRE":   {3, cmdExpire(time.Second)},
+	"PEXPIRE":  {3, cmdExpire(time.Millisecond)},
+	"TTL":      {2, cmdTTL(time.Second)},
+	"PTTL":     {2, cmdTTL(time.Millisecond)},
+	"PERSIST":  {2, cmdPersist},
+	"TYPE":     {2, cmdType},
+	"KEYS":     {2, cmdKeys},
+	"SCAN":     {-2, cmdScan},
+	"DBSIZE":   {1, cmdDBSize},
+	"FLUSHDB":  {-1, cmdFlush},
+	"FLUSHALL": {-1, cmdFlush},
+
+	"HGET":    {3, cmdHGet},
+	"HSET":    {-4, cmdHSet},
+	"HMSET":   {-4, cmdHMSet},
+	"HGETALL": {2, cmdHGetAll},
+	"HDEL":    {-3, cmdHDel},
+	"HEXISTS": {3, cmdHExists},
+	"HINCRBY": {4, cmdHIncrBy},
+	"HLEN":    {2, cmdHLen},
+
+	"LPUSH":  {-3, cmdPush(true)},
+	"RPUSH":  {-3, cmdPush(false)},
+	"LPOP":   {2, cmdPop(true)},
+	"RPOP":   {2, cmdPop(false)},
+	"LRANGE": {4, cmdLRange},
+	"LTRIM":  {4, cmdLTrim},
+	"LLEN":   {2, cmdLLen},
+
+	"SADD":      {-3, cmdSAdd},
+	"SREM":      {-3, cmdSRem},
+	"SMEMBERS":  {2, cmdSMembers},
+	"SISMEMBER": {3, cmdSIsMember},
+	"SCARD":     {2, cmdSCard},
+
+	"ZADD":          {-4, cmdZAdd},
+	"ZREM":          {-3, cmdZRem},
+	"ZSCORE":        {3, cmdZScore},
+	"ZINCRBY":       {4, cmdZIncrBy},
+	"ZRANGE":        {-4, cmdZRange},
+	"ZRANGEBYSCORE": {-4, cmdZRangeByScore},
+	"ZCARD":         {2, cmdZCard},
+}
+
+// exec 执行命令并写入回复
+func (srv *Server) exec(w *respWriter, name string, args []string) {
+	cmd, ok := commands[name]
+	if !ok {
+		w.err(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+		return
+	}
+	if n := len(args) + 1; (cmd.arity > 0 && n != cmd.arity) || (cmd.arity < 0 && n < -cmd.arity) {
+		w.err(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return
+	}
+	if err := cmd.fn(srv, w, args); err != nil {
+		w.error(err)
+	}
+}
+
+// errSyntax 语法错误
+var errSyntax = errors.New("syntax error")
+
+// parseInt 解析整数参数
+func parseInt(s string) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	return n, nil
+}
+
+// parseScore 解析有序集合分数区间参数，支持 -inf、+inf 和表示开区间的 ( 前缀
+func parseScore(s string) (float64, error) {
+	exclusive := strings.HasPrefix(s, "(")
+	if exclusive {
+		s = s[1:]
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, errors.New("min or max is not a float")
+	}
+	return f, nil
+}
+
+// formatFloat 按 Redis 的方式格式化分数
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func cmdOK(srv *Server, w *respWriter, args []string) error {
+	w.simple("OK")
+	return nil
+}
+
+func cmdPing(srv *Server, w *respWriter, args []string) error {
+	if len(args) > 0 {
+		w.bulk(args[0])
+	} else {
+		w.simple("PONG")
+	}
+	return nil
+}
+
+func cmdEcho(srv *Server, w *respWriter, args []string) error {
+	w.bulk(args[0])
+	return nil
+}
+
+func cmdGet(srv *Server, w *respWriter, args []string) error {
+	v, err := srv.store.Get(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.bulk(v)
+	return nil
+}
+
+// cmdSet 支持 EX、PX、NX、XX、KEEPTTL 和 GET 选项
+func cmdSet(srv *Server, w *respWriter, args []string) error {
+	key, value := args[0], args[1]
+	var (
+		ttl                 time.Duration
+		nx, xx, keep, doGet bool
+	)
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "KEEPTTL":
+			keep = true
+		case "GET":
+			doGet = true
+		case "EX", "PX":
+			if i+1 >= len(args) {
+				return errSyntax
+			}
+			n, err := parseInt(args[i+1])
+			if err != nil {
+				return err
+			}
+			if n <= 0 {
+				return errors.New("invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if strings.ToUpper(args[i]) == "PX" {
+				unit = time.Millisecond
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return errSyntax
+		}
+	}
+	if nx && xx {
+		return errSyntax
+	}
+
+	return srv.store.do(func() error {
+		old, err := srv.store.lookup(key, kindString)
+		if doGet && err != nil {
+			return err
+		}
+		exists := srv.store.get(key) != nil
+		if (nx && exists) || (xx && !exists) {
+			if doGet && old != nil {
+				w.bulk(old.str)
+			} else {
+				w.null()
+			}
+			return nil
+		}
+		srv.store.set(key, value, ttl, keep)
+		switch {
+		case doGet && old != nil:
+			w.bulk(old.str)
+		case doGet:
+			w.null()
+		default:
+			w.simple("OK")
+		}
+		return nil
+	})
+}
+
+func cmdSetEX(srv *Server, w *respWriter, args []string) error {
+	n, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return errors.New("invalid expire time in 'setex' command")
+	}
+	return srv.store.do(func() error {
+		srv.store.set(args[0], args[2], time.Duration(n)*time.Second, false)
+		w.simple("OK")
+		return nil
+	})
+}
+
+func cmdSetNX(srv *Server, w *respWriter, args []string) error {
+	ok, err := srv.store.SetNX(context.Background(), args[0], args[1], 0)
+	if err != nil {
+		return err
+	}
+	w.bool(ok)
+	return nil
+}
+
+func cmdMGet(srv *Server, w *respWriter, args []string) error {
+	values, err := srv.store.MGet(context.Background(), args...)
+	if err != nil {
+		return err
+	}
+	w.array(len(args))
+	for _, key := range args {
+		if v, ok := values[key]; ok {
+			w.bulk(v)
+		} else {
+			w.null()
+		}
+	}
+	return nil
+}
+
+func cmdMSet(srv *Server, w *respWriter, args []string) error {
+	if len(args)%2 != 0 {
+		return errors.New("wrong number of arguments for 'mset' command")
+	}
+	return srv.store.do(func() error {
+		for i := 0; i < len(args); i += 2 {
+			srv.store.set(args[i], args[i+1], 0, false)
+		}
+		w.simple("OK")
+		return nil
+	})
+}
+
+// cmdIncrBy 实现 INCR/DECR/INCRBY/DECRBY，sign 为 -1 时取反
+func cmdIncrBy(sign int64) func(srv *Server, w *respWriter, args []string) error {
+	return func(srv *Server, w *respWriter, args []string) error {
+		n := int64(1)
+		if len(args) > 1 {
+			var err error
+			if n, err = parseInt(args[1]); err != nil {
+				return err
+			}
+		}
+		return srv.store.do(func() error {
+			v, err := srv.store.incrBy(args[0], sign*n)
+			if err != nil {
+				return err
+			}
+			w.int(v)
+			return nil
+		})
+	}
+}
+
+func cmdDel(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		w.int(srv.store.del(args...))
+		return nil
+	})
+}
+
+func cmdExists(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.Exists(context.Background(), args...)
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+// cmdExpire 实现 EXPIRE/PEXPIRE
+func cmdExpire(unit time.Duration) func(srv *Server, w *respWriter, args []string) error {
+	return func(srv *Server, w *respWriter, args []string) error {
+		n, err := parseInt(args[1])
+		if err != nil {
+			return err
+		}
+		ok, err := srv.store.Expire(context.Background(), args[0], time.Duration(n)*unit)
+		if err != nil {
+			return err
+		}
+		w.bool(ok)
+		return nil
+	}
+}
+
+// cmdTTL 实现 TTL/PTTL，剩余时间向上取整
+func cmdTTL(unit time.Duration) func(srv *Server, w *respWriter, args []string) error {
+	return func(srv *Server, w *respWriter, args []string) error {
+		return srv.store.do(func() error {
+			ttl := srv.store.ttl(args[0])
+			if ttl < 0 {
+				w.int(int64(ttl))
+			} else {
+				w.int(int64((ttl + unit - 1) / unit))
+			}
+			return nil
+		})
+	}
+}
+
+func cmdPersist(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		e := srv.store.get(args[0])
+		ok := e != nil && !e.expireAt.IsZero()
+		if ok {
+			e.expireAt = time.Time{}
+		}
+		w.bool(ok)
+		return nil
+	})
+}
+
+func cmdType(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		if e := srv.store.get(args[0]); e != nil {
+			w.simple(e.kind.String())
+		} else {
+			w.simple("none")
+		}
+		return nil
+	})
+}
+
+func cmdKeys(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		w.strings(srv.store.keys(args[0]))
+		return nil
+	})
+}
+
+// cmdScan 以排序后键列表的下标作为游标，支持 MATCH、COUNT 和 TYPE 选项
+func cmdScan(srv *Server, w *respWriter, args []string) error {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return errors.New("invalid cursor")
+	}
+	match, count, typ := "", int64(10), ""
+	for i := 1; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			return errSyntax
+		}
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			match = args[i+1]
+		case "COUNT":
+			if count, err = parseInt(args[i+1]); err != nil {
+				return err
+			}
+			if count < 1 {
+				return errSyntax
+			}
+		case "TYPE":
+			typ = strings.ToLower(args[i+1])
+		default:
+			return errSyntax
+		}
+	}
+
+	return srv.store.do(func() error {
+		all := srv.store.keys("")
+		var keys []string
+		next := cursor
+		for next < uint64(len(all)) && int64(next-cursor) < count {
+			key := all[next]
+			next++
+			if match != "" && !globMatch(match, key) {
+				continue
+			}
+			if typ != "" && srv.store.data[key].kind.String() != typ {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		if next >= uint64(len(all)) {
+			next = 0
+		}
+		w.array(2)
+		w.bulk(strconv.FormatUint(next, 10))
+		w.strings(keys)
+		return nil
+	})
+}
+
+func cmdDBSize(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		w.int(int64(len(srv.store.keys(""))))
+		return nil
+	})
+}
+
+func cmdFlush(srv *Server, w *respWriter, args []string) error {
+	srv.store.FlushAll()
+	w.simple("OK")
+	return nil
+}
+
+func cmdHGet(srv *Server, w *respWriter, args []string) error {
+	v, err := srv.store.HGet(context.Background(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+	w.bulk(v)
+	return nil
+}
+
+func cmdHSet(srv *Server, w *respWriter, args []string) error {
+	if len(args)%2 != 1 {
+		return errors.New("wrong number of arguments for 'hset' command")
+	}
+	return srv.store.do(func() error {
+		n, err := srv.store.hset(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		w.int(n)
+		return nil
+	})
+}
+
+func cmdHMSet(srv *Server, w *respWriter, args []string) error {
+	if len(args)%2 != 1 {
+		return errors.New("wrong number of arguments for 'hmset' command")
+	}
+	return srv.store.do(func() error {
+		if _, err := srv.store.hset(args[0], args[1:]); err != nil {
+			return err
+		}
+		w.simple("OK")
+		return nil
+	})
+}
+
+func cmdHGetAll(srv *Server, w *respWriter, args []string) error {
+	values, err := srv.store.HGetAll(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.array(len(values) * 2)
+	for field, v := range values {
+		w.bulk(field)
+		w.bulk(v)
+	}
+	return nil
+}
+
+func cmdHDel(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.HDel(context.Background(), args[0], args[1:]...)
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+func cmdHExists(srv *Server, w *respWriter, args []string) error {
+	ok, err := srv.store.HExists(context.Background(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+	w.bool(ok)
+	return nil
+}
+
+func cmdHIncrBy(srv *Server, w *respWriter, args []string) error {
+	n, err := parseInt(args[2])
+	if err != nil {
+		return err
+	}
+	v, err := srv.store.HIncrBy(context.Background(), args[0], args[1], n)
+	if err != nil {
+		return err
+	}
+	w.int(v)
+	return nil
+}
+
+func cmdHLen(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.HLen(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+// cmdPush 实现 LPUSH/RPUSH
+func cmdPush(head bool) func(srv *Server, w *respWriter, args []string) error {
+	return func(srv *Server, w *respWriter, args []string) error {
+		return srv.store.do(func() error {
+			n, err := srv.store.pushStrings(args[0], args[1:], head)
+			if err != nil {
+				return err
+			}
+			w.int(n)
+			return nil
+		})
+	}
+}
+
+// cmdPop 实现 LPOP/RPOP，列表不存在时回复空值
+func cmdPop(head bool) func(srv *Server, w *respWriter, args []string) error {
+	return func(srv *Server, w *respWriter, args []string) error {
+		return srv.store.do(func() error {
+			v, ok, err := srv.store.popString(args[0], head)
+			switch {
+			case err != nil:
+				return err
+			case ok:
+				w.bulk(v)
+			default:
+				w.null()
+			}
+			return nil
+		})
+	}
+}
+
+func cmdLRange(srv *Server, w *respWriter, args []string) error {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return err
+	}
+	values, err := srv.store.LRange(context.Background(), args[0], start, stop)
+	if err != nil {
+		return err
+	}
+	w.strings(values)
+	return nil
+}
+
+func cmdLTrim(srv *Server, w *respWriter, args []string) error {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return err
+	}
+	if err := srv.store.LTrim(context.Background(), args[0], start, stop); err != nil {
+		return err
+	}
+	w.simple("OK")
+	return nil
+}
+
+func cmdLLen(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.LLen(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+func cmdSAdd(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		n, err := srv.store.sadd(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		w.int(n)
+		return nil
+	})
+}
+
+func cmdSRem(srv *Server, w *respWriter, args []string) error {
+	return srv.store.do(func() error {
+		n, err := srv.store.srem(args[0], args[1:])
+		if err != nil {
+			return err
+		}
+		w.int(n)
+		return nil
+	})
+}
+
+func cmdSMembers(srv *Server, w *respWriter, args []string) error {
+	members, err := srv.store.SMembers(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.strings(members)
+	return nil
+}
+
+func cmdSIsMember(srv *Server, w *respWriter, args []string) error {
+	ok, err := srv.store.SIsMember(context.Background(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+	w.bool(ok)
+	return nil
+}
+
+func cmdSCard(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.SCard(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+// cmdZAdd 只支持 score member 对，不支持 NX/XX/GT/LT 等选项
+func cmdZAdd(srv *Server, w *respWriter, args []string) error {
+	if len(args)%2 != 1 {
+		return errSyntax
+	}
+	members := make([]db.ZMember, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		score, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return ErrNotFloat
+		}
+		members = append(members, db.ZMember{Member: args[i+1], Score: score})
+	}
+	n, err := srv.store.ZAdd(context.Background(), args[0], members...)
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+func cmdZRem(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.ZRem(context.Background(), args[0], args[1:]...)
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
+
+func cmdZScore(srv *Server, w *respWriter, args []string) error {
+	score, err := srv.store.ZScore(context.Background(), args[0], args[1])
+	if err != nil {
+		return err
+	}
+	w.bulk(formatFloat(score))
+	return nil
+}
+
+func cmdZIncrBy(srv *Server, w *respWriter, args []string) error {
+	n, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return ErrNotFloat
+	}
+	score, err := srv.store.ZIncrBy(context.Background(), args[0], args[2], n)
+	if err != nil {
+		return err
+	}
+	w.bulk(formatFloat(score))
+	return nil
+}
+
+// writeZMembers 输出有序集合成员，withScores 时成员与分数交替
+func writeZMembers(w *respWriter, members []db.ZMember, withScores bool) {
+	if !withScores {
+		w.array(len(members))
+		for _, m := range members {
+			w.bulk(m.Member)
+		}
+		return
+	}
+	w.array(len(members) * 2)
+	for _, m := range members {
+		w.bulk(m.Member)
+		w.bulk(formatFloat(m.Score))
+	}
+}
+
+// cmdZRange 只支持按下标范围查询和 WITHSCORES 选项
+func cmdZRange(srv *Server, w *respWriter, args []string) error {
+	start, err := parseInt(args[1])
+	if err != nil {
+		return err
+	}
+	stop, err := parseInt(args[2])
+	if err != nil {
+		return err
+	}
+	withScores := false
+	for _, opt := range args[3:] {
+		if strings.ToUpper(opt) != "WITHSCORES" {
+			return errSyntax
+		}
+		withScores = true
+	}
+	members, err := srv.store.ZRange(context.Background(), args[0], start, stop)
+	if err != nil {
+		return err
+	}
+	writeZMembers(w, members, withScores)
+	return nil
+}
+
+func cmdZRangeByScore(srv *Server, w *respWriter, args []string) error {
+	min, err := parseScore(args[1])
+	if err != nil {
+		return err
+	}
+	max, err := parseScore(args[2])
+	if err != nil {
+		return err
+	}
+	// 开区间转换为相邻的可表示浮点数
+	if strings.HasPrefix(args[1], "(") {
+		min = math.Nextafter(min, math.Inf(1))
+	}
+	if strings.HasPrefix(args[2], "(") {
+		max = math.Nextafter(max, math.Inf(-1))
+	}
+	var (
+		withScores, none bool
+		offset, count    int64
+	)
+	for i := 3; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "WITHSCORES":
+			withScores = true
+		case "LIMIT":
+			if i+2 >= len(args) {
+				return errSyntax
+			}
+			if offset, err = parseInt(args[i+1]); err != nil {
+				return err
+			}
+			if count, err = parseInt(args[i+2]); err != nil {
+				return err
+			}
+			// 负数表示不限制数量，0 表示不返回任何成员
+			none = count == 0
+			if count < 0 {
+				count = 0
+			}
+			i += 2
+		default:
+			return errSyntax
+		}
+	}
+	if none {
+		writeZMembers(w, nil, withScores)
+		return nil
+	}
+	members, err := srv.store.ZRangeByScore(context.Background(), args[0], min, max, offset, count)
+	if err != nil {
+		return err
+	}
+	writeZMembers(w, members, withScores)
+	return nil
+}
+
+func cmdZCard(srv *Server, w *respWriter, args []string) error {
+	n, err := srv.store.ZCard(context.Background(), args[0])
+	if err != nil {
+		return err
+	}
+	w.int(n)
+	return nil
+}
@@ -0,0 +1,212 @@
+// Package metrics 将数据库客户端的连接池统计输出为 Prometheus 文本格式
+//
+// Collector 不依赖 Prometheus 客户端库，可直接作为 http.Handler 挂载到 /metrics，
+// 也可通过 WriteTo 拼接到已有的指标输出中。
+package metrics
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// DefaultNamespace 默认指标名前缀
+const DefaultNamespace = "gosuite"
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// metric 指标定义
+type metric[S any] struct {
+	name  string
+	help  string
+	typ   string
+	value func(S) float64
+}
+
+// sqlMetrics SQL 连接池指标
+var sqlMetrics = []metric[sql.DBStats]{
+	{"db_max_open_connections", "Maximum number of open connections to the database.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.MaxOpenConnections) }},
+	{"db_open_connections", "Number of established connections, both in use and idle.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.OpenConnections) }},
+	{"db_in_use_connections", "Number of connections currently in use.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.InUse) }},
+	{"db_idle_connections", "Number of idle connections.", "gauge",
+		func(s sql.DBStats) float64 { return float64(s.Idle) }},
+	{"db_wait_count_total", "Total number of connections waited for.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.WaitCount) }},
+	{"db_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", "counter",
+		func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }},
+	{"db_max_idle_closed_total", "Total number of connections closed due to SetMaxIdleConns.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleClosed) }},
+	{"db_max_idle_time_closed_total", "Total number of connections closed due to SetConnMaxIdleTime.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxIdleTimeClosed) }},
+	{"db_max_lifetime_closed_total", "Total number of connections closed due to SetConnMaxLifetime.", "counter",
+		func(s sql.DBStats) float64 { return float64(s.MaxLifetimeClosed) }},
+}
+
+// redisMetrics Redis 连接池指标
+var redisMetrics = []metric[db.RedisPoolStats]{
+	{"redis_pool_hits_total", "Total number of times a free connection was found in the pool.", "counter",
+		func(s db.RedisPoolStats) float64 { return float64(s.Hits) }},
+	{"redis_pool_misses_total", "Total number of times a free connection was not found in the pool.", "counter",
+		func(s db.RedisPoolStats) float64 { return float64(s.Misses) }},
+	{"redis_pool_timeouts_total", "Total number of times a wait timeout occurred.", "counter",
+		func(s db.RedisPoolStats) float64 { return float64(s.Timeouts) }},
+	{"redis_pool_connections", "Number of connections in the pool.", "gauge",
+		func(s db.RedisPoolStats) float64 { return float64(s.TotalConns) }},
+	{"redis_pool_idle_connections", "Number of idle connections in the pool.", "gauge",
+		func(s db.RedisPoolStats) float64 { return float64(s.IdleConns) }},
+	{"redis_pool_stale_connections_total", "Total number of stale connections removed from the pool.", "counter",
+		func(s db.RedisPoolStats) float64 { return float64(s.StaleConns) }},
+}
+
+// Collector 连接池指标收集器
+type Collector struct {
+	namespace string
+
+	mu      sync.RWMutex
+	clients map[string]db.Client
+}
+
+// Option 收集器配置选项函数
+type Option func(*Collector)
+
+// WithNamespace 设置指标名前缀，默认 gosuite，为空表示不加前缀
+func WithNamespace(namespace string) Option {
+	return func(c *Collector) {
+		c.namespace = namespace
+	}
+}
+
+// NewCollector 创建连接池指标收集器
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		namespace: DefaultNamespace,
+		clients:   make(map[string]db.Client),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Register 注册客户端，name 作为指标的 client 标签值
+func (c *Collector) Register(name string, client db.Client) error {
+	if client == nil {
+		return errors.ErrNilClient
+	}
+	if name == "" {
+		return errors.Wrap(errors.ErrInvalidParameter, "metrics: client name is required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.clients[name]; ok {
+		return errors.Wrap(errors.ErrInvalidParameter, "metrics: duplicate client "+name)
+	}
+	c.clients[name] = client
+	return nil
+}
+
+// Unregister 取消注册客户端
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.clients, name)
+}
+
+// sample 单个客户端单个连接池的统计
+type sample[S any] struct {
+	labels string
+	stats  S
+}
+
+// WriteTo 以 Prometheus 文本格式写出全部已注册客户端的连接池指标
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.clients))
+	for name := range c.clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		sqlSamples   []sample[sql.DBStats]
+		redisSamples []sample[db.RedisPoolStats]
+	)
+	for _, name := range names {
+		stats := c.clients[name].Stats()
+		if stats.SQL != nil {
+			sqlSamples = append(sqlSamples, sample[sql.DBStats]{labels(name, "primary"), *stats.SQL})
+		}
+		replicas := make([]string, 0, len(stats.Replicas))
+		for replica := range stats.Replicas {
+			replicas = append(replicas, replica)
+		}
+		sort.Strings(replicas)
+		for _, replica := range replicas {
+			sqlSamples = append(sqlSamples, sample[sql.DBStats]{labels(name, replica), stats.Replicas[replica]})
+		}
+		if stats.Redis != nil {
+			redisSamples = append(redisSamples, sample[db.RedisPoolStats]{labels(name, ""), *stats.Redis})
+		}
+	}
+	c.mu.RUnlock()
+
+	var buf bytes.Buffer
+	writeFamilies(&buf, c.namespace, sqlMetrics, sqlSamples)
+	writeFamilies(&buf, c.namespace, redisMetrics, redisSamples)
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP 实现 http.Handler，输出 Prometheus 文本格式指标
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	_, _ = c.WriteTo(w)
+}
+
+// writeFamilies 按指标族写出样本，每个指标族只输出一次 HELP 和 TYPE
+func writeFamilies[S any](buf *bytes.Buffer, namespace string, metrics []metric[S], samples []sample[S]) {
+	if len(samples) == 0 {
+		return
+	}
+	for _, m := range metrics {
+		name := m.name
+		if namespace != "" {
+			name = namespace + "_" + name
+		}
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, m.help, name, m.typ)
+		for _, s := range samples {
+			fmt.Fprintf(buf, "%s{%s} %s\n", name, s.labels, strconv.FormatFloat(m.value(s.stats), 'g', -1, 64))
+		}
+	}
+}
+
+// labels 生成标签串，pool 为空时省略 pool 标签
+func labels(client, pool string) string {
+	if pool == "" {
+		return `client="` + escape(client) + `"`
+	}
+	return `client="` + escape(client) + `",pool="` + escape(pool) + `"`
+}
+
+// labelEscaper 转义标签值中的反斜杠、双引号和换行
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escape 转义标签值
+func escape(v string) string {
+	return labelEscaper.Replace(v)
+}
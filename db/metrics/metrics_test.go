@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+)
+
+type fakeClient struct {
+	stats db.Stats
+}
+
+func (f *fakeClient) Close() error                   { return nil }
+func (f *fakeClient) Ping(ctx context.Context) error { return nil }
+func (f *fakeClient) IsConnected() bool              { return true }
+func (f *fakeClient) Stats() db.Stats                { return f.stats }
+
+func TestWriteTo(t *testing.T) {
+	c := NewCollector()
+
+	primary := sql.DBStats{MaxOpenConnections: 25, OpenConnections: 3, InUse: 1, Idle: 2, WaitDuration: 1500 * time.Millisecond}
+	replica := sql.DBStats{OpenConnections: 1}
+	if err := c.Register("main", &fakeClient{stats: db.Stats{
+		SQL:      &primary,
+		Replicas: map[string]sql.DBStats{"10.0.0.2:3306": replica},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("cache", &fakeClient{stats: db.Stats{
+		Redis: &db.RedisPoolStats{Hits: 10, Misses: 2, TotalConns: 4, IdleConns: 3},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+	if _, err := c.WriteTo(&out); err != nil {
+		t.Fatal(err)
+	}
+	text := out.String()
+
+	for _, want := range []string{
+		"# TYPE gosuite_db_open_connections gauge\n",
+		`gosuite_db_open_connections{client="main",pool="primary"} 3` + "\n",
+		`gosuite_db_open_connections{client="main",pool="10.0.0.2:3306"} 1` + "\n",
+		`gosuite_db_wait_duration_seconds_total{client="main",pool="primary"} 1.5` + "\n",
+		"# TYPE gosuite_redis_pool_hits_total counter\n",
+		`gosuite_redis_pool_hits_total{client="cache"} 10` + "\n",
+		`gosuite_redis_pool_connections{client="cache"} 4` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("output missing %q\n%s", want, text)
+		}
+	}
+	if n := strings.Count(text, "# HELP gosuite_db_open_connections "); n != 1 {
+		t.Errorf("expected HELP once per family, got %d", n)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	c := NewCollector(WithNamespace("app"))
+
+	if err := c.Register("main", nil); err == nil {
+		t.Error("expected error for nil client")
+	}
+	if err := c.Register("main", &fakeClient{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Register("main", &fakeClient{}); err == nil {
+		t.Error("expected error for duplicate name")
+	}
+
+	c.Unregister("main")
+	stats := sql.DBStats{}
+	if err := c.Register("a\"b", &fakeClient{stats: db.Stats{SQL: &stats}}); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("content type = %q", ct)
+	}
+	if !strings.Contains(rec.Body.String(), `app_db_open_connections{client="a\"b",pool="primary"} 0`) {
+		t.Errorf("unexpected output:\n%s", rec.Body.String())
+	}
+}
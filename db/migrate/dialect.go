@@ -0,0 +1,145 @@
+package migrate
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// lockPollInterval PostgreSQL 轮询获取咨询锁的间隔
+const lockPollInterval = 500 * time.Millisecond
+
+// createTableSQL 返回迁移记录表的建表语句
+func (m *Migrator) createTableSQL() string {
+	return "CREATE TABLE IF NOT EXISTS " + m.dialect.QuoteIdent(m.table) + ` (
+	version BIGINT NOT NULL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	checksum VARCHAR(64) NOT NULL,
+	execution_ms BIGINT NOT NULL,
+	applied_at TIMESTAMP NOT NULL
+)`
+}
+
+// tableExists 检查迁移记录表是否存在，表名为 schema.table 时在指定 schema 中查找
+func (m *Migrator) tableExists(ctx context.Context) (bool, error) {
+	schemaName, table := splitTable(m.table)
+
+	var (
+		query string
+		args  = []interface{}{table}
+	)
+	switch m.dialect {
+	case db.DialectMySQL:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?"
+		if schemaName != "" {
+			query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?"
+			args = []interface{}{schemaName, table}
+		}
+	case db.DialectPostgres:
+		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1"
+		if schemaName != "" {
+			query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2"
+			args = []interface{}{schemaName, table}
+		}
+	case db.DialectSQLite:
+		master := "sqlite_master"
+		if schemaName != "" {
+			master = m.dialect.QuoteIdent(schemaName) + ".sqlite_master"
+		}
+		query = "SELECT COUNT(*) FROM " + master + " WHERE type = 'table' AND name = ?"
+	default:
+		return false, errors.Wrap(errors.ErrInvalidParameter, "migrate: unsupported dialect "+string(m.dialect))
+	}
+
+	var count int
+	if err := m.client.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// splitTable 将 schema.table 形式的表名拆分为 schema 和表名，没有 schema 时返回空
+func splitTable(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// lock 获取迁移咨询锁，返回释放函数
+// 锁绑定在一个独占连接的事务上：MySQL 使用 GET_LOCK，PostgreSQL 使用 pg_advisory_xact_lock
+// SQLite 为单机嵌入式数据库，写操作本身串行，不加锁
+func (m *Migrator) lock(ctx context.Context) (func(), error) {
+	if m.dialect == db.DialectSQLite {
+		return func() {}, nil
+	}
+
+	tx, err := m.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	release := func() { _ = tx.Rollback() }
+
+	switch m.dialect {
+	case db.DialectMySQL:
+		var acquired *int
+		seconds := int(m.lockTimeout / time.Second)
+		if err := tx.QueryRow(ctx, "SELECT GET_LOCK(?, ?)", m.lockName(), seconds).Scan(&acquired); err != nil {
+			release()
+			return nil, err
+		}
+		if acquired == nil || *acquired != 1 {
+			release()
+			return nil, errors.ErrTimeout
+		}
+		release = func() {
+			_ = tx.Exec(context.Background(), "SELECT RELEASE_LOCK(?)", m.lockName())
+			_ = tx.Rollback()
+		}
+	case db.DialectPostgres:
+		key := m.lockKey()
+		deadline := time.Now().Add(m.lockTimeout)
+		for {
+			var acquired bool
+			if err := tx.QueryRow(ctx, "SELECT pg_try_advisory_xact_lock($1)", key).Scan(&acquired); err != nil {
+				release()
+				return nil, err
+			}
+			if acquired {
+				break
+			}
+			if time.Now().After(deadline) {
+				release()
+				return nil, errors.ErrTimeout
+			}
+			select {
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			case <-time.After(lockPollInterval):
+			}
+		}
+	default:
+		release()
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "migrate: unsupported dialect "+string(m.dialect))
+	}
+
+	return release, nil
+}
+
+// lockName 返回 MySQL 命名锁名称
+func (m *Migrator) lockName() string {
+	return "gosuite_migrate:" + m.table
+}
+
+// lockKey 返回 PostgreSQL 咨询锁键
+func (m *Migrator) lockKey() int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(m.lockName()))
+	return int64(h.Sum64())
+}
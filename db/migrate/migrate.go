@@ -0,0 +1,387 @@
+// Package migrate 提供基于版本号的 SQL 数据库迁移
+//
+// 迁移文件从 fs.FS 根目录加载（可直接使用 embed.FS 配合 fs.Sub），命名格式为
+// {version}_{name}.up.sql 和 {version}_{name}.down.sql。已执行的版本及其校验和记录在
+// schema_migrations 表中，执行期间持有数据库咨询锁，保证多副本同时启动时只有一个执行迁移。
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+)
+
+// 错误类型，对应 errors.OpError 的 Kind 字段
+const (
+	KindParse    = "parse"    // 迁移文件解析失败
+	KindLock     = "lock"     // 获取咨询锁失败
+	KindChecksum = "checksum" // 已执行迁移的文件被修改
+	KindExecute  = "execute"  // 执行迁移 SQL 失败
+	KindState    = "state"    // 迁移状态不满足操作条件
+)
+
+// 默认配置
+const (
+	DefaultTable       = "schema_migrations" // 迁移记录表名
+	DefaultLockTimeout = time.Minute         // 等待咨询锁的超时时间
+)
+
+// State 迁移状态
+type State string
+
+const (
+	// StatePending 未执行
+	StatePending State = "pending"
+	// StateApplied 已执行
+	StateApplied State = "applied"
+	// StateModified 已执行，但文件内容与执行时不一致
+	StateModified State = "modified"
+	// StateMissing 已执行，但迁移文件不存在
+	StateMissing State = "missing"
+)
+
+// Status 单个版本的迁移状态
+type Status struct {
+	Version   int64     // 版本号
+	Name      string    // 名称
+	State     State     // 状态
+	AppliedAt time.Time // 执行时间，未执行时为零值
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	client      db.SQLClient
+	dialect     db.Dialect
+	migrations  []*Migration
+	table       string
+	lockTimeout time.Duration
+	dryRun      bool
+}
+
+// Option 迁移执行器配置选项函数
+type Option func(*Migrator)
+
+// WithTable 设置迁移记录表名，默认 schema_migrations
+func WithTable(table string) Option {
+	return func(m *Migrator) {
+		m.table = table
+	}
+}
+
+// WithDialect 设置 SQL 方言，默认从客户端的 Dialect() 获取
+func WithDialect(dialect db.Dialect) Option {
+	return func(m *Migrator) {
+		m.dialect = dialect
+	}
+}
+
+// WithLockTimeout 设置等待咨询锁的超时时间，默认 1 分钟
+func WithLockTimeout(timeout time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTimeout = timeout
+	}
+}
+
+// WithDryRun 启用演练模式，只输出将要执行的 SQL，不修改数据库
+func WithDryRun() Option {
+	return func(m *Migrator) {
+		m.dryRun = true
+	}
+}
+
+// New 创建迁移执行器，从 fsys 根目录加载迁移文件
+func New(client db.SQLClient, fsys fs.FS, opts ...Option) (*Migrator, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+
+	m := &Migrator{
+		client:      client,
+		table:       DefaultTable,
+		lockTimeout: DefaultLockTimeout,
+	}
+	if dp, ok := client.(db.DialectProvider); ok {
+		m.dialect = dp.Dialect()
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.dialect == "" {
+		return nil, errors.NewOpError("migrate.new", KindParse, errors.New("dialect is required"))
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	m.migrations = migrations
+
+	return m, nil
+}
+
+// Migrations 返回已加载的全部迁移，按版本号升序
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Up 执行全部未执行的迁移，返回本次执行（演练模式下为将要执行）的迁移
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	return m.UpTo(ctx, math.MaxInt64)
+}
+
+// UpTo 执行版本号不大于 version 的未执行迁移
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]*Migration, error) {
+	const op = "migrate.up"
+
+	unlock, err := m.prepare(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, errors.NewOpError(op, KindExecute, err)
+	}
+
+	var pending []*Migration
+	for _, mig := range m.migrations {
+		rec, ok := records[mig.Version]
+		if ok && rec.checksum != mig.Checksum {
+			return nil, errors.NewOpError(op, KindChecksum,
+				fmt.Errorf("version %d (%s) was modified after being applied", mig.Version, mig.Name))
+		}
+		if !ok && mig.Version <= version {
+			pending = append(pending, mig)
+		}
+	}
+
+	applied := make([]*Migration, 0, len(pending))
+	for _, mig := range pending {
+		if err := m.run(ctx, mig, mig.Up, true); err != nil {
+			return applied, errors.NewOpError(op, KindExecute,
+				errors.Wrapf(err, "version %d (%s)", mig.Version, mig.Name))
+		}
+		applied = append(applied, mig)
+	}
+
+	return applied, nil
+}
+
+// Down 按版本号倒序回滚最近 steps 个已执行的迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	const op = "migrate.down"
+
+	if steps <= 0 {
+		return nil, errors.NewOpError(op, KindState, errors.ErrInvalidParameter)
+	}
+
+	unlock, err := m.prepare(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, errors.NewOpError(op, KindExecute, err)
+	}
+
+	versions := make([]int64, 0, len(records))
+	for v := range records {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	if len(versions) > steps {
+		versions = versions[:steps]
+	}
+
+	reverted := make([]*Migration, 0, len(versions))
+	for _, v := range versions {
+		mig := m.find(v)
+		if mig == nil || strings.TrimSpace(mig.Down) == "" {
+			return reverted, errors.NewOpError(op, KindState,
+				fmt.Errorf("version %d has no down migration", v))
+		}
+		if err := m.run(ctx, mig, mig.Down, false); err != nil {
+			return reverted, errors.NewOpError(op, KindExecute,
+				errors.Wrapf(err, "version %d (%s)", mig.Version, mig.Name))
+		}
+		reverted = append(reverted, mig)
+	}
+
+	return reverted, nil
+}
+
+// Status 返回全部迁移的状态，包含已执行但文件已删除的版本
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	records, err := m.records(ctx)
+	if err != nil {
+		return nil, errors.NewOpError("migrate.status", KindExecute, err)
+	}
+
+	statuses := make([]*Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := &Status{Version: mig.Version, Name: mig.Name, State: StatePending}
+		if rec, ok := records[mig.Version]; ok {
+			st.State = StateApplied
+			st.AppliedAt = rec.appliedAt
+			if rec.checksum != mig.Checksum {
+				st.State = StateModified
+			}
+			delete(records, mig.Version)
+		}
+		statuses = append(statuses, st)
+	}
+	for _, rec := range records {
+		statuses = append(statuses, &Status{
+			Version:   rec.version,
+			Name:      rec.name,
+			State:     StateMissing,
+			AppliedAt: rec.appliedAt,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// record 迁移记录表中的一行
+type record struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
+
+// executor 可执行 SQL 语句的对象，db.SQLClient 和 db.Tx 均满足
+type executor interface {
+	Exec(ctx context.Context, query string, args ...interface{}) error
+}
+
+// prepare 获取咨询锁并确保迁移记录表存在，演练模式下不加锁也不建表
+func (m *Migrator) prepare(ctx context.Context, op string) (func(), error) {
+	if m.dryRun {
+		return func() {}, nil
+	}
+
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return nil, errors.NewOpError(op, KindLock, err)
+	}
+
+	if err := m.client.Exec(ctx, m.createTableSQL()); err != nil {
+		unlock()
+		return nil, errors.NewOpError(op, KindExecute, err)
+	}
+
+	return unlock, nil
+}
+
+// records 读取已执行的迁移记录，记录表不存在时返回空
+// 配置读写分离时强制读主库，避免副本延迟导致重复执行
+func (m *Migrator) records(ctx context.Context) (map[int64]*record, error) {
+	ctx = db.WithPrimary(ctx)
+
+	exists, err := m.tableExists(ctx)
+	if err != nil || !exists {
+		return map[int64]*record{}, err
+	}
+
+	rows, err := m.client.Query(ctx,
+		"SELECT version, name, checksum, applied_at FROM "+m.dialect.QuoteIdent(m.table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make(map[int64]*record)
+	for rows.Next() {
+		rec := &record{}
+		if err := rows.Scan(&rec.version, &rec.name, &rec.checksum, &rec.appliedAt); err != nil {
+			return nil, err
+		}
+		records[rec.version] = rec
+	}
+	return records, rows.Err()
+}
+
+// run 执行一次升级或回滚，并更新迁移记录
+// 支持事务性 DDL 的方言在事务中执行，迁移文件可通过 -- migrate:no-transaction 关闭
+func (m *Migrator) run(ctx context.Context, mig *Migration, script string, up bool) error {
+	statements := splitStatements(script, m.dialect == db.DialectMySQL)
+
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+
+	if m.dryRun {
+		logger.Infof("migrate: [dry-run] %s version %d (%s):\n%s", direction, mig.Version, mig.Name,
+			strings.Join(statements, ";\n"))
+		return nil
+	}
+
+	start := time.Now()
+	exec := func(ex executor) error {
+		for _, stmt := range statements {
+			if err := ex.Exec(ctx, stmt); err != nil {
+				return err
+			}
+		}
+		if up {
+			return ex.Exec(ctx, m.insertSQL(), mig.Version, mig.Name, mig.Checksum,
+				time.Since(start).Milliseconds(), time.Now())
+		}
+		return ex.Exec(ctx, "DELETE FROM "+m.dialect.QuoteIdent(m.table)+
+			" WHERE version = "+m.dialect.Placeholder(1), mig.Version)
+	}
+
+	var err error
+	if m.transactionalDDL() && !mig.NoTx {
+		err = db.WithTx(ctx, m.client, func(tx db.Tx) error { return exec(tx) })
+	} else {
+		err = exec(m.client)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("migrate: %s version %d (%s) in %v", direction, mig.Version, mig.Name, time.Since(start))
+	return nil
+}
+
+// insertSQL 返回插入迁移记录的语句
+func (m *Migrator) insertSQL() string {
+	d := m.dialect
+	return "INSERT INTO " + d.QuoteIdent(m.table) +
+		" (version, name, checksum, execution_ms, applied_at) VALUES (" +
+		d.Placeholder(1) + ", " + d.Placeholder(2) + ", " + d.Placeholder(3) + ", " +
+		d.Placeholder(4) + ", " + d.Placeholder(5) + ")"
+}
+
+// transactionalDDL 判断方言是否支持在事务中执行 DDL
+func (m *Migrator) transactionalDDL() bool {
+	return m.dialect != db.DialectMySQL
+}
+
+// find 按版本号查找迁移
+func (m *Migrator) find(version int64) *Migration {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return mig
+		}
+	}
+	return nil
+}
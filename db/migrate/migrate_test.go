@@ -0,0 +1,150 @@
+package migrate_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hyperits/gosuite/db/migrate"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);")},
+	"0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;\nCREATE INDEX idx_users_email ON users (email);")},
+	"0002_add_email.down.sql":    {Data: []byte("DROP INDEX idx_users_email;\nALTER TABLE users DROP COLUMN email;")},
+}
+
+func TestMigrator(t *testing.T) {
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	m, err := migrate.New(client, testMigrations)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() returned error: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("Up() applied %d migrations, want 2", len(applied))
+	}
+	if err := client.Exec(ctx, "INSERT INTO users (name, email) VALUES (?, ?)", "alice", "a@example.com"); err != nil {
+		t.Fatalf("insert after migration failed: %v", err)
+	}
+
+	if applied, err = m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up() = %d, %v, want 0, nil", len(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 1)
+	if err != nil || len(reverted) != 1 || reverted[0].Version != 2 {
+		t.Fatalf("Down(1) = %v, %v, want version 2", reverted, err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].State != migrate.StateApplied || statuses[1].State != migrate.StatePending {
+		t.Fatalf("Status() = %+v, %+v, want applied and pending", statuses[0], statuses[1])
+	}
+
+	modified := fstest.MapFS{
+		"0001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);")},
+	}
+	m2, err := migrate.New(client, modified)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	_, err = m2.Up(ctx)
+	var opErr *errors.OpError
+	if !errors.As(err, &opErr) || opErr.Kind != migrate.KindChecksum {
+		t.Fatalf("Up() with modified file error = %v, want checksum OpError", err)
+	}
+}
+
+func TestMigratorDryRun(t *testing.T) {
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	m, err := migrate.New(client, testMigrations, migrate.WithDryRun())
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil || len(applied) != 2 {
+		t.Fatalf("dry-run Up() = %d, %v, want 2, nil", len(applied), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	for _, st := range statuses {
+		if st.State != migrate.StatePending {
+			t.Errorf("version %d state = %s after dry-run, want pending", st.Version, st.State)
+		}
+	}
+}
+
+func TestMigratorSchemaQualifiedTable(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// 单连接保证 ATTACH 的数据库对后续语句可见
+	open := func() *sqlite.Client {
+		client, err := sqlite.NewClient(&sqlite.Config{Path: filepath.Join(dir, "app.db"), MaxOpenConns: 1})
+		if err != nil {
+			t.Fatalf("NewClient() returned error: %v", err)
+		}
+		if err := client.Exec(ctx, "ATTACH DATABASE ? AS audit", filepath.Join(dir, "audit.db")); err != nil {
+			t.Fatalf("ATTACH returned error: %v", err)
+		}
+		return client
+	}
+
+	client := open()
+	m, err := migrate.New(client, testMigrations, migrate.WithTable("audit.schema_migrations"))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 2 {
+		t.Fatalf("Up() = %d, %v, want 2, nil", len(applied), err)
+	}
+	client.Close()
+
+	// 重新打开后已执行的迁移不会重复执行
+	client = open()
+	defer client.Close()
+	m, err = migrate.New(client, testMigrations, migrate.WithTable("audit.schema_migrations"))
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() returned error: %v", err)
+	}
+	for _, s := range statuses {
+		if s.State != migrate.StateApplied {
+			t.Errorf("Status() after reopen = %+v, want applied", s)
+		}
+	}
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("Up() after reopen = %d, %v, want 0, nil", len(applied), err)
+	}
+}
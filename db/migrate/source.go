@@ -0,0 +1,192 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// noTxDirective 迁移文件首行包含此指令时不在事务中执行（如 CREATE INDEX CONCURRENTLY）
+const noTxDirective = "-- migrate:no-transaction"
+
+// fileNamePattern 迁移文件名格式：{version}_{name}.{up|down}.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([\w\-]+)\.(up|down)\.sql$`)
+
+// Migration 单个版本的迁移
+type Migration struct {
+	Version  int64  // 版本号
+	Name     string // 名称
+	Up       string // 升级 SQL
+	Down     string // 回滚 SQL，可为空
+	Checksum string // 升级 SQL 的 SHA-256 校验和
+	NoTx     bool   // 是否不在事务中执行
+}
+
+// load 从 fsys 根目录加载迁移文件，按版本号升序返回
+func load(fsys fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, errors.NewOpError("migrate.load", KindParse, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, errors.NewOpError("migrate.load", KindParse,
+				errors.New("invalid migration file name: "+entry.Name()))
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, errors.NewOpError("migrate.load", KindParse, errors.Wrap(err, entry.Name()))
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, errors.NewOpError("migrate.load", KindParse, errors.Wrap(err, entry.Name()))
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: matches[2]}
+			byVersion[version] = m
+		} else if m.Name != matches[2] {
+			return nil, errors.NewOpError("migrate.load", KindParse,
+				errors.New("duplicate migration version: "+matches[1]))
+		}
+
+		if matches[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, errors.NewOpError("migrate.load", KindParse,
+				errors.New("missing up migration for version "+strconv.FormatInt(m.Version, 10)))
+		}
+		sum := sha256.Sum256([]byte(m.Up))
+		m.Checksum = hex.EncodeToString(sum[:])
+		m.NoTx = strings.HasPrefix(strings.TrimSpace(m.Up), noTxDirective)
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// splitStatements 按分号拆分 SQL 语句
+// 忽略引号、注释和 PostgreSQL $tag$ 字符串中的分号，仅含注释的片段被丢弃
+// backslashEscapes 表示字符串中的反斜杠是否为转义符（MySQL 默认如此）
+func splitStatements(script string, backslashEscapes bool) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		hasCode    bool
+	)
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); hasCode && stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(script); {
+		rest := script[i:]
+		c := script[i]
+
+		var n int
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			n = skipQuoted(rest, c, backslashEscapes && c != '`')
+			hasCode = true
+		case strings.HasPrefix(rest, "--"):
+			n = strings.IndexByte(rest, '\n')
+			if n < 0 {
+				n = len(rest)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			n = strings.Index(rest[2:], "*/")
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += 4
+			}
+		case c == '$' && dollarTag(rest) != "":
+			tag := dollarTag(rest)
+			n = strings.Index(rest[len(tag):], tag)
+			if n < 0 {
+				n = len(rest)
+			} else {
+				n += 2 * len(tag)
+			}
+			hasCode = true
+		case c == ';':
+			flush()
+			i++
+			continue
+		default:
+			n = 1
+			if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+				hasCode = true
+			}
+		}
+
+		current.WriteString(rest[:n])
+		i += n
+	}
+	flush()
+
+	return statements
+}
+
+// skipQuoted 返回以 quote 开头的字符串字面量长度，支持重复引号转义
+func skipQuoted(s string, quote byte, backslashEscapes bool) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// dollarTag 解析 PostgreSQL 美元引号标签，如 $$ 或 $body$
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;")},
+		"0002_add_email.down.sql":    {Data: []byte("ALTER TABLE users DROP COLUMN email;")},
+		"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id BIGINT PRIMARY KEY);")},
+		"0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"0003_index.up.sql":          {Data: []byte("-- migrate:no-transaction\nCREATE INDEX CONCURRENTLY idx ON users (email);")},
+		"README.md":                  {Data: []byte("ignored")},
+	}
+
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatalf("load() returned error: %v", err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("load() returned %d migrations, want 3", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" || migrations[0].Down == "" {
+		t.Errorf("unexpected first migration: %+v", migrations[0])
+	}
+	if migrations[0].Checksum == "" || migrations[0].Checksum == migrations[1].Checksum {
+		t.Errorf("unexpected checksums: %q, %q", migrations[0].Checksum, migrations[1].Checksum)
+	}
+	if !migrations[2].NoTx || migrations[1].NoTx {
+		t.Errorf("no-transaction directive not detected")
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"bad name":     {"create_users.up.sql": {Data: []byte("SELECT 1")}},
+		"missing up":   {"0001_users.down.sql": {Data: []byte("DROP TABLE users")}},
+		"duplicate":    {"0001_a.up.sql": {Data: []byte("SELECT 1")}, "0001_b.up.sql": {Data: []byte("SELECT 2")}},
+		"empty up sql": {"0001_a.up.sql": {Data: []byte("  ")}},
+	}
+
+	for name, fsys := range tests {
+		if _, err := load(fsys); err == nil {
+			t.Errorf("%s: load() should return error", name)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	script := `
+-- create table; with comment
+CREATE TABLE t (name VARCHAR(10) DEFAULT 'a;b');
+/* block; comment */
+INSERT INTO t VALUES ('it''s; fine');
+CREATE FUNCTION f() RETURNS trigger AS $body$
+BEGIN
+  RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+-- trailing comment only
+`
+	got := splitStatements(script, false)
+	want := []string{
+		"-- create table; with comment\nCREATE TABLE t (name VARCHAR(10) DEFAULT 'a;b')",
+		"/* block; comment */\nINSERT INTO t VALUES ('it''s; fine')",
+		"CREATE FUNCTION f() RETURNS trigger AS $body$\nBEGIN\n  RETURN NEW;\nEND;\n$body$ LANGUAGE plpgsql",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("splitStatements() = %q, want %q", got, want)
+	}
+
+	got = splitStatements(`INSERT INTO t VALUES ('a\';b'); SELECT 1`, true)
+	if len(got) != 2 {
+		t.Fatalf("splitStatements() with backslash escapes = %q, want 2 statements", got)
+	}
+}
@@ -0,0 +1,246 @@
+// Package outbox 实现事务性发件箱（Transactional Outbox）
+//
+// 业务数据和领域事件在同一个数据库事务中写入（Enqueue），由 Relay 在后台
+// 以 SELECT ... FOR UPDATE SKIP LOCKED 认领待发送事件（认领时设置租期），在事务外投递给 Publisher，
+// 投递成功后标记为已发送，失败时按指数退避重试，超过最大次数后标记为死信。
+// 多个 Relay 实例可同时运行，同一事件在租期内只会被一个实例认领，但投递语义为至少一次，
+// 消费方应使用 Message.ID 去重。
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// DefaultTable 默认发件箱表名
+const DefaultTable = "outbox"
+
+// 事件状态，对应 status 列
+const (
+	StatusPending = "pending" // 待发送
+	StatusSent    = "sent"    // 已发送
+	StatusDead    = "dead"    // 超过最大重试次数
+)
+
+// Event 待发送的领域事件
+type Event struct {
+	Topic   string            // 主题，如 "order.created"
+	Key     string            // 业务键，如订单号，供下游分区或去重
+	Payload []byte            // 事件内容
+	Headers map[string]string // 附加元数据
+	Delay   time.Duration     // 延迟发送时间，0 表示立即发送
+}
+
+// Message 投递给 Publisher 的事件
+type Message struct {
+	ID        int64             // 发件箱记录 ID，单调递增，可用于消费端去重
+	Topic     string            // 主题
+	Key       string            // 业务键
+	Payload   []byte            // 事件内容
+	Headers   map[string]string // 附加元数据
+	Attempts  int               // 此前已失败的投递次数
+	CreatedAt time.Time         // 入队时间
+}
+
+// Publisher 事件发布者
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// PublisherFunc 函数形式的 Publisher
+type PublisherFunc func(ctx context.Context, msg *Message) error
+
+// Publish 调用 f(ctx, msg)
+func (f PublisherFunc) Publish(ctx context.Context, msg *Message) error {
+	return f(ctx, msg)
+}
+
+// Outbox 发件箱
+type Outbox struct {
+	client  db.SQLClient
+	dialect db.Dialect
+	table   string
+}
+
+// Option 发件箱配置选项函数
+type Option func(*Outbox)
+
+// WithTable 设置发件箱表名，默认 outbox
+func WithTable(table string) Option {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+// WithDialect 设置 SQL 方言，默认从客户端的 Dialect() 获取
+func WithDialect(dialect db.Dialect) Option {
+	return func(o *Outbox) {
+		o.dialect = dialect
+	}
+}
+
+// New 创建发件箱
+func New(client db.SQLClient, opts ...Option) (*Outbox, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+
+	o := &Outbox{client: client, table: DefaultTable}
+	if dp, ok := client.(db.DialectProvider); ok {
+		o.dialect = dp.Dialect()
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	switch o.dialect {
+	case db.DialectMySQL, db.DialectPostgres, db.DialectSQLite:
+	case "":
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "outbox: dialect is required")
+	default:
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "outbox: unsupported dialect "+string(o.dialect))
+	}
+
+	return o, nil
+}
+
+// Schema 返回发件箱表的建表语句，可放入迁移文件
+func (o *Outbox) Schema() string {
+	if index := o.indexSQL(); index != "" {
+		return o.tableSQL() + ";\n" + index
+	}
+	return o.tableSQL()
+}
+
+// CreateTable 创建发件箱表（如不存在）
+func (o *Outbox) CreateTable(ctx context.Context) error {
+	if err := o.client.Exec(ctx, o.tableSQL()); err != nil {
+		return err
+	}
+	if index := o.indexSQL(); index != "" {
+		return o.client.Exec(ctx, index)
+	}
+	return nil
+}
+
+// Enqueue 在事务中写入事件，随事务提交后由 Relay 发送
+func (o *Outbox) Enqueue(ctx context.Context, tx db.Tx, events ...*Event) error {
+	if tx == nil {
+		return errors.Wrap(errors.ErrInvalidParameter, "outbox: tx is required")
+	}
+
+	now := time.Now().UTC()
+	query := o.insertSQL()
+	for _, e := range events {
+		if e == nil || e.Topic == "" {
+			return errors.Wrap(errors.ErrInvalidParameter, "outbox: event topic is required")
+		}
+
+		headers := ""
+		if len(e.Headers) > 0 {
+			data, err := json.Marshal(e.Headers)
+			if err != nil {
+				return errors.Wrap(err, "outbox: encode headers")
+			}
+			headers = string(data)
+		}
+
+		payload := e.Payload
+		if payload == nil {
+			payload = []byte{}
+		}
+
+		if err := tx.Exec(ctx, query, e.Topic, e.Key, payload, headers, StatusPending,
+			now, now.Add(e.Delay)); err != nil {
+			return errors.Wrap(err, "outbox.enqueue")
+		}
+	}
+	return nil
+}
+
+// Purge 删除早于 before 发送的已发送事件，返回错误时可能已删除部分记录
+func (o *Outbox) Purge(ctx context.Context, before time.Time) error {
+	d := o.dialect
+	return o.client.Exec(ctx, "DELETE FROM "+d.QuoteIdent(o.table)+
+		" WHERE status = "+d.Placeholder(1)+" AND sent_at < "+d.Placeholder(2),
+		StatusSent, before.UTC())
+}
+
+// tableSQL 返回建表语句，MySQL 在建表时一并创建索引
+func (o *Outbox) tableSQL() string {
+	table := o.dialect.QuoteIdent(o.table)
+
+	switch o.dialect {
+	case db.DialectMySQL:
+		return "CREATE TABLE IF NOT EXISTS " + table + ` (
+	id BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL DEFAULT '',
+	payload LONGBLOB NOT NULL,
+	headers TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	created_at DATETIME(6) NOT NULL,
+	available_at DATETIME(6) NOT NULL,
+	sent_at DATETIME(6) NULL,
+	INDEX ` + o.indexName() + ` (status, available_at)
+)`
+	case db.DialectPostgres:
+		return "CREATE TABLE IF NOT EXISTS " + table + ` (
+	id BIGSERIAL PRIMARY KEY,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL DEFAULT '',
+	payload BYTEA NOT NULL,
+	headers TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	created_at TIMESTAMPTZ NOT NULL,
+	available_at TIMESTAMPTZ NOT NULL,
+	sent_at TIMESTAMPTZ NULL
+)`
+	default:
+		return "CREATE TABLE IF NOT EXISTS " + table + ` (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	topic VARCHAR(255) NOT NULL,
+	event_key VARCHAR(255) NOT NULL DEFAULT '',
+	payload BLOB NOT NULL,
+	headers TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	attempts INT NOT NULL DEFAULT 0,
+	last_error TEXT NULL,
+	created_at TIMESTAMP NOT NULL,
+	available_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP NULL
+)`
+	}
+}
+
+// indexSQL 返回建索引语句，MySQL 返回空
+func (o *Outbox) indexSQL() string {
+	if o.dialect == db.DialectMySQL {
+		return ""
+	}
+	return "CREATE INDEX IF NOT EXISTS " + o.indexName() + " ON " +
+		o.dialect.QuoteIdent(o.table) + " (status, available_at)"
+}
+
+// indexName 返回待发送事件索引名
+func (o *Outbox) indexName() string {
+	return o.dialect.QuoteIdent("idx_" + o.table + "_status")
+}
+
+// insertSQL 返回插入事件的语句
+func (o *Outbox) insertSQL() string {
+	d := o.dialect
+	return "INSERT INTO " + d.QuoteIdent(o.table) +
+		" (topic, event_key, payload, headers, status, created_at, available_at) VALUES (" +
+		d.Placeholder(1) + ", " + d.Placeholder(2) + ", " + d.Placeholder(3) + ", " +
+		d.Placeholder(4) + ", " + d.Placeholder(5) + ", " + d.Placeholder(6) + ", " +
+		d.Placeholder(7) + ")"
+}
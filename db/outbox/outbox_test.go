@@ -0,0 +1,288 @@
+package outbox_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/outbox"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// recorder 记录投递的事件，fail 返回非 nil 时投递失败
+type recorder struct {
+	mu   sync.Mutex
+	msgs []*outbox.Message
+	fail func(msg *outbox.Message) error
+}
+
+func (r *recorder) Publish(ctx context.Context, msg *outbox.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.fail != nil {
+		if err := r.fail(msg); err != nil {
+			return err
+		}
+	}
+	r.msgs = append(r.msgs, msg)
+	return nil
+}
+
+func newTestOutbox(t *testing.T) (*sqlite.Client, *outbox.Outbox) {
+	t.Helper()
+
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ob, err := outbox.New(client)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	if err := ob.CreateTable(context.Background()); err != nil {
+		t.Fatalf("CreateTable() returned error: %v", err)
+	}
+	return client, ob
+}
+
+func status(t *testing.T, client db.SQLClient, id int64) (string, int) {
+	t.Helper()
+
+	var (
+		s        string
+		attempts int
+	)
+	if err := client.QueryRow(context.Background(), "SELECT status, attempts FROM outbox WHERE id = ?", id).Scan(&s, &attempts); err != nil {
+		t.Fatalf("query status failed: %v", err)
+	}
+	return s, attempts
+}
+
+func TestEnqueueWithinTransaction(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		if err := ob.Enqueue(ctx, tx, &outbox.Event{Topic: "order.created", Payload: []byte("1")}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+
+	err = db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx,
+			&outbox.Event{Topic: "order.created", Key: "o-1", Payload: []byte(`{"id":1}`), Headers: map[string]string{"trace": "abc"}},
+			&outbox.Event{Topic: "order.paid", Key: "o-1"},
+		)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &recorder{}
+	relay, err := outbox.NewRelay(ob, pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := relay.RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || len(pub.msgs) != 2 {
+		t.Fatalf("RunOnce() = %d, published %d, want 2", n, len(pub.msgs))
+	}
+
+	first := pub.msgs[0]
+	if first.Topic != "order.created" || first.Key != "o-1" || string(first.Payload) != `{"id":1}` || first.Headers["trace"] != "abc" {
+		t.Errorf("unexpected message %+v", first)
+	}
+	if s, _ := status(t, client, first.ID); s != outbox.StatusSent {
+		t.Errorf("status = %s, want sent", s)
+	}
+
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Errorf("second RunOnce() = %d, want 0", n)
+	}
+}
+
+func TestRelayRetry(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx, &outbox.Event{Topic: "flaky"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pub := &recorder{fail: func(*outbox.Message) error { return errors.New(strings.Repeat("x", 2000)) }}
+	relay, _ := outbox.NewRelay(ob, pub, outbox.WithMaxAttempts(2), outbox.WithBackoff(time.Millisecond, time.Millisecond))
+
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s, attempts := status(t, client, 1); s != outbox.StatusPending || attempts != 1 {
+		t.Fatalf("after first failure: status = %s, attempts = %d", s, attempts)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s, attempts := status(t, client, 1); s != outbox.StatusDead || attempts != 2 {
+		t.Fatalf("after max attempts: status = %s, attempts = %d", s, attempts)
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx, &outbox.Event{Topic: "later"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	failing := true
+	pub := &recorder{fail: func(*outbox.Message) error {
+		if failing {
+			return errors.New("unavailable")
+		}
+		return nil
+	}}
+	relay, _ := outbox.NewRelay(ob, pub, outbox.WithBackoff(time.Hour, time.Hour))
+
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	failing = false
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Errorf("RunOnce() during backoff claimed %d events", n)
+	}
+	if len(pub.msgs) != 0 {
+		t.Errorf("published %d messages during backoff", len(pub.msgs))
+	}
+}
+
+func TestRelayLease(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx, &outbox.Event{Topic: "leased"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 投递在事务外进行：内存数据库为单连接，投递期间仍可在客户端上查询；租期内其他投递器认领不到事件
+	other, _ := outbox.NewRelay(ob, &recorder{})
+	relay, _ := outbox.NewRelay(ob, outbox.PublisherFunc(func(ctx context.Context, msg *outbox.Message) error {
+		if s, _ := status(t, client, msg.ID); s != outbox.StatusPending {
+			t.Errorf("status during publish = %s, want pending", s)
+		}
+		if n, err := other.RunOnce(ctx); err != nil || n != 0 {
+			t.Errorf("RunOnce() during lease = %d, %v, want 0", n, err)
+		}
+		return nil
+	}))
+	if n, err := relay.RunOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("RunOnce() = %d, %v", n, err)
+	}
+	if s, _ := status(t, client, 1); s != outbox.StatusSent {
+		t.Errorf("status = %s, want sent", s)
+	}
+}
+
+func TestRelayLeaseExpired(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx := context.Background()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx, &outbox.Event{Topic: "slow"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 租期过后事件被其他投递器重新认领并发送，原投递器的失败结果不会把状态改回待发送
+	pub := &recorder{}
+	other, _ := outbox.NewRelay(ob, pub)
+	relay, _ := outbox.NewRelay(ob, outbox.PublisherFunc(func(ctx context.Context, msg *outbox.Message) error {
+		time.Sleep(5 * time.Millisecond)
+		if n, err := other.RunOnce(ctx); err != nil || n != 1 {
+			t.Errorf("RunOnce() after lease = %d, %v, want 1", n, err)
+		}
+		return errors.New("timeout")
+	}), outbox.WithLease(time.Millisecond))
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if s, attempts := status(t, client, 1); s != outbox.StatusSent || attempts != 0 || len(pub.msgs) != 1 {
+		t.Errorf("status = %s, attempts = %d, published %d, want sent, 0, 1", s, attempts, len(pub.msgs))
+	}
+}
+
+func TestRun(t *testing.T) {
+	client, ob := newTestOutbox(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		return ob.Enqueue(ctx, tx, &outbox.Event{Topic: "a"}, &outbox.Event{Topic: "b"}, &outbox.Event{Topic: "c"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	pub := &recorder{fail: func(*outbox.Message) error { return nil }}
+	relay, _ := outbox.NewRelay(ob, outbox.PublisherFunc(func(ctx context.Context, msg *outbox.Message) error {
+		err := pub.Publish(ctx, msg)
+		if msg.Topic == "c" {
+			close(done)
+		}
+		return err
+	}), outbox.WithBatchSize(1), outbox.WithPollInterval(time.Millisecond))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- relay.Run(ctx) }()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("relay did not publish all events")
+	}
+	cancel()
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	client, _ := newTestOutbox(t)
+
+	ob, err := outbox.New(client, outbox.WithDialect(db.DialectPostgres), outbox.WithTable("events_outbox"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := ob.Schema()
+	for _, want := range []string{`CREATE TABLE IF NOT EXISTS "events_outbox"`, "BYTEA", `CREATE INDEX IF NOT EXISTS "idx_events_outbox_status"`} {
+		if !strings.Contains(schema, want) {
+			t.Errorf("schema missing %q:\n%s", want, schema)
+		}
+	}
+
+	if _, err := outbox.New(client, outbox.WithDialect("oracle")); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("unsupported dialect error = %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	gredis "github.com/hyperits/gosuite/db/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RedisStreamPublisher 将事件写入 Redis Stream，每个主题对应一个 Stream
+// 消息字段：id（发件箱记录 ID）、topic、key、payload、headers（JSON，可为空）
+type RedisStreamPublisher struct {
+	client gredis.UniversalClientProvider
+	prefix string
+	maxLen int64
+}
+
+// RedisStreamOption Redis Stream 发布者配置选项函数
+type RedisStreamOption func(*RedisStreamPublisher)
+
+// WithStreamPrefix 设置 Stream 名前缀，Stream 名为 prefix + topic
+func WithStreamPrefix(prefix string) RedisStreamOption {
+	return func(p *RedisStreamPublisher) {
+		p.prefix = prefix
+	}
+}
+
+// WithStreamMaxLen 设置 Stream 近似最大长度（XADD MAXLEN ~），0 表示不限制
+func WithStreamMaxLen(maxLen int64) RedisStreamOption {
+	return func(p *RedisStreamPublisher) {
+		p.maxLen = maxLen
+	}
+}
+
+// NewRedisStreamPublisher 创建 Redis Stream 发布者
+func NewRedisStreamPublisher(client gredis.UniversalClientProvider, opts ...RedisStreamOption) *RedisStreamPublisher {
+	p := &RedisStreamPublisher{client: client}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Publish 以 XADD 写入事件
+func (p *RedisStreamPublisher) Publish(ctx context.Context, msg *Message) error {
+	headers := ""
+	if len(msg.Headers) > 0 {
+		data, err := json.Marshal(msg.Headers)
+		if err != nil {
+			return err
+		}
+		headers = string(data)
+	}
+
+	return p.client.UniversalClient().XAdd(ctx, &goredis.XAddArgs{
+		Stream: p.prefix + msg.Topic,
+		MaxLen: p.maxLen,
+		Approx: p.maxLen > 0,
+		Values: []interface{}{
+			"id", strconv.FormatInt(msg.ID, 10),
+			"topic", msg.Topic,
+			"key", msg.Key,
+			"payload", msg.Payload,
+			"headers", headers,
+		},
+	}).Err()
+}
@@ -0,0 +1,277 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+)
+
+// Relay 默认配置
+const (
+	DefaultBatchSize    = 100
+	DefaultPollInterval = time.Second
+	DefaultMaxAttempts  = 10
+	DefaultBackoff      = time.Second
+	DefaultMaxBackoff   = 5 * time.Minute
+	DefaultLease        = time.Minute
+)
+
+// maxErrorLength last_error 列保存的错误信息最大长度
+const maxErrorLength = 1024
+
+// Relay 发件箱投递器
+type Relay struct {
+	outbox       *Outbox
+	publisher    Publisher
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	lease        time.Duration
+}
+
+// RelayOption 投递器配置选项函数
+type RelayOption func(*Relay)
+
+// WithBatchSize 设置每批认领的事件数，默认 100
+func WithBatchSize(n int) RelayOption {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batchSize = n
+		}
+	}
+}
+
+// WithPollInterval 设置无待发送事件时的轮询间隔，默认 1 秒
+func WithPollInterval(interval time.Duration) RelayOption {
+	return func(r *Relay) {
+		if interval > 0 {
+			r.pollInterval = interval
+		}
+	}
+}
+
+// WithMaxAttempts 设置最大投递次数，超过后标记为死信，默认 10，0 表示无限重试
+func WithMaxAttempts(n int) RelayOption {
+	return func(r *Relay) {
+		if n >= 0 {
+			r.maxAttempts = n
+		}
+	}
+}
+
+// WithBackoff 设置重试退避，第 n 次失败后等待 base*2^(n-1)，不超过 max，默认 1 秒和 5 分钟
+func WithBackoff(base, max time.Duration) RelayOption {
+	return func(r *Relay) {
+		if base > 0 {
+			r.backoff = base
+		}
+		if max > 0 {
+			r.maxBackoff = max
+		}
+	}
+}
+
+// WithLease 设置认领租期，默认 1 分钟
+// 认领后的事件在租期内不会被再次认领，投递器在租期内未记录结果（如进程崩溃）时事件重新变为可认领，
+// 租期应大于投递一批事件所需的时间
+func WithLease(lease time.Duration) RelayOption {
+	return func(r *Relay) {
+		if lease > 0 {
+			r.lease = lease
+		}
+	}
+}
+
+// NewRelay 创建发件箱投递器
+func NewRelay(outbox *Outbox, publisher Publisher, opts ...RelayOption) (*Relay, error) {
+	if outbox == nil || publisher == nil {
+		return nil, errors.ErrInvalidParameter
+	}
+
+	r := &Relay{
+		outbox:       outbox,
+		publisher:    publisher,
+		batchSize:    DefaultBatchSize,
+		pollInterval: DefaultPollInterval,
+		maxAttempts:  DefaultMaxAttempts,
+		backoff:      DefaultBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		lease:        DefaultLease,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Run 持续投递直到 ctx 取消，返回 ctx.Err()
+// 一批认领满时立即处理下一批，否则等待轮询间隔
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Errorf("outbox relay failed: %v", err)
+		}
+
+		if err == nil && n >= r.batchSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// RunOnce 认领并投递一批事件，返回认领的事件数
+// 认领在短事务中完成：锁定到期的待发送事件并将 available_at 推后一个租期；
+// 投递在事务之外进行，最后在另一个事务中记录投递结果，避免发布期间长时间持有行锁和连接
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	var msgs []*Message
+	err := db.WithTx(ctx, r.outbox.client, func(tx db.Tx) (err error) {
+		msgs, err = r.claim(ctx, tx)
+		return err
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "outbox.relay.claim")
+	}
+	if len(msgs) == 0 {
+		return 0, nil
+	}
+
+	results := make([]error, len(msgs))
+	for i, msg := range msgs {
+		results[i] = r.publisher.Publish(ctx, msg)
+	}
+
+	err = db.WithTx(ctx, r.outbox.client, func(tx db.Tx) error {
+		for i, msg := range msgs {
+			if err := r.record(ctx, tx, msg, results[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "outbox.relay.record")
+	}
+	return len(msgs), nil
+}
+
+// claim 锁定一批到期的待发送事件，并将其 available_at 设为租期结束时间
+func (r *Relay) claim(ctx context.Context, tx db.Tx) ([]*Message, error) {
+	o := r.outbox
+	d := o.dialect
+	table := d.QuoteIdent(o.table)
+	now := time.Now().UTC()
+
+	query := "SELECT id, topic, event_key, payload, headers, attempts, created_at FROM " + table +
+		" WHERE status = " + d.Placeholder(1) + " AND available_at <= " + d.Placeholder(2) +
+		" ORDER BY id LIMIT " + d.Placeholder(3)
+	// SQLite 写事务本身串行，不支持也不需要行锁
+	if d != db.DialectSQLite {
+		query += " FOR UPDATE SKIP LOCKED"
+	}
+
+	rows, err := tx.Query(ctx, query, StatusPending, now, r.batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []*Message
+	for rows.Next() {
+		var (
+			msg     Message
+			headers string
+		)
+		if err := rows.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &headers, &msg.Attempts, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		if headers != "" {
+			if err := json.Unmarshal([]byte(headers), &msg.Headers); err != nil {
+				return nil, errors.Wrapf(err, "decode headers of event %d", msg.ID)
+			}
+		}
+		msgs = append(msgs, &msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(msgs) == 0 {
+		return nil, nil
+	}
+
+	args := []interface{}{now.Add(r.lease)}
+	marks := make([]string, len(msgs))
+	for i, msg := range msgs {
+		args = append(args, msg.ID)
+		marks[i] = d.Placeholder(i + 2)
+	}
+	err = tx.Exec(ctx, "UPDATE "+table+" SET available_at = "+d.Placeholder(1)+
+		" WHERE id IN ("+strings.Join(marks, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// record 记录单个事件的投递结果，投递失败不中断整批，仅记录重试信息
+// 只更新仍为待发送状态的事件，租期过后被其他投递器重新认领并已发送的事件不会被改回
+func (r *Relay) record(ctx context.Context, tx db.Tx, msg *Message, pubErr error) error {
+	o := r.outbox
+	d := o.dialect
+	table := d.QuoteIdent(o.table)
+	now := time.Now().UTC()
+
+	if pubErr == nil {
+		return tx.Exec(ctx, "UPDATE "+table+" SET status = "+d.Placeholder(1)+", sent_at = "+d.Placeholder(2)+
+			" WHERE id = "+d.Placeholder(3)+" AND status = "+d.Placeholder(4), StatusSent, now, msg.ID, StatusPending)
+	}
+
+	attempts := msg.Attempts + 1
+	status := StatusPending
+	if r.maxAttempts > 0 && attempts >= r.maxAttempts {
+		status = StatusDead
+		logger.Errorf("outbox event %d (%s) moved to dead after %d attempts: %v", msg.ID, msg.Topic, attempts, pubErr)
+	} else {
+		logger.Warnf("outbox event %d (%s) publish failed (attempt %d): %v", msg.ID, msg.Topic, attempts, pubErr)
+	}
+
+	return tx.Exec(ctx, "UPDATE "+table+" SET status = "+d.Placeholder(1)+", attempts = "+d.Placeholder(2)+
+		", last_error = "+d.Placeholder(3)+", available_at = "+d.Placeholder(4)+
+		" WHERE id = "+d.Placeholder(5)+" AND status = "+d.Placeholder(6),
+		status, attempts, truncate(pubErr.Error(), maxErrorLength), now.Add(r.delay(attempts)), msg.ID, StatusPending)
+}
+
+// delay 返回第 attempts 次失败后的退避时间
+func (r *Relay) delay(attempts int) time.Duration {
+	d := r.backoff
+	for i := 1; i < attempts && d < r.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.maxBackoff {
+		d = r.maxBackoff
+	}
+	return d
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/jackc/pgx/v5"
+)
+
+// CopyFrom 通过 COPY FROM STDIN 协议将 rows 流式写入 table，返回写入的行数
+// 比多行 INSERT 更快，但不支持冲突处理；table 可为 schema.table 形式
+// 配置多租户时写入 ctx 中租户对应的 schema 或数据库
+func (c *Client) CopyFrom(ctx context.Context, table string, columns []string, rows db.RowSource) (int64, error) {
+	if table == "" || len(columns) == 0 || rows == nil {
+		return 0, errors.Wrap(errors.ErrInvalidParameter, "postgres.copy: table, columns and rows are required")
+	}
+
+	sqlDB, err := c.conn()
+	if err != nil {
+		return 0, err
+	}
+	pool, release, err := c.tenants.acquire(ctx, sqlDB)
+	if err != nil {
+		return 0, err
+	}
+	if release != nil {
+		defer release()
+	}
+
+	// 未按租户借出连接时从连接池借出单个连接
+	conn, ok := pool.(*sql.Conn)
+	if !ok {
+		if conn, err = pool.(*sql.DB).Conn(ctx); err != nil {
+			return 0, errors.Wrap(err, "postgres.copy")
+		}
+		defer conn.Close()
+	}
+
+	ident := pgx.Identifier(strings.Split(table, "."))
+	statement := "COPY " + ident.Sanitize() + " (" + strings.Join(quoteColumns(columns), ", ") + ") FROM STDIN"
+
+	var n int64
+	err = c.hooks.Run(ctx, "copy", statement, nil, func(ctx context.Context) error {
+		return conn.Raw(func(dc interface{}) error {
+			pc, err := pgxConn(dc)
+			if err != nil {
+				return err
+			}
+			n, err = pc.CopyFrom(ctx, ident, columns, rows)
+			return err
+		})
+	})
+	if err != nil {
+		return n, errors.Wrap(err, "postgres.copy")
+	}
+	return n, nil
+}
+
+// quoteColumns 引用列名
+func quoteColumns(columns []string) []string {
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = pgx.Identifier{c}.Sanitize()
+	}
+	return quoted
+}
@@ -0,0 +1,360 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/glebarez/sqlite"
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/internal/sqlcore"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+func TestBuildConnConfig(t *testing.T) {
+	conf := &Config{
+		Host:             "db.local",
+		Port:             5432,
+		Username:         "app",
+		Password:         "p@ss word/?&=",
+		DbName:           "orders",
+		ApplicationName:  "order-service",
+		SearchPath:       "app,public",
+		StatementTimeout: 30 * time.Second,
+		ConnectTimeout:   3 * time.Second,
+		Params:           map[string]string{"lock_timeout": "5000"},
+	}
+
+	cc, err := buildConnConfig(conf, "replica.local", 5433)
+	if err != nil {
+		t.Fatalf("buildConnConfig() returned error: %v", err)
+	}
+	if cc.Password != conf.Password || cc.User != "app" || cc.Database != "orders" {
+		t.Errorf("credentials not preserved: user=%q password=%q db=%q", cc.User, cc.Password, cc.Database)
+	}
+	if cc.Host != "replica.local" || cc.Port != 5433 {
+		t.Errorf("address = %s:%d, want replica.local:5433", cc.Host, cc.Port)
+	}
+	if cc.TLSConfig != nil {
+		t.Error("TLSConfig should be nil with sslmode=disable")
+	}
+	if cc.ConnectTimeout != 3*time.Second {
+		t.Errorf("ConnectTimeout = %v, want 3s", cc.ConnectTimeout)
+	}
+
+	want := map[string]string{
+		"TimeZone":          "Asia/Shanghai",
+		"application_name":  "order-service",
+		"search_path":       "app,public",
+		"statement_timeout": "30000",
+		"lock_timeout":      "5000",
+	}
+	for k, v := range want {
+		if cc.RuntimeParams[k] != v {
+			t.Errorf("RuntimeParams[%q] = %q, want %q", k, cc.RuntimeParams[k], v)
+		}
+	}
+}
+
+func TestBuildConnConfigFromDSN(t *testing.T) {
+	conf := &Config{
+		DSN:      "host=db.local port=5432 user=app password='secret' dbname=orders sslmode=disable",
+		TimeZone: "UTC",
+	}
+	if err := conf.Validate(); err != nil {
+		t.Fatalf("Validate() returned error: %v", err)
+	}
+
+	cc, err := buildConnConfig(conf, conf.Host, conf.Port)
+	if err != nil {
+		t.Fatalf("buildConnConfig() returned error: %v", err)
+	}
+	if cc.Host != "db.local" || cc.Password != "secret" || cc.RuntimeParams["TimeZone"] != "UTC" {
+		t.Errorf("unexpected config from dsn: host=%q password=%q params=%v", cc.Host, cc.Password, cc.RuntimeParams)
+	}
+}
+
+func TestTenantConfig(t *testing.T) {
+	base := Config{Host: "db.local", Port: 5432, Username: "app", DbName: "saas"}
+
+	for _, tc := range []*TenantConfig{{}, {Mode: TenantDatabase, NameFormat: "tenant_%s"}} {
+		conf := base
+		conf.Tenancy = tc
+		if err := conf.Validate(); err != nil {
+			t.Errorf("Validate(%+v) returned error: %v", tc, err)
+		}
+	}
+	for _, tc := range []*TenantConfig{{Mode: "table"}, {NameFormat: "tenant"}, {NameFormat: "%s_%s"}} {
+		conf := base
+		conf.Tenancy = tc
+		if err := conf.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", tc)
+		}
+	}
+
+	tn := newTenancy(&TenantConfig{NameFormat: "tenant_%s", SharedSchemas: []string{"public"}}, nil)
+	if got := tn.searchPath("acme"); got != `"tenant_acme", "public"` {
+		t.Errorf("searchPath() = %s", got)
+	}
+}
+
+func TestTenantAcquire(t *testing.T) {
+	pool := &sql.DB{}
+	ctx := context.Background()
+
+	var none *tenancy
+	if conn, release, err := none.acquire(WithTenant(ctx, "acme"), pool); conn != pool || release != nil || err != nil {
+		t.Errorf("acquire() without tenancy = %v, %v", conn, err)
+	}
+
+	tn := newTenancy(&TenantConfig{Required: true}, nil)
+	if _, _, err := tn.acquire(ctx, pool); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("acquire() without tenant = %v, want ErrNoTenant", err)
+	}
+	if _, _, err := tn.acquire(WithTenant(ctx, `acme"; DROP SCHEMA x; --`), pool); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("acquire() with invalid tenant = %v, want ErrInvalidTenant", err)
+	}
+
+	tn = newTenancy(&TenantConfig{}, nil)
+	if conn, release, err := tn.acquire(ctx, pool); conn != pool || release != nil || err != nil {
+		t.Errorf("acquire() without tenant = %v, %v", conn, err)
+	}
+}
+
+func TestTenantPoolLRU(t *testing.T) {
+	cc, err := pgx.ParseConfig("postgres://app@127.0.0.1:1/saas?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opened []string
+	tn := newTenancy(&TenantConfig{Mode: TenantDatabase, NameFormat: "tenant_%s", MaxPools: 2}, func(database string) (*sql.DB, error) {
+		opened = append(opened, database)
+		return stdlib.OpenDB(*cc), nil
+	})
+
+	isClosed := func(conn interface{}) bool {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		err := conn.(*sql.DB).PingContext(ctx)
+		return err != nil && strings.Contains(err.Error(), "database is closed")
+	}
+
+	ctx := context.Background()
+	a, releaseA, err := tn.acquire(WithTenant(ctx, "a"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, releaseB, _ := tn.acquire(WithTenant(ctx, "b"), nil)
+	releaseB()
+	again, releaseAgain, _ := tn.acquire(WithTenant(ctx, "a"), nil)
+	releaseAgain()
+	if again != a {
+		t.Error("expected cached pool for tenant a")
+	}
+
+	// 容量为 2，打开 c 时淘汰最久未使用的 b
+	_, releaseC, _ := tn.acquire(WithTenant(ctx, "c"), nil)
+	releaseC()
+	if !isClosed(b) {
+		t.Error("evicted idle pool b should be closed")
+	}
+
+	// 打开 d 时淘汰 a，但 a 仍在使用，归还后才关闭
+	_, releaseD, _ := tn.acquire(WithTenant(ctx, "d"), nil)
+	releaseD()
+	if isClosed(a) {
+		t.Error("evicted pool a should stay open while in use")
+	}
+	releaseA()
+	releaseA()
+	if !isClosed(a) {
+		t.Error("evicted pool a should be closed after release")
+	}
+
+	if got := strings.Join(opened, ","); got != "tenant_a,tenant_b,tenant_c,tenant_d" {
+		t.Errorf("opened = %s", got)
+	}
+	if len(tn.stats()) != 2 {
+		t.Errorf("stats() = %v", tn.stats())
+	}
+
+	// 关闭时仍在使用的连接池在归还后才关闭
+	d, releaseD, _ := tn.acquire(WithTenant(ctx, "d"), nil)
+	if err := tn.close(); err != nil {
+		t.Fatal(err)
+	}
+	if isClosed(d) {
+		t.Error("pool d should stay open while in use after close")
+	}
+	releaseD()
+	if !isClosed(d) {
+		t.Error("pool d should be closed after release")
+	}
+	if _, _, err := tn.acquire(WithTenant(ctx, "c"), nil); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("acquire() after close = %v", err)
+	}
+}
+
+func TestTenantPoolLRUOpenOutsideLock(t *testing.T) {
+	cc, err := pgx.ParseConfig("postgres://app@127.0.0.1:1/saas?connect_timeout=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slow := make(chan struct{})
+	pools := newPoolLRU(4, func(tenant string) (*sql.DB, error) {
+		if tenant == "slow" {
+			<-slow
+		}
+		return stdlib.OpenDB(*cc), nil
+	})
+	defer pools.close()
+
+	type result struct {
+		conn    interface{}
+		release func()
+	}
+	results := make(chan result, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			conn, release, err := pools.acquire("slow")
+			if err != nil {
+				t.Error(err)
+			}
+			results <- result{conn, release}
+		}()
+	}
+
+	// 慢租户打开期间，其他租户不被阻塞
+	done := make(chan struct{})
+	go func() {
+		_, release, err := pools.acquire("fast")
+		if err != nil {
+			t.Error(err)
+		} else {
+			release()
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("acquire blocked by another tenant's open")
+	}
+
+	// 并发打开同一租户时只保留一个连接池
+	close(slow)
+	r1, r2 := <-results, <-results
+	if r1.conn != r2.conn {
+		t.Error("concurrent acquire returned different pools")
+	}
+	r1.release()
+	r2.release()
+	if len(pools.stats()) != 2 {
+		t.Errorf("stats() = %v", pools.stats())
+	}
+}
+
+// newSQLiteBackedClient 创建底层连接池为内存 SQLite 的客户端，用于测试与方言无关的客户端逻辑
+func newSQLiteBackedClient(t *testing.T) *Client {
+	t.Helper()
+
+	sqlDB, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	c := &Client{
+		sqlDB:   sqlDB,
+		conf:    &Config{},
+		hooks:   sqlcore.NewHooks("postgres"),
+		watcher: db.NewWatcher("postgres", sqlDB.PingContext, db.WithCheckInterval(time.Hour)),
+	}
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClientOperations(t *testing.T) {
+	ctx := context.Background()
+	c := newSQLiteBackedClient(t)
+
+	if err := c.Exec(ctx, "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if err := c.Exec(ctx, "INSERT INTO missing VALUES (1)"); err == nil || !strings.Contains(err.Error(), "postgres.exec") {
+		t.Errorf("Exec() on missing table error = %v, want postgres.exec error", err)
+	}
+
+	// 提交的事务可见，回滚的事务不可见
+	if err := db.WithTx(ctx, c, func(tx db.Tx) error {
+		return tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "a")
+	}); err != nil {
+		t.Fatalf("WithTx() commit error = %v", err)
+	}
+	rollback := errors.New("rollback")
+	if err := db.WithTx(ctx, c, func(tx db.Tx) error {
+		if err := tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "b"); err != nil {
+			return err
+		}
+		return rollback
+	}); !errors.Is(err, rollback) {
+		t.Fatalf("WithTx() rollback error = %v", err)
+	}
+	tx, err := c.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+
+	var n int
+	if err := c.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&n); err != nil || n != 1 {
+		t.Errorf("QueryRow() = %d, %v, want 1", n, err)
+	}
+	rows, err := c.Query(ctx, "SELECT name FROM users")
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	_ = rows.Close()
+	if len(names) != 1 || names[0] != "a" {
+		t.Errorf("Query() = %v, want [a]", names)
+	}
+}
+
+func TestClosedClient(t *testing.T) {
+	ctx := context.Background()
+	c := newSQLiteBackedClient(t)
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if c.IsConnected() {
+		t.Error("IsConnected() = true after Close()")
+	}
+	checks := map[string]error{
+		"Ping":  c.Ping(ctx),
+		"Exec":  c.Exec(ctx, "SELECT 1"),
+		"Close": c.Close(),
+	}
+	checks["QueryRow"] = c.QueryRow(ctx, "SELECT 1").Scan(new(int))
+	_, checks["Query"] = c.Query(ctx, "SELECT 1")
+	_, checks["Begin"] = c.Begin(ctx)
+	_, checks["BeginTx"] = c.BeginTx(ctx, nil)
+	for name, err := range checks {
+		if !errors.Is(err, errors.ErrAlreadyClosed) {
+			t.Errorf("%s() after Close error = %v, want ErrAlreadyClosed", name, err)
+		}
+	}
+}
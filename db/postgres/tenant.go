@@ -0,0 +1,436 @@
+package postgres
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/internal/sqlcore"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// TenantMode 租户隔离方式
+type TenantMode string
+
+const (
+	// TenantSchema 每个租户一个 schema，共享连接池，借出连接时设置 search_path，归还前重置
+	TenantSchema TenantMode = "schema"
+
+	// TenantDatabase 每个租户一个数据库，使用独立的连接池
+	TenantDatabase TenantMode = "database"
+)
+
+// 租户配置默认值
+const (
+	DefaultTenantPools        = 16 // database 模式下保留的租户连接池上限
+	DefaultTenantMaxOpenConns = 5  // database 模式下每个租户连接池的最大连接数
+)
+
+// tenantResetTimeout 归还连接前重置 search_path 的超时
+const tenantResetTimeout = 3 * time.Second
+
+var (
+	// ErrInvalidTenant 租户 id 不合法，只允许字母、数字和下划线
+	ErrInvalidTenant = errors.New("postgres: invalid tenant id")
+
+	// ErrNoTenant 配置了 Required 但 ctx 中没有租户
+	ErrNoTenant = errors.New("postgres: no tenant in context")
+)
+
+// tenantPattern 合法的租户 id
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// TenantConfig 多租户配置
+// 租户 id 通过 WithTenant 放入 ctx，Exec/Query/QueryRow/Begin 按租户隔离执行；
+// ctx 中没有租户时使用默认的 search_path 和数据库。通过 DB() 进行的 gorm 操作不区分租户。
+type TenantConfig struct {
+	Mode          TenantMode // 隔离方式，默认 schema
+	NameFormat    string     // 租户 id 到 schema 名或数据库名的格式，默认 "%s"，如 "tenant_%s"
+	SharedSchemas []string   // schema 模式下追加到 search_path 的公共 schema，如 "public"
+	Required      bool       // 是否拒绝 ctx 中没有租户的操作
+
+	// database 模式的连接池配置
+	MaxPools         int // 保留的租户连接池上限，默认 16，超出时关闭最久未使用的连接池
+	PoolMaxOpenConns int // 每个租户连接池的最大连接数，默认 5
+}
+
+// validate 验证多租户配置
+func (t *TenantConfig) validate() error {
+	switch t.Mode {
+	case "", TenantSchema, TenantDatabase:
+	default:
+		return errors.New("postgres: unknown tenant mode " + string(t.Mode))
+	}
+	if t.NameFormat != "" && strings.Count(t.NameFormat, "%s") != 1 {
+		return errors.New("postgres: tenant name format must contain exactly one %s")
+	}
+	return nil
+}
+
+// tenantContextKey ctx 中保存租户 id 的键
+type tenantContextKey struct{}
+
+// WithTenant 返回携带租户 id 的 ctx
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFrom 返回 ctx 中的租户 id
+func TenantFrom(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// tenancy 多租户连接管理
+type tenancy struct {
+	conf  TenantConfig
+	open  func(database string) (*sql.DB, error) // database 模式下打开租户连接池
+	pools *poolLRU
+
+	// leased schema 模式下已设置租户 search_path、尚未重置的连接
+	leased sync.Map // *pgx.Conn -> tenant
+}
+
+// newTenancy 创建多租户连接管理，conf 为 nil 时返回 nil
+func newTenancy(conf *TenantConfig, open func(database string) (*sql.DB, error)) *tenancy {
+	if conf == nil {
+		return nil
+	}
+
+	t := &tenancy{conf: *conf, open: open}
+	if t.conf.Mode == "" {
+		t.conf.Mode = TenantSchema
+	}
+	if t.conf.NameFormat == "" {
+		t.conf.NameFormat = "%s"
+	}
+	if t.conf.MaxPools <= 0 {
+		t.conf.MaxPools = DefaultTenantPools
+	}
+	if t.conf.PoolMaxOpenConns <= 0 {
+		t.conf.PoolMaxOpenConns = DefaultTenantMaxOpenConns
+	}
+	if t.conf.Mode == TenantDatabase {
+		t.pools = newPoolLRU(t.conf.MaxPools, func(tenant string) (*sql.DB, error) {
+			sqlDB, err := open(t.name(tenant))
+			if err != nil {
+				return nil, err
+			}
+			sqlDB.SetMaxOpenConns(t.conf.PoolMaxOpenConns)
+			return sqlDB, nil
+		})
+	}
+	return t
+}
+
+// name 返回租户对应的 schema 名或数据库名
+func (t *tenancy) name(tenant string) string {
+	return fmt.Sprintf(t.conf.NameFormat, tenant)
+}
+
+// searchPath 返回租户的 search_path
+func (t *tenancy) searchPath(tenant string) string {
+	schemas := make([]string, 0, 1+len(t.conf.SharedSchemas))
+	schemas = append(schemas, db.DialectPostgres.QuoteIdent(t.name(tenant)))
+	for _, s := range t.conf.SharedSchemas {
+		schemas = append(schemas, db.DialectPostgres.QuoteIdent(s))
+	}
+	return strings.Join(schemas, ", ")
+}
+
+// openOptions 返回连接池的 pgx 选项
+// schema 模式下借出连接时校验连接未残留租户的 search_path，残留的连接被丢弃
+func (t *tenancy) openOptions() []stdlib.OptionOpenDB {
+	if t == nil || t.conf.Mode != TenantSchema {
+		return nil
+	}
+	return []stdlib.OptionOpenDB{stdlib.OptionResetSession(func(ctx context.Context, conn *pgx.Conn) error {
+		if tenant, ok := t.leased.LoadAndDelete(conn); ok {
+			logger.Warnf("postgres: discarding connection still bound to tenant %v", tenant)
+			return driver.ErrBadConn
+		}
+		return nil
+	})}
+}
+
+// acquire 按 ctx 中的租户借出执行语句的连接，pool 为未指定租户时使用的连接池
+// 返回的 release 不为 nil 时调用方用完连接后必须调用
+func (t *tenancy) acquire(ctx context.Context, pool *sql.DB) (sqlcore.Conn, func(), error) {
+	if t == nil {
+		return pool, nil, nil
+	}
+
+	tenant, ok := TenantFrom(ctx)
+	if !ok {
+		if t.conf.Required {
+			return nil, nil, ErrNoTenant
+		}
+		return pool, nil, nil
+	}
+	if !tenantPattern.MatchString(tenant) {
+		return nil, nil, errors.Wrap(ErrInvalidTenant, tenant)
+	}
+
+	if t.conf.Mode == TenantDatabase {
+		return t.pools.acquire(tenant)
+	}
+	return t.checkout(ctx, pool, tenant)
+}
+
+// checkout 从连接池借出连接并设置租户的 search_path
+func (t *tenancy) checkout(ctx context.Context, pool *sql.DB, tenant string) (sqlcore.Conn, func(), error) {
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "postgres.tenant")
+	}
+
+	err = conn.Raw(func(dc interface{}) error {
+		pc, err := pgxConn(dc)
+		if err != nil {
+			return err
+		}
+		t.leased.Store(pc, tenant)
+		_, err = pc.Exec(ctx, "SELECT set_config('search_path', $1, false)", t.searchPath(tenant))
+		return err
+	})
+	if err != nil {
+		t.checkin(conn)
+		return nil, nil, errors.Wrap(err, "postgres.tenant")
+	}
+
+	var once sync.Once
+	return conn, func() { once.Do(func() { t.checkin(conn) }) }, nil
+}
+
+// checkin 重置 search_path 后归还连接，重置失败时丢弃连接，避免租户设置泄漏给其他请求
+func (t *tenancy) checkin(conn *sql.Conn) {
+	ctx, cancel := context.WithTimeout(context.Background(), tenantResetTimeout)
+	defer cancel()
+
+	_ = conn.Raw(func(dc interface{}) error {
+		pc, err := pgxConn(dc)
+		if err != nil {
+			return driver.ErrBadConn
+		}
+		if _, err := pc.Exec(ctx, "RESET search_path"); err != nil {
+			t.leased.Delete(pc)
+			logger.Warnf("postgres: discarding connection after search_path reset failed: %v", err)
+			return driver.ErrBadConn
+		}
+		t.leased.Delete(pc)
+		return nil
+	})
+	_ = conn.Close()
+}
+
+// close 关闭全部租户连接池
+func (t *tenancy) close() error {
+	if t == nil || t.pools == nil {
+		return nil
+	}
+	return t.pools.close()
+}
+
+// stats 返回各租户连接池的统计
+func (t *tenancy) stats() map[string]sql.DBStats {
+	if t == nil || t.pools == nil {
+		return nil
+	}
+	return t.pools.stats()
+}
+
+// pgxConn 从 database/sql 的驱动连接中取出 pgx 连接
+func pgxConn(dc interface{}) (*pgx.Conn, error) {
+	c, ok := dc.(*stdlib.Conn)
+	if !ok {
+		return nil, fmt.Errorf("postgres: unexpected driver connection %T", dc)
+	}
+	return c.Conn(), nil
+}
+
+// poolEntry 租户连接池及其引用计数
+type poolEntry struct {
+	tenant  string
+	db      *sql.DB
+	refs    int
+	evicted bool
+}
+
+// poolLRU 有容量上限的租户连接池缓存
+// 被淘汰的连接池在正在进行的操作全部结束后关闭
+type poolLRU struct {
+	mu     sync.Mutex
+	max    int
+	ll     *list.List // 元素为 *poolEntry，队首为最近使用
+	items  map[string]*list.Element
+	open   func(tenant string) (*sql.DB, error)
+	closed bool
+}
+
+func newPoolLRU(max int, open func(tenant string) (*sql.DB, error)) *poolLRU {
+	return &poolLRU{max: max, ll: list.New(), items: make(map[string]*list.Element), open: open}
+}
+
+// acquire 返回租户的连接池，不存在时打开，release 在操作结束后调用
+// 打开连接池在锁外进行，不阻塞其他租户的请求
+func (p *poolLRU) acquire(tenant string) (sqlcore.Conn, func(), error) {
+	p.mu.Lock()
+	e, err := p.lookup(tenant)
+	p.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e == nil {
+		sqlDB, err := p.open(tenant)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p.mu.Lock()
+		e, err = p.lookup(tenant)
+		if err != nil || e != nil {
+			// 已关闭，或并发请求已先打开同一租户的连接池
+			p.mu.Unlock()
+			_ = sqlDB.Close()
+			if err != nil {
+				return nil, nil, err
+			}
+		} else {
+			e = &poolEntry{tenant: tenant, db: sqlDB, refs: 1}
+			p.items[tenant] = p.ll.PushFront(e)
+			p.mu.Unlock()
+		}
+	}
+
+	p.mu.Lock()
+	var evicted []*sql.DB
+	for p.ll.Len() > p.max {
+		old := p.ll.Remove(p.ll.Back()).(*poolEntry)
+		delete(p.items, old.tenant)
+		old.evicted = true
+		if old.refs == 0 {
+			evicted = append(evicted, old.db)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, sqlDB := range evicted {
+		_ = sqlDB.Close()
+	}
+
+	var once sync.Once
+	return e.db, func() { once.Do(func() { p.release(e) }) }, nil
+}
+
+// lookup 查找租户的连接池并增加引用计数，不存在时返回 nil，调用方须持有锁
+func (p *poolLRU) lookup(tenant string) (*poolEntry, error) {
+	if p.closed {
+		return nil, errors.ErrAlreadyClosed
+	}
+	el, ok := p.items[tenant]
+	if !ok {
+		return nil, nil
+	}
+	p.ll.MoveToFront(el)
+	e := el.Value.(*poolEntry)
+	e.refs++
+	return e, nil
+}
+
+// release 减少引用计数，已淘汰且不再使用的连接池被关闭
+func (p *poolLRU) release(e *poolEntry) {
+	p.mu.Lock()
+	e.refs--
+	closeNow := e.evicted && e.refs == 0
+	p.mu.Unlock()
+
+	if closeNow {
+		_ = e.db.Close()
+	}
+}
+
+// close 关闭全部连接池，仍在使用的连接池在最后一次 release 时关闭
+func (p *poolLRU) close() error {
+	p.mu.Lock()
+	p.closed = true
+	var idle []*poolEntry
+	for _, el := range p.items {
+		e := el.Value.(*poolEntry)
+		e.evicted = true
+		if e.refs == 0 {
+			idle = append(idle, e)
+		}
+	}
+	p.items = make(map[string]*list.Element)
+	p.ll.Init()
+	p.mu.Unlock()
+
+	var errs []error
+	for _, e := range idle {
+		if err := e.db.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, e.tenant))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// stats 返回各租户连接池的统计
+func (p *poolLRU) stats() map[string]sql.DBStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := make(map[string]sql.DBStats, len(p.items))
+	for tenant, el := range p.items {
+		s[tenant] = el.Value.(*poolEntry).db.Stats()
+	}
+	return s
+}
+
+// leaseRow 读取后归还租户连接的 db.Row
+type leaseRow struct {
+	db.Row
+	release func()
+}
+
+func (r *leaseRow) Scan(dest ...interface{}) error {
+	defer r.release()
+	return r.Row.Scan(dest...)
+}
+
+// leaseRows 关闭后归还租户连接的 db.Rows
+type leaseRows struct {
+	db.Rows
+	release func()
+}
+
+func (r *leaseRows) Close() error {
+	defer r.release()
+	return r.Rows.Close()
+}
+
+// leaseTx 提交或回滚后归还租户连接的 db.Tx
+type leaseTx struct {
+	db.Tx
+	release func()
+}
+
+func (t *leaseTx) Commit() error {
+	defer t.release()
+	return t.Tx.Commit()
+}
+
+func (t *leaseTx) Rollback() error {
+	defer t.release()
+	return t.Tx.Rollback()
+}
@@ -0,0 +1,549 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// notFound 将 redis.Nil 转换为同时满足 errors.ErrNotFound 和 redis.Nil 的错误
+func notFound(err error) error {
+	if err == redis.Nil {
+		return fmt.Errorf("%w: %w", errors.ErrNotFound, redis.Nil)
+	}
+	return err
+}
+
+// Incr 将整数值加一并返回新值
+func (c *Client) Incr(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.Incr(ctx, key).Result()
+}
+
+// IncrBy 将整数值增加 n 并返回新值
+func (c *Client) IncrBy(ctx context.Context, key string, n int64) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.IncrBy(ctx, key, n).Result()
+}
+
+// Decr 将整数值减一并返回新值
+func (c *Client) Decr(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.Decr(ctx, key).Result()
+}
+
+// DecrBy 将整数值减少 n 并返回新值
+func (c *Client) DecrBy(ctx context.Context, key string, n int64) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.DecrBy(ctx, key, n).Result()
+}
+
+// SetNX 键不存在时设置值，返回是否设置成功
+func (c *Client) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	rc, err := c.active()
+	if err != nil {
+		return false, err
+	}
+	return rc.SetNX(ctx, key, value, ttl).Result()
+}
+
+// MGet 批量获取值，结果只包含存在的键
+// 集群模式下所有键必须位于同一槽位
+func (c *Client) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := rc.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			result[keys[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// MSet 批量设置值
+// 集群模式下所有键必须位于同一槽位
+func (c *Client) MSet(ctx context.Context, values map[string]interface{}) error {
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return rc.MSet(ctx, values).Err()
+}
+
+// Expire 设置过期时间，返回键是否存在
+func (c *Client) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	rc, err := c.active()
+	if err != nil {
+		return false, err
+	}
+	return rc.Expire(ctx, key, ttl).Result()
+}
+
+// TTL 返回剩余过期时间，没有过期时间时返回 db.NoExpiration
+func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := rc.TTL(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Redis 以 -2 表示键不存在，-1 表示没有过期时间
+	switch ttl {
+	case -2:
+		return 0, notFound(redis.Nil)
+	case -1:
+		return db.NoExpiration, nil
+	}
+	return ttl, nil
+}
+
+// HGet 获取字段值
+func (c *Client) HGet(ctx context.Context, key, field string) (string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return "", err
+	}
+	v, err := rc.HGet(ctx, key, field).Result()
+	return v, notFound(err)
+}
+
+// HSet 设置多个字段
+func (c *Client) HSet(ctx context.Context, key string, values map[string]interface{}) error {
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return rc.HSet(ctx, key, values).Err()
+}
+
+// HGetAll 获取全部字段
+func (c *Client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	return rc.HGetAll(ctx, key).Result()
+}
+
+// HDel 删除字段，返回实际删除的数量
+func (c *Client) HDel(ctx context.Context, key string, fields ...string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.HDel(ctx, key, fields...).Result()
+}
+
+// HExists 检查字段是否存在
+func (c *Client) HExists(ctx context.Context, key, field string) (bool, error) {
+	rc, err := c.active()
+	if err != nil {
+		return false, err
+	}
+	return rc.HExists(ctx, key, field).Result()
+}
+
+// HIncrBy 将字段的整数值增加 n 并返回新值
+func (c *Client) HIncrBy(ctx context.Context, key, field string, n int64) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.HIncrBy(ctx, key, field, n).Result()
+}
+
+// HLen 返回字段数量
+func (c *Client) HLen(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.HLen(ctx, key).Result()
+}
+
+// LPush 从头部插入，返回插入后的长度
+func (c *Client) LPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.LPush(ctx, key, values...).Result()
+}
+
+// RPush 从尾部插入，返回插入后的长度
+func (c *Client) RPush(ctx context.Context, key string, values ...interface{}) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.RPush(ctx, key, values...).Result()
+}
+
+// LPop 从头部弹出
+func (c *Client) LPop(ctx context.Context, key string) (string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return "", err
+	}
+	v, err := rc.LPop(ctx, key).Result()
+	return v, notFound(err)
+}
+
+// RPop 从尾部弹出
+func (c *Client) RPop(ctx context.Context, key string) (string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return "", err
+	}
+	v, err := rc.RPop(ctx, key).Result()
+	return v, notFound(err)
+}
+
+// LRange 返回下标 start 到 stop（包含）的元素
+func (c *Client) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	return rc.LRange(ctx, key, start, stop).Result()
+}
+
+// LTrim 只保留下标 start 到 stop（包含）的元素
+func (c *Client) LTrim(ctx context.Context, key string, start, stop int64) error {
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	return rc.LTrim(ctx, key, start, stop).Err()
+}
+
+// LLen 返回列表长度
+func (c *Client) LLen(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.LLen(ctx, key).Result()
+}
+
+// SAdd 添加成员，返回新增的数量
+func (c *Client) SAdd(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.SAdd(ctx, key, members...).Result()
+}
+
+// SRem 删除成员，返回实际删除的数量
+func (c *Client) SRem(ctx context.Context, key string, members ...interface{}) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.SRem(ctx, key, members...).Result()
+}
+
+// SMembers 返回全部成员
+func (c *Client) SMembers(ctx context.Context, key string) ([]string, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	return rc.SMembers(ctx, key).Result()
+}
+
+// SIsMember 检查是否为成员
+func (c *Client) SIsMember(ctx context.Context, key string, member interface{}) (bool, error) {
+	rc, err := c.active()
+	if err != nil {
+		return false, err
+	}
+	return rc.SIsMember(ctx, key, member).Result()
+}
+
+// SCard 返回成员数量
+func (c *Client) SCard(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.SCard(ctx, key).Result()
+}
+
+// ZAdd 添加或更新成员，返回新增的数量
+func (c *Client) ZAdd(ctx context.Context, key string, members ...db.ZMember) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	zs := make([]redis.Z, len(members))
+	for i, m := range members {
+		zs[i] = redis.Z{Score: m.Score, Member: m.Member}
+	}
+	return rc.ZAdd(ctx, key, zs...).Result()
+}
+
+// ZRem 删除成员，返回实际删除的数量
+func (c *Client) ZRem(ctx context.Context, key string, members ...string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	args := make([]interface{}, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	return rc.ZRem(ctx, key, args...).Result()
+}
+
+// ZScore 返回成员分数
+func (c *Client) ZScore(ctx context.Context, key, member string) (float64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	v, err := rc.ZScore(ctx, key, member).Result()
+	return v, notFound(err)
+}
+
+// ZIncrBy 将成员分数增加 n 并返回新分数
+func (c *Client) ZIncrBy(ctx context.Context, key, member string, n float64) (float64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.ZIncrBy(ctx, key, n, member).Result()
+}
+
+// ZRange 按分数升序返回下标 start 到 stop（包含）的成员
+func (c *Client) ZRange(ctx context.Context, key string, start, stop int64) ([]db.ZMember, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	zs, err := rc.ZRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toZMembers(zs), nil
+}
+
+// ZRangeByScore 按分数升序返回分数在 [min, max] 内的成员
+func (c *Client) ZRangeByScore(ctx context.Context, key string, min, max float64, offset, count int64) ([]db.ZMember, error) {
+	rc, err := c.active()
+	if err != nil {
+		return nil, err
+	}
+	by := &redis.ZRangeBy{Min: formatScore(min), Max: formatScore(max)}
+	if count > 0 {
+		by.Offset, by.Count = offset, count
+	}
+	zs, err := rc.ZRangeByScoreWithScores(ctx, key, by).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toZMembers(zs), nil
+}
+
+// ZCard 返回成员数量
+func (c *Client) ZCard(ctx context.Context, key string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.ZCard(ctx, key).Result()
+}
+
+// Scan 返回匹配 match 的键迭代器，集群模式下依次遍历所有主节点
+// 配置了 KeyPrefix 时只遍历前缀下的键，返回的键不含前缀
+func (c *Client) Scan(ctx context.Context, match string, count int64) db.KeyIterator {
+	rc, err := c.active()
+	if err != nil {
+		return &keyIterator{err: err}
+	}
+
+	it := &keyIterator{match: match, count: count}
+	cluster, ok := rc.(*redis.ClusterClient)
+	if !ok {
+		it.nodes = []scanner{rc}
+		return it
+	}
+
+	// 主节点客户端不经过集群客户端的钩子，需要自行处理前缀
+	if prefix := c.conf.KeyPrefix; prefix != "" {
+		if match == "" {
+			match = "*"
+		}
+		it.match = db.EscapeGlob(prefix) + match
+		it.prefix = prefix
+	}
+
+	var mu sync.Mutex
+	it.err = cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		mu.Lock()
+		defer mu.Unlock()
+		it.nodes = append(it.nodes, node)
+		return nil
+	})
+	return it
+}
+
+// scanner 可执行 SCAN 的节点
+type scanner interface {
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+}
+
+// keyIterator 依次遍历各节点的 SCAN 结果
+type keyIterator struct {
+	nodes  []scanner
+	match  string
+	count  int64
+	prefix string // 需要从结果中去掉的键前缀
+
+	cur *redis.ScanIterator
+	key string
+	err error
+}
+
+func (it *keyIterator) Next(ctx context.Context) bool {
+	for it.err == nil {
+		if it.cur == nil {
+			if len(it.nodes) == 0 {
+				return false
+			}
+			it.cur = it.nodes[0].Scan(ctx, 0, it.match, it.count).Iterator()
+			it.nodes = it.nodes[1:]
+		}
+		if it.cur.Next(ctx) {
+			it.key = strings.TrimPrefix(it.cur.Val(), it.prefix)
+			return true
+		}
+		it.err = it.cur.Err()
+		it.cur = nil
+	}
+	return false
+}
+
+func (it *keyIterator) Key() string {
+	return it.key
+}
+
+func (it *keyIterator) Err() error {
+	return it.err
+}
+
+// delBatchSize DelMatch 每批删除的键数量
+const delBatchSize = 500
+
+// DelMatch 删除匹配 match 的全部键，返回删除的数量
+// 以 SCAN 遍历、按批通过管道逐个 UNLINK，集群模式下同样适用；遍历期间新写入的键可能不会被删除
+func (c *Client) DelMatch(ctx context.Context, match string) (int64, error) {
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	batch := make([]string, 0, delBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		cmds, err := rc.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range batch {
+				pipe.Unlink(ctx, key)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, cmd := range cmds {
+			deleted += cmd.(*redis.IntCmd).Val()
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	it := c.Scan(ctx, match, delBatchSize)
+	for it.Next(ctx) {
+		batch = append(batch, it.Key())
+		if len(batch) == delBatchSize {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// FlushNamespace 删除 KeyPrefix 下的全部键，返回删除的数量
+// 未配置 KeyPrefix 时返回 errors.ErrNotConfigured，避免误删整个数据库
+func (c *Client) FlushNamespace(ctx context.Context) (int64, error) {
+	if c.conf.KeyPrefix == "" {
+		return 0, errors.Wrap(errors.ErrNotConfigured, "redis: key prefix is empty")
+	}
+	return c.DelMatch(ctx, "*")
+}
+
+// toZMembers 转换有序集合成员
+func toZMembers(zs []redis.Z) []db.ZMember {
+	members := make([]db.ZMember, len(zs))
+	for i, z := range zs {
+		members[i] = db.ZMember{Member: z.Member, Score: z.Score}
+	}
+	return members
+}
+
+// formatScore 将分数格式化为 Redis 区间参数，支持正负无穷
+func formatScore(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
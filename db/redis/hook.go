@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/internal/sqlcore"
+	"github.com/redis/go-redis/v9"
+)
+
+// maxStatementArgs 记录到 Statement 中的命令参数上限，避免大批量命令产生过长的字符串
+const maxStatementArgs = 64
+
+// hookAdapter 将 db.Hook 适配为 go-redis 的 Hook
+type hookAdapter struct {
+	hooks  *sqlcore.Hooks
+	redact bool // 隐藏键以外的参数值
+}
+
+var _ redis.Hook = hookAdapter{}
+
+// DialHook 建立连接时不调用钩子
+func (a hookAdapter) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 在每条命令前后调用钩子，操作名为命令名
+func (a hookAdapter) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		var err error
+		_ = a.hooks.Run(ctx, cmd.FullName(), a.cmdString(cmd), nil, func(ctx context.Context) error {
+			err = next(ctx, cmd)
+			return eventErr(err)
+		})
+		return err
+	}
+}
+
+// ProcessPipelineHook 在每个管道（包括 MULTI/EXEC 事务）前后调用钩子，操作名为 "pipeline"
+func (a hookAdapter) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		stmts := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			stmts[i] = a.cmdString(cmd)
+		}
+
+		var err error
+		_ = a.hooks.Run(ctx, "pipeline", strings.Join(stmts, "; "), nil, func(ctx context.Context) error {
+			err = next(ctx, cmds)
+			if err == nil {
+				for _, cmd := range cmds {
+					if e := eventErr(cmd.Err()); e != nil {
+						return e
+					}
+				}
+			}
+			return eventErr(err)
+		})
+		return err
+	}
+}
+
+// eventErr 过滤表示"键不存在"的 redis.Nil，不作为操作错误记录
+func eventErr(err error) error {
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
+
+// cmdString 将命令格式化为以空格分隔的字符串，开启 redact 时键以外的参数以 "?" 代替
+func (a hookAdapter) cmdString(cmd redis.Cmder) string {
+	args := cmd.Args()
+	var keep map[int]bool
+	if a.redact {
+		keep = visibleArgs(cmd.Name(), args)
+	}
+	truncated := len(args) > maxStatementArgs
+	if truncated {
+		args = args[:maxStatementArgs]
+	}
+
+	var b strings.Builder
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if keep != nil && !keep[i] {
+			b.WriteByte('?')
+			continue
+		}
+		switch v := arg.(type) {
+		case string:
+			b.WriteString(v)
+		case []byte:
+			b.Write(v)
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	if truncated {
+		b.WriteString(" ...")
+	}
+	return b.String()
+}
+
+// visibleArgs 返回隐藏参数值时仍保留的参数下标：命令名、子命令和键
+func visibleArgs(name string, args []interface{}) map[int]bool {
+	keep := map[int]bool{0: true}
+	if _, ok := subKeyCommands[name]; ok {
+		keep[1] = true
+	}
+	for _, pos := range keyPositions(name, args) {
+		keep[pos] = true
+	}
+	return keep
+}
+
+// AddHook 追加操作钩子，对之后执行的命令生效
+func (c *Client) AddHook(hook db.Hook) {
+	c.hooks.Add(hook)
+}
@@ -0,0 +1,309 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/redis/go-redis/v9"
+)
+
+// prefixHook 为命令中的键透明地加上前缀，并去掉 SCAN、KEYS 等返回结果中的前缀
+//
+// 参数在命令执行后恢复原值，ScanIterator 等重复执行同一命令时不会重复加前缀。
+// pub/sub 频道名不是键，不加前缀。
+type prefixHook struct {
+	prefix string
+}
+
+var _ redis.Hook = prefixHook{}
+
+// DialHook 建立连接时不做处理
+func (h prefixHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 为单条命令的键加上前缀
+func (h prefixHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		restore := h.rewrite(cmd)
+		err := next(ctx, cmd)
+		h.strip(cmd)
+		restore()
+		return err
+	}
+}
+
+// ProcessPipelineHook 为管道（包括 MULTI/EXEC 事务）中每条命令的键加上前缀
+func (h prefixHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		restores := make([]func(), len(cmds))
+		for i, cmd := range cmds {
+			restores[i] = h.rewrite(cmd)
+		}
+		err := next(ctx, cmds)
+		for i, cmd := range cmds {
+			h.strip(cmd)
+			restores[i]()
+		}
+		return err
+	}
+}
+
+// rewrite 为命令的键参数加上前缀，返回恢复原参数的函数
+func (h prefixHook) rewrite(cmd redis.Cmder) func() {
+	args := cmd.Args()
+	positions := keyPositions(cmd.Name(), args)
+	if len(positions) == 0 {
+		return func() {}
+	}
+	prefix := h.prefix
+	if name := cmd.Name(); name == "scan" || name == "keys" {
+		prefix = db.EscapeGlob(prefix)
+	}
+	originals := make([]interface{}, len(positions))
+	for i, pos := range positions {
+		originals[i] = args[pos]
+		args[pos] = prefix + argString(args[pos])
+	}
+	return func() {
+		for i, pos := range positions {
+			args[pos] = originals[i]
+		}
+	}
+}
+
+// strip 去掉返回结果中键的前缀，不属于当前前缀的键被丢弃
+func (h prefixHook) strip(cmd redis.Cmder) {
+	if cmd.Err() != nil {
+		return
+	}
+	switch cmd := cmd.(type) {
+	case *redis.ScanCmd:
+		if cmd.Name() != "scan" {
+			return
+		}
+		keys, cursor := cmd.Val()
+		cmd.SetVal(h.stripKeys(keys), cursor)
+	case *redis.StringSliceCmd:
+		switch cmd.Name() {
+		case "keys":
+			cmd.SetVal(h.stripKeys(cmd.Val()))
+		case "blpop", "brpop":
+			// 返回值为 [key, value]
+			if val := cmd.Val(); len(val) == 2 {
+				cmd.SetVal([]string{strings.TrimPrefix(val[0], h.prefix), val[1]})
+			}
+		}
+	case *redis.ZWithKeyCmd:
+		// BZPOPMIN、BZPOPMAX 返回弹出元素所在的键
+		if val := cmd.Val(); val != nil {
+			val.Key = strings.TrimPrefix(val.Key, h.prefix)
+		}
+	case *redis.KeyValuesCmd:
+		// LMPOP、BLMPOP
+		if key, val := cmd.Val(); key != "" {
+			cmd.SetVal(strings.TrimPrefix(key, h.prefix), val)
+		}
+	case *redis.ZSliceWithKeyCmd:
+		// ZMPOP、BZMPOP
+		if key, val := cmd.Val(); key != "" {
+			cmd.SetVal(strings.TrimPrefix(key, h.prefix), val)
+		}
+	case *redis.XStreamSliceCmd:
+		streams := cmd.Val()
+		for i := range streams {
+			streams[i].Stream = strings.TrimPrefix(streams[i].Stream, h.prefix)
+		}
+	}
+}
+
+// stripKeys 去掉键的前缀
+func (h prefixHook) stripKeys(keys []string) []string {
+	out := keys[:0]
+	for _, key := range keys {
+		if strings.HasPrefix(key, h.prefix) {
+			out = append(out, key[len(h.prefix):])
+		}
+	}
+	return out
+}
+
+// argString 将键参数转换为字符串
+func argString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(arg)
+}
+
+// 按键参数位置分类的命令，未列出的命令（如 PING、PUBLISH、SCRIPT）不改写
+var (
+	// 第一个参数为键
+	firstKeyCommands = toSet(
+		"get", "set", "setnx", "setex", "psetex", "getset", "getdel", "getex", "append", "strlen",
+		"getrange", "setrange", "incr", "incrby", "incrbyfloat", "decr", "decrby",
+		"expire", "pexpire", "expireat", "pexpireat", "expiretime", "pexpiretime", "persist",
+		"ttl", "pttl", "type", "dump", "restore",
+		"setbit", "getbit", "bitcount", "bitpos", "bitfield", "pfadd",
+		"hget", "hset", "hsetnx", "hmset", "hmget", "hgetall", "hdel", "hexists", "hincrby",
+		"hincrbyfloat", "hlen", "hkeys", "hvals", "hscan", "hstrlen", "hrandfield",
+		"lpush", "rpush", "lpushx", "rpushx", "lpop", "rpop", "lrange", "ltrim", "llen",
+		"lindex", "lset", "linsert", "lrem", "lpos",
+		"sadd", "srem", "smembers", "sismember", "smismember", "scard", "spop", "srandmember", "sscan",
+		"zadd", "zrem", "zscore", "zmscore", "zincrby", "zrange", "zrangebyscore", "zrevrange",
+		"zrevrangebyscore", "zrangebylex", "zrevrangebylex", "zrank", "zrevrank", "zcard", "zcount",
+		"zlexcount", "zremrangebyrank", "zremrangebyscore", "zremrangebylex", "zpopmin", "zpopmax",
+		"zscan", "zrandmember",
+		"xadd", "xlen", "xrange", "xrevrange", "xdel", "xtrim", "xack", "xpending", "xclaim", "xautoclaim",
+		"geoadd", "geodist", "geopos", "geohash", "geosearch", "georadius_ro", "georadiusbymember_ro",
+	)
+
+	// 全部参数均为键
+	allKeysCommands = toSet(
+		"del", "unlink", "exists", "touch", "watch", "mget", "pfcount", "pfmerge",
+		"sinter", "sunion", "sdiff", "sinterstore", "sunionstore", "sdiffstore",
+	)
+
+	// 除最后一个超时参数外均为键
+	blockingCommands = toSet("blpop", "brpop", "bzpopmin", "bzpopmax")
+
+	// 前两个参数为键
+	twoKeysCommands = toSet(
+		"rename", "renamenx", "smove", "rpoplpush", "lmove", "brpoplpush", "blmove", "copy",
+		"zrangestore", "geosearchstore", "lcs",
+	)
+
+	// 键数量位于第一个参数，之后为键
+	firstNumKeysCommands = toSet("zunion", "zinter", "zdiff", "zintercard", "sintercard", "lmpop", "zmpop")
+
+	// 键数量位于第二个参数，之后为键
+	numKeysCommands = toSet("eval", "evalsha", "eval_ro", "evalsha_ro", "fcall", "fcall_ro", "blmpop", "bzmpop")
+
+	// 第二个参数为键的子命令，如 XGROUP CREATE key group
+	subKeyCommands = toSet("xgroup", "xinfo", "object", "memory")
+)
+
+// toSet 构造字符串集合
+func toSet(names ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return set
+}
+
+// keyPositions 返回命令中键参数的下标
+func keyPositions(name string, args []interface{}) []int {
+	has := func(set map[string]struct{}) bool {
+		_, ok := set[name]
+		return ok
+	}
+	rangeOf := func(from, to int) []int {
+		if to > len(args) {
+			to = len(args)
+		}
+		var positions []int
+		for i := from; i < to; i++ {
+			positions = append(positions, i)
+		}
+		return positions
+	}
+
+	switch {
+	case has(firstKeyCommands):
+		return rangeOf(1, 2)
+	case has(allKeysCommands):
+		return rangeOf(1, len(args))
+	case has(blockingCommands):
+		return rangeOf(1, len(args)-1)
+	case has(twoKeysCommands):
+		return rangeOf(1, 3)
+	case has(firstNumKeysCommands):
+		return rangeOf(2, 2+intArg(args, 1))
+	case has(numKeysCommands):
+		return rangeOf(3, 3+intArg(args, 2))
+	case has(subKeyCommands):
+		return rangeOf(2, 3)
+	case name == "mset" || name == "msetnx":
+		var positions []int
+		for i := 1; i < len(args); i += 2 {
+			positions = append(positions, i)
+		}
+		return positions
+	case name == "zunionstore" || name == "zinterstore" || name == "zdiffstore":
+		return append([]int{1}, rangeOf(3, 3+intArg(args, 2))...)
+	case name == "bitop":
+		// BITOP operation destkey key [key ...]
+		return rangeOf(2, len(args))
+	case name == "georadius":
+		// GEORADIUS key longitude latitude radius unit，STORE、STOREDIST 之后的参数为目标键
+		return append([]int{1}, optionKeys(args, 6, "store", "storedist")...)
+	case name == "georadiusbymember":
+		// GEORADIUSBYMEMBER key member radius unit，选项同 GEORADIUS
+		return append([]int{1}, optionKeys(args, 5, "store", "storedist")...)
+	case name == "sort" || name == "sort_ro":
+		// BY、GET 模式引用其他键，"#" 表示元素本身，BY nosort 表示不排序
+		positions := []int{1}
+		for _, pos := range optionKeys(args, 2, "store", "by", "get") {
+			if v := argString(args[pos]); v != "#" && !strings.EqualFold(v, "nosort") {
+				positions = append(positions, pos)
+			}
+		}
+		return positions
+	case name == "xread" || name == "xreadgroup":
+		// STREAMS 之后前一半为键，后一半为 ID
+		for i, arg := range args {
+			if strings.EqualFold(argString(arg), "streams") {
+				n := (len(args) - i - 1) / 2
+				return rangeOf(i+1, i+1+n)
+			}
+		}
+	case name == "keys":
+		return rangeOf(1, 2)
+	case name == "scan":
+		// MATCH 模式加前缀；没有 MATCH 时由 strip 过滤结果
+		for i := 2; i+1 < len(args); i++ {
+			if strings.EqualFold(argString(args[i]), "match") {
+				return []int{i + 1}
+			}
+		}
+	}
+	return nil
+}
+
+// optionKeys 返回 from 之后紧跟在指定选项名后的参数下标
+func optionKeys(args []interface{}, from int, options ...string) []int {
+	var positions []int
+	for i := from; i+1 < len(args); i++ {
+		opt := argString(args[i])
+		for _, name := range options {
+			if strings.EqualFold(opt, name) {
+				positions = append(positions, i+1)
+				i++
+				break
+			}
+		}
+	}
+	return positions
+}
+
+// intArg 将参数解析为非负整数，失败时返回 0
+func intArg(args []interface{}, pos int) int {
+	if pos >= len(args) {
+		return 0
+	}
+	n, err := strconv.Atoi(argString(args[pos]))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
@@ -6,11 +6,25 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/internal/sqlcore"
 	"github.com/hyperits/gosuite/errors"
 	"github.com/hyperits/gosuite/logger"
 	"github.com/redis/go-redis/v9"
 )
 
+// 确保 Client 实现 db.KVStore 接口
+var _ db.KVStore = (*Client)(nil)
+
+// 确保 Client 实现 UniversalClientProvider 接口
+var _ UniversalClientProvider = (*Client)(nil)
+
+// UniversalClientProvider 提供底层 go-redis 客户端的对象
+// ratelimit、lock、queue、eventbus、cache 和 outbox 等包通过它访问 Redis，Client 满足该接口
+type UniversalClientProvider interface {
+	UniversalClient() redis.UniversalClient
+}
+
 // Config Redis 配置
 type Config struct {
 	Address           string
@@ -24,14 +38,34 @@ type Config struct {
 	SentinelAddresses []string
 	ClusterAddresses  []string
 	MaxRedirects      *int // 仅集群模式，重定向次数，默认 2
+
+	// 健康检查配置
+	HealthCheckInterval time.Duration // 后台健康检查间隔，默认 10 秒
+	HealthCheckTimeout  time.Duration // 单次健康检查超时，默认 3 秒
+	ReconnectAfter      time.Duration // 连接持续断开超过该时长后重建底层客户端，默认 30 秒
+
+	// KeyPrefix 键前缀，如 "order-service:"，透明地加在所有命令的键上（包括通过 UniversalClient 执行的命令），
+	// SCAN、KEYS 返回的键会去掉前缀；pub/sub 频道名不加前缀
+	KeyPrefix string
+
+	// Hooks 在每条命令前后调用的钩子
+	Hooks []db.Hook
+
+	// Redact 是否隐藏参数值，开启后钩子收到的 Statement 只保留命令名、子命令和键，其余参数以 "?" 代替
+	Redact bool
 }
 
+// DefaultReconnectAfter 默认重建底层客户端的断开时长
+const DefaultReconnectAfter = 30 * time.Second
+
 // Client Redis 客户端
 type Client struct {
-	conf   *Config
-	client redis.UniversalClient
-	closed bool
-	mu     sync.RWMutex
+	conf    *Config
+	client  redis.UniversalClient
+	watcher *db.Watcher
+	hooks   *sqlcore.Hooks
+	closed  bool
+	mu      sync.RWMutex
 }
 
 // NewClient 创建 Redis 客户端
@@ -49,15 +83,34 @@ func NewClient(conf *Config) (*Client, error) {
 		return nil, err
 	}
 
-	return &Client{
+	c := &Client{
 		conf:   conf,
 		client: client,
-	}, nil
+		hooks:  sqlcore.NewHooks("redis", conf.Hooks...),
+	}
+	c.addHooks(client)
+
+	reconnectAfter := conf.ReconnectAfter
+	if reconnectAfter <= 0 {
+		reconnectAfter = DefaultReconnectAfter
+	}
+	c.watcher = db.NewWatcher("redis", c.ping,
+		db.WithCheckInterval(conf.HealthCheckInterval),
+		db.WithCheckTimeout(conf.HealthCheckTimeout),
+		db.WithReconnect(reconnectAfter, c.reconnect))
+
+	return c, nil
 }
 
 // UniversalClient 返回底层的 Redis 客户端
+// 连接长时间断开后底层客户端会被重建，不应长期持有返回值
 func (c *Client) UniversalClient() redis.UniversalClient {
-	return c.client
+	return c.rc()
+}
+
+// Watcher 返回后台健康检查器，可用于订阅连接状态变化
+func (c *Client) Watcher() *db.Watcher {
+	return c.watcher
 }
 
 // GetConfig 返回 Redis 配置
@@ -67,6 +120,8 @@ func (c *Client) GetConfig() *Config {
 
 // Close 关闭 Redis 连接
 func (c *Client) Close() error {
+	c.watcher.Stop()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -90,7 +145,7 @@ func (c *Client) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
 
-// IsConnected 检查是否已连接
+// IsConnected 返回后台健康检查缓存的连接状态
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -99,35 +154,121 @@ func (c *Client) IsConnected() bool {
 		return false
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	return c.watcher.Connected()
+}
 
-	return c.client.Ping(ctx).Err() == nil
+// Stats 返回连接池统计
+func (c *Client) Stats() db.Stats {
+	ps := c.rc().PoolStats()
+	return db.Stats{
+		Redis: &db.RedisPoolStats{
+			Hits:       ps.Hits,
+			Misses:     ps.Misses,
+			Timeouts:   ps.Timeouts,
+			TotalConns: ps.TotalConns,
+			IdleConns:  ps.IdleConns,
+			StaleConns: ps.StaleConns,
+		},
+	}
 }
 
-// Get 获取值
+// Get 获取值，键不存在时返回 redis.Nil
 func (c *Client) Get(ctx context.Context, key string) (string, error) {
-	return c.client.Get(ctx, key).Result()
+	rc, err := c.active()
+	if err != nil {
+		return "", err
+	}
+	return rc.Get(ctx, key).Result()
 }
 
 // Set 设置值（无过期时间）
 func (c *Client) Set(ctx context.Context, key string, value interface{}) error {
-	return c.client.Set(ctx, key, value, 0).Err()
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	return rc.Set(ctx, key, value, 0).Err()
 }
 
 // SetWithTTL 设置值并指定过期时间
 func (c *Client) SetWithTTL(ctx context.Context, key string, value interface{}, ttlSeconds int) error {
-	return c.client.Set(ctx, key, value, time.Duration(ttlSeconds)*time.Second).Err()
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	return rc.Set(ctx, key, value, time.Duration(ttlSeconds)*time.Second).Err()
 }
 
 // Del 删除键
 func (c *Client) Del(ctx context.Context, keys ...string) error {
-	return c.client.Del(ctx, keys...).Err()
+	rc, err := c.active()
+	if err != nil {
+		return err
+	}
+	return rc.Del(ctx, keys...).Err()
 }
 
 // Exists 检查键是否存在
 func (c *Client) Exists(ctx context.Context, keys ...string) (int64, error) {
-	return c.client.Exists(ctx, keys...).Result()
+	rc, err := c.active()
+	if err != nil {
+		return 0, err
+	}
+	return rc.Exists(ctx, keys...).Result()
+}
+
+// active 返回当前的底层客户端，客户端已关闭时返回 errors.ErrAlreadyClosed
+func (c *Client) active() (redis.UniversalClient, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, errors.ErrAlreadyClosed
+	}
+	return c.client, nil
+}
+
+// rc 返回当前的底层客户端
+func (c *Client) rc() redis.UniversalClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// ping 供健康检查使用的 Ping
+func (c *Client) ping(ctx context.Context) error {
+	return c.rc().Ping(ctx).Err()
+}
+
+// reconnect 重建底层客户端，成功后关闭旧客户端
+func (c *Client) reconnect(ctx context.Context) error {
+	client, err := connect(c.conf)
+	if err != nil {
+		return err
+	}
+	c.addHooks(client)
+
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return client.Close()
+	}
+	old := c.client
+	c.client = client
+	c.mu.Unlock()
+
+	logger.Infof("redis client re-established")
+	return old.Close()
+}
+
+// addHooks 为底层客户端添加键前缀和操作钩子
+// 键前缀钩子在外层，操作钩子记录的是加上前缀后的实际命令
+func (c *Client) addHooks(client redis.UniversalClient) {
+	if c.conf.KeyPrefix != "" {
+		client.AddHook(prefixHook{prefix: c.conf.KeyPrefix})
+	}
+	client.AddHook(hookAdapter{hooks: c.hooks, redact: c.conf.Redact})
 }
 
 // IsConfigured 检查配置是否有效
@@ -203,6 +344,8 @@ func connect(conf *Config) (redis.UniversalClient, error) {
 	defer cancel()
 
 	if err := rc.Ping(ctx).Err(); err != nil {
+		// 重连期间会反复调用，必须释放连接池和后台 goroutine
+		_ = rc.Close()
 		return nil, errors.Wrap(err, "redis.connect")
 	}
 
@@ -0,0 +1,373 @@
+package redis
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/memkv"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestClient 创建连接到内存 Redis 服务的客户端
+func newTestClient(t *testing.T, prefix string) (*Client, *memkv.Server) {
+	t.Helper()
+	srv, err := memkv.NewServer(memkv.New())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	c, err := NewClient(&Config{Address: srv.Addr(), KeyPrefix: prefix, HealthCheckInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, srv
+}
+
+func TestKeyPrefix(t *testing.T) {
+	ctx := context.Background()
+	c, srv := newTestClient(t, "app:")
+	store := srv.Store()
+
+	c.Set(ctx, "k", "v")
+	c.HSet(ctx, "h", map[string]interface{}{"f": 1})
+	if _, err := store.Get(ctx, "app:k"); err != nil {
+		t.Errorf("prefixed key not stored: %v", err)
+	}
+	if v, err := c.Get(ctx, "k"); err != nil || v != "v" {
+		t.Errorf("Get = %q, %v", v, err)
+	}
+
+	// 通过 UniversalClient 执行的命令同样加前缀，管道和多键命令也生效
+	rc := c.UniversalClient()
+	pipe := rc.Pipeline()
+	pipe.MSet(ctx, "a", "1", "b", "2")
+	pipe.Incr(ctx, "a")
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("pipeline: %v", err)
+	}
+	if v, _ := store.Get(ctx, "app:a"); v != "2" {
+		t.Errorf("app:a = %q", v)
+	}
+	if n, _ := rc.Exists(ctx, "a", "b", "k", "missing").Result(); n != 3 {
+		t.Errorf("Exists = %d", n)
+	}
+
+	// 其他命名空间的键不可见
+	store.Set(ctx, "other:x", "1")
+	var keys []string
+	it := c.Scan(ctx, "", 1)
+	for it.Next(ctx) {
+		keys = append(keys, it.Key())
+	}
+	sort.Strings(keys)
+	if err := it.Err(); err != nil || len(keys) != 4 || keys[0] != "a" || keys[3] != "k" {
+		t.Errorf("Scan = %v, %v", keys, err)
+	}
+	if got, _ := rc.Keys(ctx, "[ab]").Result(); len(got) != 2 {
+		t.Errorf("Keys = %v", got)
+	}
+
+	ns := db.Namespace("user")
+	c.Set(ctx, ns.Key("1"), "x")
+	c.Set(ctx, ns.Key("2"), "y")
+	if n, err := c.DelMatch(ctx, ns.Match()); err != nil || n != 2 {
+		t.Errorf("DelMatch = %d, %v", n, err)
+	}
+	if n, err := c.FlushNamespace(ctx); err != nil || n != 4 {
+		t.Errorf("FlushNamespace = %d, %v", n, err)
+	}
+	if n, _ := store.Exists(ctx, "other:x"); n != 1 {
+		t.Error("FlushNamespace removed keys outside the namespace")
+	}
+}
+
+func TestFlushNamespaceWithoutPrefix(t *testing.T) {
+	c, _ := newTestClient(t, "")
+	if _, err := c.FlushNamespace(context.Background()); !errors.Is(err, errors.ErrNotConfigured) {
+		t.Errorf("FlushNamespace err = %v", err)
+	}
+}
+
+func TestKeyPositions(t *testing.T) {
+	cases := []struct {
+		args []interface{}
+		want []int
+	}{
+		// 不含键
+		{[]interface{}{"ping"}, nil},
+		{[]interface{}{"publish", "ch", "msg"}, nil},
+		// 第一个参数为键
+		{[]interface{}{"get", "k"}, []int{1}},
+		{[]interface{}{"georadius_ro", "g", 1.0, 2.0, 5, "km"}, []int{1}},
+		{[]interface{}{"georadiusbymember_ro", "g", "m", 5, "km"}, []int{1}},
+		// 全部参数为键
+		{[]interface{}{"del", "a", "b"}, []int{1, 2}},
+		// 阻塞命令
+		{[]interface{}{"blpop", "a", "b", 5}, []int{1, 2}},
+		{[]interface{}{"bzpopmin", "a", "b", 0}, []int{1, 2}},
+		// 前两个参数为键
+		{[]interface{}{"lmove", "a", "b", "left", "right"}, []int{1, 2}},
+		{[]interface{}{"geosearchstore", "dst", "src", "frommember", "m", "byradius", 5, "km"}, []int{1, 2}},
+		{[]interface{}{"lcs", "a", "b", "len"}, []int{1, 2}},
+		// 键数量位于第一个参数
+		{[]interface{}{"zunion", 2, "a", "b", "withscores"}, []int{2, 3}},
+		{[]interface{}{"zinter", 2, "a", "b"}, []int{2, 3}},
+		{[]interface{}{"zdiff", 2, "a", "b"}, []int{2, 3}},
+		{[]interface{}{"zintercard", 2, "a", "b", "limit", 1}, []int{2, 3}},
+		{[]interface{}{"sintercard", 2, "a", "b", "limit", 1}, []int{2, 3}},
+		{[]interface{}{"lmpop", 2, "a", "b", "left", "count", 1}, []int{2, 3}},
+		{[]interface{}{"zmpop", 1, "a", "min"}, []int{2}},
+		// 键数量位于第二个参数
+		{[]interface{}{"evalsha", "sha", 2, "a", "b", "arg"}, []int{3, 4}},
+		{[]interface{}{"eval", "return 1", 0}, nil},
+		{[]interface{}{"blmpop", 5, 2, "a", "b", "left"}, []int{3, 4}},
+		{[]interface{}{"bzmpop", 5, 1, "a", "max", "count", 2}, []int{3}},
+		// 子命令
+		{[]interface{}{"xgroup", "create", "s", "g", "$"}, []int{2}},
+		// 特殊位置
+		{[]interface{}{"mset", "a", 1, "b", 2}, []int{1, 3}},
+		{[]interface{}{"zunionstore", "dst", 2, "a", "b", "weights", 1, 2}, []int{1, 3, 4}},
+		{[]interface{}{"bitop", "and", "dst", "a", "b"}, []int{2, 3, 4}},
+		{[]interface{}{"xreadgroup", "group", "g", "c", "count", 1, "streams", "s1", "s2", ">", ">"}, []int{7, 8}},
+		{[]interface{}{"georadius", "g", 1.0, 2.0, 5, "km", "store", "dst"}, []int{1, 7}},
+		{[]interface{}{"georadius", "g", 1.0, 2.0, 5, "km", "count", 3, "storedist", "dst"}, []int{1, 9}},
+		{[]interface{}{"georadiusbymember", "g", "store", 5, "km", "store", "dst"}, []int{1, 6}},
+		{[]interface{}{"sort", "l", "by", "w_*", "get", "#", "get", "o_*", "store", "dst"}, []int{1, 3, 7, 9}},
+		{[]interface{}{"sort_ro", "l", "by", "nosort", "limit", 0, 10}, []int{1}},
+		{[]interface{}{"keys", "u:*"}, []int{1}},
+		{[]interface{}{"scan", 0, "match", "u:*", "count", 10}, []int{3}},
+	}
+	for _, c := range cases {
+		got := keyPositions(c.args[0].(string), c.args)
+		if len(got) != len(c.want) {
+			t.Errorf("%v: positions = %v, want %v", c.args, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%v: positions = %v, want %v", c.args, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestPrefixStrip(t *testing.T) {
+	ctx := context.Background()
+	h := prefixHook{prefix: "app:"}
+
+	zk := redis.NewZWithKeyCmd(ctx, "bzpopmin", "app:a", 0)
+	zk.SetVal(&redis.ZWithKey{Key: "app:a", Z: redis.Z{Member: "m", Score: 1}})
+	h.strip(zk)
+	if got := zk.Val(); got.Key != "a" || got.Member != "m" {
+		t.Errorf("bzpopmin = %+v", got)
+	}
+
+	kv := redis.NewKeyValuesCmd(ctx, "lmpop", 1, "app:a", "left")
+	kv.SetVal("app:a", []string{"x"})
+	h.strip(kv)
+	if key, val := kv.Val(); key != "a" || len(val) != 1 {
+		t.Errorf("lmpop = %q, %v", key, val)
+	}
+
+	zs := redis.NewZSliceWithKeyCmd(ctx, "zmpop", 1, "app:z", "min")
+	zs.SetVal("app:z", []redis.Z{{Member: "m"}})
+	h.strip(zs)
+	if key, val := zs.Val(); key != "z" || len(val) != 1 {
+		t.Errorf("zmpop = %q, %v", key, val)
+	}
+
+	ss := redis.NewStringSliceCmd(ctx, "blpop", "app:l", 0)
+	ss.SetVal([]string{"app:l", "v"})
+	h.strip(ss)
+	if got := ss.Val(); got[0] != "l" || got[1] != "v" {
+		t.Errorf("blpop = %v", got)
+	}
+
+	xs := redis.NewXStreamSliceCmd(ctx, "xread", "streams", "app:s", "0")
+	xs.SetVal([]redis.XStream{{Stream: "app:s"}})
+	h.strip(xs)
+	if got := xs.Val(); got[0].Stream != "s" {
+		t.Errorf("xread = %v", got)
+	}
+}
+
+// recordHook 记录命令的 Statement
+type recordHook struct{ stmts []string }
+
+func (h *recordHook) Before(ctx context.Context, e *db.QueryEvent) context.Context { return ctx }
+func (h *recordHook) After(ctx context.Context, e *db.QueryEvent) {
+	h.stmts = append(h.stmts, e.Statement)
+}
+
+func TestHookRedact(t *testing.T) {
+	srv, err := memkv.NewServer(memkv.New())
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	for _, redact := range []bool{false, true} {
+		h := &recordHook{}
+		c, err := NewClient(&Config{Address: srv.Addr(), KeyPrefix: "app:", Hooks: []db.Hook{h}, Redact: redact, HealthCheckInterval: time.Hour})
+		if err != nil {
+			t.Fatalf("NewClient: %v", err)
+		}
+		ctx := context.Background()
+		c.Set(ctx, "token", "secret")
+		c.UniversalClient().MSet(ctx, "a", "s1", "b", "s2")
+		c.Close()
+
+		want := []string{"set app:token secret", "mset app:a s1 app:b s2"}
+		if redact {
+			want = []string{"set app:token ?", "mset app:a ? app:b ?"}
+		}
+		if len(h.stmts) < 2 || h.stmts[len(h.stmts)-2] != want[0] || h.stmts[len(h.stmts)-1] != want[1] {
+			t.Errorf("redact=%v: statements = %q, want suffix %q", redact, h.stmts, want)
+		}
+	}
+}
+
+func TestCommands(t *testing.T) {
+	ctx := context.Background()
+	c, srv := newTestClient(t, "app:")
+	store := srv.Store()
+
+	c.Set(ctx, "persist", "v")
+	c.SetWithTTL(ctx, "volatile", "v", 60)
+	c.HSet(ctx, "h", map[string]interface{}{"f": "1"})
+	c.RPush(ctx, "l", "a")
+	c.ZAdd(ctx, "z",
+		db.ZMember{Member: "a", Score: 1}, db.ZMember{Member: "b", Score: 2},
+		db.ZMember{Member: "c", Score: 3}, db.ZMember{Member: "d", Score: 4})
+
+	t.Run("TTL", func(t *testing.T) {
+		cases := []struct {
+			key     string
+			want    time.Duration
+			wantErr bool
+		}{
+			{"persist", db.NoExpiration, false},
+			{"volatile", time.Minute, false},
+			{"missing", 0, true},
+		}
+		for _, tc := range cases {
+			got, err := c.TTL(ctx, tc.key)
+			if tc.wantErr {
+				if !errors.Is(err, errors.ErrNotFound) || !errors.Is(err, redis.Nil) {
+					t.Errorf("TTL(%s) err = %v, want ErrNotFound and redis.Nil", tc.key, err)
+				}
+				continue
+			}
+			if err != nil || got < tc.want-time.Second || got > tc.want {
+				t.Errorf("TTL(%s) = %v, %v, want %v", tc.key, got, err, tc.want)
+			}
+		}
+	})
+
+	t.Run("MGet", func(t *testing.T) {
+		got, err := c.MGet(ctx, "persist", "missing", "volatile")
+		if err != nil || len(got) != 2 || got["persist"] != "v" || got["volatile"] != "v" {
+			t.Errorf("MGet = %v, %v", got, err)
+		}
+		if _, ok := got["missing"]; ok {
+			t.Error("MGet returned missing key")
+		}
+		if got, err := c.MGet(ctx); err != nil || len(got) != 0 {
+			t.Errorf("MGet() = %v, %v", got, err)
+		}
+	})
+
+	t.Run("ZRangeByScore", func(t *testing.T) {
+		inf := math.Inf(1)
+		cases := []struct {
+			min, max      float64
+			offset, count int64
+			want          string
+		}{
+			{2, 3, 0, 0, "b c"},
+			{-inf, inf, 0, 0, "a b c d"},
+			{-inf, 2.5, 0, 0, "a b"},
+			{1, inf, 1, 2, "b c"},
+			{5, inf, 0, 0, ""},
+		}
+		for _, tc := range cases {
+			members, err := c.ZRangeByScore(ctx, "z", tc.min, tc.max, tc.offset, tc.count)
+			if err != nil {
+				t.Errorf("ZRangeByScore(%v, %v): %v", tc.min, tc.max, err)
+				continue
+			}
+			names := make([]string, len(members))
+			for i, m := range members {
+				names[i] = m.Member
+			}
+			if got := strings.Join(names, " "); got != tc.want {
+				t.Errorf("ZRangeByScore(%v, %v, %d, %d) = %q, want %q", tc.min, tc.max, tc.offset, tc.count, got, tc.want)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		cases := []struct {
+			name string
+			call func() error
+		}{
+			{"HGet", func() error { _, err := c.HGet(ctx, "h", "missing"); return err }},
+			{"LPop", func() error { _, err := c.LPop(ctx, "missing"); return err }},
+			{"RPop", func() error { _, err := c.RPop(ctx, "missing"); return err }},
+			{"ZScore", func() error { _, err := c.ZScore(ctx, "z", "missing"); return err }},
+			{"TTL", func() error { _, err := c.TTL(ctx, "missing"); return err }},
+		}
+		for _, tc := range cases {
+			if err := tc.call(); !errors.Is(err, errors.ErrNotFound) || !errors.Is(err, redis.Nil) {
+				t.Errorf("%s err = %v, want ErrNotFound and redis.Nil", tc.name, err)
+			}
+		}
+		if v, err := c.HGet(ctx, "h", "f"); err != nil || v != "1" {
+			t.Errorf("HGet = %q, %v", v, err)
+		}
+	})
+
+	if _, err := store.Get(ctx, "app:persist"); err != nil {
+		t.Errorf("commands did not apply key prefix: %v", err)
+	}
+
+	t.Run("Closed", func(t *testing.T) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+		cases := []struct {
+			name string
+			call func() error
+		}{
+			{"Incr", func() error { _, err := c.Incr(ctx, "n"); return err }},
+			{"SetNX", func() error { _, err := c.SetNX(ctx, "n", 1, 0); return err }},
+			{"MGet", func() error { _, err := c.MGet(ctx, "a"); return err }},
+			{"MSet", func() error { return c.MSet(ctx, map[string]interface{}{"a": 1}) }},
+			{"Expire", func() error { _, err := c.Expire(ctx, "a", time.Second); return err }},
+			{"TTL", func() error { _, err := c.TTL(ctx, "a"); return err }},
+			{"HGet", func() error { _, err := c.HGet(ctx, "h", "f"); return err }},
+			{"HGetAll", func() error { _, err := c.HGetAll(ctx, "h"); return err }},
+			{"LPush", func() error { _, err := c.LPush(ctx, "l", "a"); return err }},
+			{"LRange", func() error { _, err := c.LRange(ctx, "l", 0, -1); return err }},
+			{"SAdd", func() error { _, err := c.SAdd(ctx, "s", "a"); return err }},
+			{"ZAdd", func() error { _, err := c.ZAdd(ctx, "z", db.ZMember{Member: "a"}); return err }},
+			{"ZRangeByScore", func() error { _, err := c.ZRangeByScore(ctx, "z", 0, 1, 0, 0); return err }},
+			{"Scan", func() error { return c.Scan(ctx, "", 10).Err() }},
+			{"DelMatch", func() error { _, err := c.DelMatch(ctx, "*"); return err }},
+		}
+		for _, tc := range cases {
+			if err := tc.call(); !errors.Is(err, errors.ErrAlreadyClosed) {
+				t.Errorf("%s after Close err = %v, want ErrAlreadyClosed", tc.name, err)
+			}
+		}
+	})
+}
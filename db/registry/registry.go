@@ -0,0 +1,294 @@
+// Package registry 管理多个具名数据源客户端的创建、获取、健康检查和关闭
+//
+// 客户端按名称注册，名称在所有类型间唯一。默认在 New 时全部连接，
+// 配置 Lazy 后在首次获取时连接。Close 按连接顺序的逆序关闭全部客户端。
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/mysql"
+	"github.com/hyperits/gosuite/db/postgres"
+	"github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+)
+
+// Config 数据源配置，键为数据源名称
+type Config struct {
+	MySQL    map[string]*mysql.Config
+	Postgres map[string]*postgres.Config
+	SQLite   map[string]*sqlite.Config
+	Redis    map[string]*redis.Config
+
+	// Lazy 是否在首次获取时才连接，默认在 New 时全部连接
+	Lazy bool
+}
+
+// Factory 创建客户端的函数
+type Factory func() (db.Client, error)
+
+// entry 单个数据源
+type entry struct {
+	name    string
+	factory Factory
+	client  db.Client
+	mu      sync.Mutex
+}
+
+// Registry 数据源注册表
+type Registry struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+	names   []string // 注册顺序
+	opened  []*entry // 连接顺序，关闭时逆序
+	closed  bool
+}
+
+// New 根据配置创建注册表，非 Lazy 模式下任一数据源连接失败时关闭已连接的客户端并返回错误
+func New(conf *Config) (*Registry, error) {
+	if conf == nil {
+		return nil, errors.ErrNilConfig
+	}
+
+	r := &Registry{entries: make(map[string]*entry)}
+
+	for _, name := range sortedKeys(conf.MySQL) {
+		c := conf.MySQL[name]
+		if err := r.Register(name, func() (db.Client, error) { return mysql.NewClient(c) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(conf.Postgres) {
+		c := conf.Postgres[name]
+		if err := r.Register(name, func() (db.Client, error) { return postgres.NewClient(c) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(conf.SQLite) {
+		c := conf.SQLite[name]
+		if err := r.Register(name, func() (db.Client, error) { return sqlite.NewClient(c) }); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range sortedKeys(conf.Redis) {
+		c := conf.Redis[name]
+		if err := r.Register(name, func() (db.Client, error) { return redis.NewClient(c) }); err != nil {
+			return nil, err
+		}
+	}
+
+	if !conf.Lazy {
+		if err := r.Connect(); err != nil {
+			return nil, errors.Join(err, r.Close())
+		}
+	}
+
+	return r, nil
+}
+
+// Register 注册具名数据源，factory 在连接时调用
+func (r *Registry) Register(name string, factory Factory) error {
+	if name == "" || factory == nil {
+		return errors.Wrap(errors.ErrInvalidParameter, "registry: name and factory are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return errors.ErrAlreadyClosed
+	}
+	if _, ok := r.entries[name]; ok {
+		return errors.Wrap(errors.ErrInvalidParameter, "registry: duplicate data source "+name)
+	}
+
+	r.entries[name] = &entry{name: name, factory: factory}
+	r.names = append(r.names, name)
+	return nil
+}
+
+// Connect 按注册顺序连接全部尚未连接的数据源，遇到错误立即返回
+func (r *Registry) Connect() error {
+	for _, name := range r.Names() {
+		if _, err := r.Client(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Names 返回全部数据源名称，按注册顺序
+func (r *Registry) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.names...)
+}
+
+// Client 返回指定名称的客户端，尚未连接时先连接
+func (r *Registry) Client(name string) (db.Client, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errors.ErrAlreadyClosed
+	}
+	e, ok := r.entries[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, errors.Wrap(errors.ErrNotFound, "registry: data source "+name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.client != nil {
+		return e.client, nil
+	}
+
+	client, err := e.factory()
+	if err != nil {
+		return nil, errors.Wrapf(err, "registry: connect %s", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// 连接期间注册表可能已关闭，此时不再保留新客户端
+	if r.closed {
+		return nil, errors.Join(errors.ErrAlreadyClosed, client.Close())
+	}
+	e.client = client
+	r.opened = append(r.opened, e)
+
+	logger.Infof("registry: data source %s connected", name)
+	return client, nil
+}
+
+// Get 返回指定名称和类型的客户端，如 Get[*mysql.Client](r, "orders")
+func Get[T db.Client](r *Registry, name string) (T, error) {
+	var zero T
+
+	client, err := r.Client(name)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := client.(T)
+	if !ok {
+		return zero, errors.Wrap(errors.ErrInvalidParameter,
+			fmt.Sprintf("registry: data source %s is %T, not %T", name, client, zero))
+	}
+	return typed, nil
+}
+
+// MySQL 返回指定名称的 MySQL 客户端
+func (r *Registry) MySQL(name string) (*mysql.Client, error) {
+	return Get[*mysql.Client](r, name)
+}
+
+// Postgres 返回指定名称的 PostgreSQL 客户端
+func (r *Registry) Postgres(name string) (*postgres.Client, error) {
+	return Get[*postgres.Client](r, name)
+}
+
+// SQLite 返回指定名称的 SQLite 客户端
+func (r *Registry) SQLite(name string) (*sqlite.Client, error) {
+	return Get[*sqlite.Client](r, name)
+}
+
+// Redis 返回指定名称的 Redis 客户端
+func (r *Registry) Redis(name string) (*redis.Client, error) {
+	return Get[*redis.Client](r, name)
+}
+
+// SQL 返回指定名称的 SQL 客户端
+func (r *Registry) SQL(name string) (db.SQLClient, error) {
+	return Get[db.SQLClient](r, name)
+}
+
+// KV 返回指定名称的键值存储客户端
+func (r *Registry) KV(name string) (db.KVClient, error) {
+	return Get[db.KVClient](r, name)
+}
+
+// Health 对全部已连接的数据源执行 Ping，返回各数据源的结果（nil 表示健康）
+// Lazy 模式下尚未连接的数据源不包含在结果中
+func (r *Registry) Health(ctx context.Context) map[string]error {
+	r.mu.Lock()
+	opened := append([]*entry(nil), r.opened...)
+	r.mu.Unlock()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]error, len(opened))
+	)
+	for _, e := range opened {
+		wg.Add(1)
+		go func(e *entry) {
+			defer wg.Done()
+			err := e.client.Ping(ctx)
+			mu.Lock()
+			results[e.name] = err
+			mu.Unlock()
+		}(e)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Ping 对全部已连接的数据源执行 Ping，合并返回失败数据源的错误
+func (r *Registry) Ping(ctx context.Context) error {
+	results := r.Health(ctx)
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			errs = append(errs, errors.Wrap(err, name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 按连接顺序的逆序关闭全部客户端，合并返回关闭错误
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return errors.ErrAlreadyClosed
+	}
+	r.closed = true
+	opened := r.opened
+	r.opened = nil
+	r.mu.Unlock()
+
+	var errs []error
+	for i := len(opened) - 1; i >= 0; i-- {
+		e := opened[i]
+		if err := e.client.Close(); err != nil {
+			errs = append(errs, errors.Wrap(err, e.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// sortedKeys 返回按字典序排列的键，保证连接顺序确定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package registry_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/mysql"
+	"github.com/hyperits/gosuite/db/registry"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// fakeClient 记录关闭顺序的客户端
+type fakeClient struct {
+	name    string
+	closed  *[]string
+	pingErr error
+}
+
+func (f *fakeClient) Close() error {
+	*f.closed = append(*f.closed, f.name)
+	return nil
+}
+func (f *fakeClient) Ping(ctx context.Context) error { return f.pingErr }
+func (f *fakeClient) IsConnected() bool              { return f.pingErr == nil }
+func (f *fakeClient) Stats() db.Stats                { return db.Stats{} }
+
+func TestEagerConnectAndTypedGet(t *testing.T) {
+	r, err := registry.New(&registry.Config{
+		SQLite: map[string]*sqlite.Config{"main": {}, "audit": {}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if got := r.Names(); len(got) != 2 || got[0] != "audit" || got[1] != "main" {
+		t.Errorf("Names() = %v", got)
+	}
+
+	client, err := r.SQLite("main")
+	if err != nil || client == nil {
+		t.Fatalf("SQLite() = %v, %v", client, err)
+	}
+	again, _ := registry.Get[*sqlite.Client](r, "main")
+	if again != client {
+		t.Error("expected the same client instance")
+	}
+	if _, err := r.SQL("main"); err != nil {
+		t.Errorf("SQL() error = %v", err)
+	}
+
+	if _, err := r.MySQL("main"); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("MySQL() on sqlite error = %v, want ErrInvalidParameter", err)
+	}
+	if _, err := r.Client("missing"); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("Client(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := r.Ping(context.Background()); err != nil {
+		t.Errorf("Ping() = %v", err)
+	}
+}
+
+func TestLazyConnect(t *testing.T) {
+	r, err := registry.New(&registry.Config{
+		Lazy:  true,
+		MySQL: map[string]*mysql.Config{"broken": {Host: "127.0.0.1", Port: 1, Username: "u", DbName: "d"}},
+	})
+	if err != nil {
+		t.Fatalf("lazy New() should not connect: %v", err)
+	}
+	defer r.Close()
+
+	if len(r.Health(context.Background())) != 0 {
+		t.Error("expected no health results before connecting")
+	}
+	if _, err := r.MySQL("broken"); err == nil {
+		t.Error("expected connect error on first Get")
+	}
+}
+
+func TestEagerConnectFailure(t *testing.T) {
+	_, err := registry.New(&registry.Config{
+		MySQL: map[string]*mysql.Config{"broken": {Host: "127.0.0.1", Port: 1, Username: "u", DbName: "d"}},
+	})
+	if err == nil {
+		t.Fatal("expected eager connect error")
+	}
+}
+
+func TestCloseReverseOrder(t *testing.T) {
+	r, err := registry.New(&registry.Config{Lazy: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var closed []string
+	down := errors.New("down")
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		f := &fakeClient{name: name, closed: &closed}
+		if name == "b" {
+			f.pingErr = down
+		}
+		if err := r.Register(name, func() (db.Client, error) { return f, nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Register("a", func() (db.Client, error) { return nil, nil }); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("duplicate Register() error = %v", err)
+	}
+
+	// 连接顺序 c、a、b
+	for _, name := range []string{"c", "a", "b"} {
+		if _, err := r.Client(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.Ping(context.Background()); !errors.Is(err, down) {
+		t.Errorf("Ping() = %v, want %v", err, down)
+	}
+	health := r.Health(context.Background())
+	if health["a"] != nil || health["b"] != down {
+		t.Errorf("Health() = %v", health)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(closed) != 3 || closed[0] != "b" || closed[1] != "a" || closed[2] != "c" {
+		t.Errorf("close order = %v, want [b a c]", closed)
+	}
+	if err := r.Close(); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("second Close() = %v", err)
+	}
+	if _, err := r.Client("a"); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Client() after Close = %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+package db
+
+import "context"
+
+// ReplicaPolicy 只读副本负载均衡策略
+type ReplicaPolicy string
+
+const (
+	// ReplicaRoundRobin 轮询
+	ReplicaRoundRobin ReplicaPolicy = "round_robin"
+	// ReplicaLeastConn 最少使用中连接
+	ReplicaLeastConn ReplicaPolicy = "least_conn"
+)
+
+type primaryKey struct{}
+
+// WithPrimary 返回强制读主库的 context，用于写后立即读等需要强一致的场景
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimaryForced 判断 context 是否要求读主库
+func IsPrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(primaryKey{}).(bool)
+	return forced
+}
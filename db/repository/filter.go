@@ -0,0 +1,85 @@
+package repository
+
+import "gorm.io/gorm/clause"
+
+// Filter 查询条件，column 为数据库列名，值以参数绑定方式传递
+type Filter = clause.Expression
+
+// Eq 等于，value 为 nil 时生成 IS NULL
+func Eq(column string, value interface{}) Filter {
+	return clause.Eq{Column: col(column), Value: value}
+}
+
+// Ne 不等于，value 为 nil 时生成 IS NOT NULL
+func Ne(column string, value interface{}) Filter {
+	return clause.Neq{Column: col(column), Value: value}
+}
+
+// Gt 大于
+func Gt(column string, value interface{}) Filter {
+	return clause.Gt{Column: col(column), Value: value}
+}
+
+// Gte 大于等于
+func Gte(column string, value interface{}) Filter {
+	return clause.Gte{Column: col(column), Value: value}
+}
+
+// Lt 小于
+func Lt(column string, value interface{}) Filter {
+	return clause.Lt{Column: col(column), Value: value}
+}
+
+// Lte 小于等于
+func Lte(column string, value interface{}) Filter {
+	return clause.Lte{Column: col(column), Value: value}
+}
+
+// In 属于集合
+func In(column string, values ...interface{}) Filter {
+	return clause.IN{Column: col(column), Values: values}
+}
+
+// Like 模式匹配，通配符由调用方提供
+func Like(column string, pattern string) Filter {
+	return clause.Like{Column: col(column), Value: pattern}
+}
+
+// IsNull 为空
+func IsNull(column string) Filter {
+	return clause.Eq{Column: col(column), Value: nil}
+}
+
+// NotNull 不为空
+func NotNull(column string) Filter {
+	return clause.Neq{Column: col(column), Value: nil}
+}
+
+// Between 闭区间 [from, to]
+func Between(column string, from, to interface{}) Filter {
+	return clause.And(Gte(column, from), Lte(column, to))
+}
+
+// And 全部条件同时满足
+func And(filters ...Filter) Filter {
+	return clause.And(filters...)
+}
+
+// Or 任一条件满足
+func Or(filters ...Filter) Filter {
+	// gorm 将单元素 Or 视为与前一个条件的 OR 连接，这里避免该语义
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return clause.Or(filters...)
+}
+
+// Not 条件取反
+func Not(filters ...Filter) Filter {
+	return clause.Not(filters...)
+}
+
+// col 返回当前表的列
+func col(column string) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: column}
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+
+	"github.com/hyperits/gosuite/errors"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// 分页默认配置
+const (
+	DefaultPageSize = 20   // 默认每页条数
+	MaxPageSize     = 1000 // 每页条数上限
+)
+
+// Order 排序列
+type Order struct {
+	Column string // 列名或字段名
+	Desc   bool   // 是否降序
+}
+
+// PageRequest 页码分页请求
+type PageRequest struct {
+	Page    int     // 页码，从 1 开始，默认 1
+	Size    int     // 每页条数，默认 20，最大 1000
+	OrderBy []Order // 排序列，默认按主键升序
+}
+
+// Page 页码分页结果
+type Page[T any] struct {
+	Items []*T  // 当前页记录
+	Total int64 // 满足条件的总记录数
+	Page  int   // 当前页码
+	Size  int   // 每页条数
+}
+
+// Pages 返回总页数
+func (p *Page[T]) Pages() int {
+	if p.Size <= 0 {
+		return 0
+	}
+	return int((p.Total + int64(p.Size) - 1) / int64(p.Size))
+}
+
+// CursorRequest 游标分页请求
+type CursorRequest struct {
+	Cursor  string // 上一页返回的 NextCursor，为空表示第一页
+	Limit   int    // 每页条数，默认 20，最大 1000
+	OrderBy string // 排序列，默认主键；非主键时以主键作为次排序保证顺序稳定，须为非空列
+	Desc    bool   // 是否降序
+}
+
+// CursorPage 游标分页结果
+type CursorPage[T any] struct {
+	Items      []*T   // 当前页记录
+	NextCursor string // 下一页游标，没有更多记录时为空
+}
+
+// HasMore 判断是否还有下一页
+func (p *CursorPage[T]) HasMore() bool {
+	return p.NextCursor != ""
+}
+
+// cursor 游标内容，序列化为 JSON 后以 base64url 编码，对调用方不透明
+type cursor struct {
+	Key json.RawMessage `json:"k,omitempty"`
+	ID  json.RawMessage `json:"id"`
+}
+
+// List 页码分页查询
+func (r *Repository[T]) List(ctx context.Context, req PageRequest, filters ...Filter) (*Page[T], error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	size := pageSize(req.Size)
+
+	orders := make([]clause.OrderByColumn, 0, len(req.OrderBy)+1)
+	for _, o := range req.OrderBy {
+		field := r.schema.LookUpField(o.Column)
+		if field == nil || field.DBName == "" {
+			return nil, errors.Wrap(errors.ErrInvalidParameter, "repository: unknown order column "+o.Column)
+		}
+		orders = append(orders, clause.OrderByColumn{Column: col(field.DBName), Desc: o.Desc})
+	}
+	if len(orders) == 0 {
+		orders = append(orders, clause.OrderByColumn{Column: col(r.pk.DBName)})
+	}
+
+	var total int64
+	if err := r.query(ctx, filters).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	result := &Page[T]{Total: total, Page: page, Size: size}
+	if total == 0 || int64((page-1)*size) >= total {
+		return result, nil
+	}
+
+	err := r.query(ctx, filters).
+		Clauses(clause.OrderBy{Columns: orders}).
+		Offset((page - 1) * size).
+		Limit(size).
+		Find(&result.Items).Error
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Scan 游标（键集）分页查询，适合深分页和无限滚动
+// 排序列的值在翻页期间被修改可能导致记录重复或遗漏。
+// NULL 不参与比较，可空排序列（指针或 sql.Scanner 类型且未声明 not null）返回 errors.ErrInvalidParameter
+func (r *Repository[T]) Scan(ctx context.Context, req CursorRequest, filters ...Filter) (*CursorPage[T], error) {
+	limit := pageSize(req.Limit)
+
+	key := r.pk
+	if req.OrderBy != "" {
+		key = r.schema.LookUpField(req.OrderBy)
+		if key == nil || key.DBName == "" {
+			return nil, errors.Wrap(errors.ErrInvalidParameter, "repository: unknown order column "+req.OrderBy)
+		}
+		if nullable(key) {
+			return nil, errors.Wrap(errors.ErrInvalidParameter, "repository: nullable order column "+req.OrderBy+" cannot be used as cursor key")
+		}
+	}
+	byPK := key == r.pk
+
+	tx := r.query(ctx, filters)
+	if req.Cursor != "" {
+		keyValue, idValue, err := r.decodeCursor(req.Cursor, key, byPK)
+		if err != nil {
+			return nil, err
+		}
+		after := func(column string, value interface{}) Filter {
+			if req.Desc {
+				return Lt(column, value)
+			}
+			return Gt(column, value)
+		}
+		if byPK {
+			tx = tx.Clauses(clause.Where{Exprs: []clause.Expression{after(r.pk.DBName, idValue)}})
+		} else {
+			tx = tx.Clauses(clause.Where{Exprs: []clause.Expression{Or(
+				after(key.DBName, keyValue),
+				And(Eq(key.DBName, keyValue), after(r.pk.DBName, idValue)),
+			)}})
+		}
+	}
+
+	orders := []clause.OrderByColumn{{Column: col(key.DBName), Desc: req.Desc}}
+	if !byPK {
+		orders = append(orders, clause.OrderByColumn{Column: col(r.pk.DBName), Desc: req.Desc})
+	}
+
+	var items []*T
+	err := tx.Clauses(clause.OrderBy{Columns: orders}).Limit(limit + 1).Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CursorPage[T]{Items: items}
+	if len(items) > limit {
+		result.Items = items[:limit]
+		next, err := r.encodeCursor(ctx, result.Items[limit-1], key, byPK)
+		if err != nil {
+			return nil, err
+		}
+		result.NextCursor = next
+	}
+	return result, nil
+}
+
+// encodeCursor 以记录的排序列和主键值生成游标
+func (r *Repository[T]) encodeCursor(ctx context.Context, entity *T, key *schema.Field, byPK bool) (string, error) {
+	rv := reflect.ValueOf(entity).Elem()
+
+	var c cursor
+	id, _ := r.pk.ValueOf(ctx, rv)
+	raw, err := json.Marshal(id)
+	if err != nil {
+		return "", errors.Wrap(err, "repository: encode cursor")
+	}
+	c.ID = raw
+
+	if !byPK {
+		value, _ := key.ValueOf(ctx, rv)
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", errors.Wrap(err, "repository: encode cursor")
+		}
+		c.Key = raw
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "repository: encode cursor")
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor 解析游标，按字段类型还原排序列和主键值
+func (r *Repository[T]) decodeCursor(token string, key *schema.Field, byPK bool) (interface{}, interface{}, error) {
+	invalid := func(err error) error {
+		return errors.Wrap(errors.ErrInvalidParameter, "repository: invalid cursor: "+err.Error())
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, nil, invalid(err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, nil, invalid(err)
+	}
+
+	id, err := decodeValue(c.ID, r.pk)
+	if err != nil {
+		return nil, nil, invalid(err)
+	}
+	if byPK {
+		return nil, id, nil
+	}
+	if len(c.Key) == 0 {
+		return nil, nil, invalid(errors.New("missing order key"))
+	}
+	value, err := decodeValue(c.Key, key)
+	if err != nil {
+		return nil, nil, invalid(err)
+	}
+	return value, id, nil
+}
+
+// decodeValue 将 JSON 值解析为字段类型
+func decodeValue(raw json.RawMessage, field *schema.Field) (interface{}, error) {
+	v := reflect.New(field.FieldType)
+	if err := json.Unmarshal(raw, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+// scannerType sql.Scanner 接口类型
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// nullable 判断字段能否存储 NULL
+// 指针和 sql.NullString、gorm.DeletedAt 等 Scanner 类型可表示 NULL，声明 not null 或主键时除外
+func nullable(field *schema.Field) bool {
+	if field.NotNull || field.PrimaryKey {
+		return false
+	}
+	t := field.FieldType
+	return t.Kind() == reflect.Ptr || reflect.PtrTo(t).Implements(scannerType)
+}
+
+// pageSize 规范化每页条数
+func pageSize(size int) int {
+	switch {
+	case size <= 0:
+		return DefaultPageSize
+	case size > MaxPageSize:
+		return MaxPageSize
+	default:
+		return size
+	}
+}
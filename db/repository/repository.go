@@ -0,0 +1,336 @@
+// Package repository 提供基于 gorm 的通用仓储，适用于 mysql、postgres、sqlite 客户端
+//
+// Repository[T] 封装常见的增删改查：模型包含 gorm.DeletedAt 字段时删除为软删除，
+// 包含整型版本列（默认 version）时更新使用乐观锁；查询条件通过 Eq、In 等构造，
+// 支持页码分页（List）和基于游标的键集分页（Scan）。记录不存在时返回 errors.ErrNotFound。
+package repository
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/hyperits/gosuite/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DefaultVersionColumn 默认乐观锁版本列
+const DefaultVersionColumn = "version"
+
+// ErrVersionConflict 乐观锁冲突，记录已被其他操作修改或删除
+var ErrVersionConflict = errors.New("repository: version conflict")
+
+// GormProvider 提供 gorm 连接的客户端，mysql、postgres、sqlite 的 Client 均满足
+type GormProvider interface {
+	DB() *gorm.DB
+}
+
+// Repository 模型 T 的通用仓储，T 为结构体类型
+type Repository[T any] struct {
+	db        *gorm.DB
+	schema    *schema.Schema
+	pk        *schema.Field
+	version   *schema.Field
+	deletedAt *schema.Field
+	unscoped  bool
+}
+
+// options 仓储配置
+type options struct {
+	versionColumn string
+}
+
+// Option 仓储配置选项函数
+type Option func(*options)
+
+// WithVersionColumn 设置乐观锁版本列，默认 version（模型无此列时不启用），为空表示禁用乐观锁
+func WithVersionColumn(column string) Option {
+	return func(o *options) {
+		o.versionColumn = column
+	}
+}
+
+// New 创建模型 T 的仓储，T 必须有且仅有一个主键
+func New[T any](client GormProvider, opts ...Option) (*Repository[T], error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+	return NewWithDB[T](client.DB(), opts...)
+}
+
+// NewWithDB 基于 gorm 连接创建模型 T 的仓储
+func NewWithDB[T any](db *gorm.DB, opts ...Option) (*Repository[T], error) {
+	if db == nil {
+		return nil, errors.ErrNilClient
+	}
+
+	o := &options{versionColumn: DefaultVersionColumn}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, errors.Wrap(err, "repository: parse model")
+	}
+	s := stmt.Schema
+
+	r := &Repository[T]{db: db, schema: s, pk: s.PrioritizedPrimaryField}
+	if r.pk == nil {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "repository: model "+s.Name+" must have a single primary key")
+	}
+
+	if o.versionColumn != "" {
+		if f := s.LookUpField(o.versionColumn); f != nil {
+			switch f.FieldType.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				r.version = f
+			default:
+				return nil, errors.Wrap(errors.ErrInvalidParameter, "repository: version column must be an integer")
+			}
+		}
+	}
+
+	for _, f := range s.Fields {
+		if f.FieldType == reflect.TypeOf(gorm.DeletedAt{}) {
+			r.deletedAt = f
+			break
+		}
+	}
+
+	return r, nil
+}
+
+// WithDB 返回绑定到指定 gorm 连接的仓储副本，通常用于在外部事务中操作
+func (r *Repository[T]) WithDB(db *gorm.DB) *Repository[T] {
+	clone := *r
+	clone.db = db
+	return &clone
+}
+
+// WithTrashed 返回包含已软删除记录的仓储副本
+func (r *Repository[T]) WithTrashed() *Repository[T] {
+	clone := *r
+	clone.unscoped = true
+	return &clone
+}
+
+// Transaction 在事务中执行 fn，fn 返回错误或 panic 时回滚
+func (r *Repository[T]) Transaction(ctx context.Context, fn func(repo *Repository[T]) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.WithDB(tx))
+	})
+}
+
+// Create 插入记录，自增主键和默认值回填到 entity
+func (r *Repository[T]) Create(ctx context.Context, entity *T) error {
+	return r.session(ctx).Create(entity).Error
+}
+
+// CreateInBatches 分批插入多条记录，batchSize 不大于 0 时一次插入
+func (r *Repository[T]) CreateInBatches(ctx context.Context, entities []*T, batchSize int) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = len(entities)
+	}
+	return r.session(ctx).CreateInBatches(entities, batchSize).Error
+}
+
+// Get 按主键查询
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	return r.First(ctx, r.byID(id))
+}
+
+// First 查询满足条件的第一条记录（按主键排序）
+func (r *Repository[T]) First(ctx context.Context, filters ...Filter) (*T, error) {
+	entity := new(T)
+	if err := r.query(ctx, filters).First(entity).Error; err != nil {
+		return nil, translate(err)
+	}
+	return entity, nil
+}
+
+// Find 查询满足条件的全部记录
+func (r *Repository[T]) Find(ctx context.Context, filters ...Filter) ([]*T, error) {
+	var entities []*T
+	if err := r.query(ctx, filters).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+// Count 统计满足条件的记录数
+func (r *Repository[T]) Count(ctx context.Context, filters ...Filter) (int64, error) {
+	var n int64
+	err := r.query(ctx, filters).Count(&n).Error
+	return n, err
+}
+
+// Exists 判断是否存在满足条件的记录
+func (r *Repository[T]) Exists(ctx context.Context, filters ...Filter) (bool, error) {
+	var n int64
+	err := r.query(ctx, filters).Limit(1).Count(&n).Error
+	return n > 0, err
+}
+
+// Update 按主键更新全部字段（包括零值）
+// 启用乐观锁时仅在版本号与 entity 一致时更新，并将 entity 的版本号加一，
+// 版本不一致或记录不存在时返回 ErrVersionConflict
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	rv := reflect.ValueOf(entity).Elem()
+
+	id, zero := r.pk.ValueOf(ctx, rv)
+	if zero {
+		return errors.Wrap(errors.ErrInvalidParameter, "repository: primary key is required for update")
+	}
+
+	tx := r.session(ctx).Model(entity).Select("*").Omit(r.pk.Name).Where(r.byID(id))
+	if r.version == nil {
+		return tx.Updates(entity).Error
+	}
+
+	field := r.version.ReflectValueOf(ctx, rv)
+	current := reflect.New(field.Type()).Elem()
+	current.Set(field)
+	bump(field)
+
+	result := tx.Where(clause.Eq{Column: col(r.version.DBName), Value: current.Interface()}).Updates(entity)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		field.Set(current)
+	}
+	return result.Error
+}
+
+// UpdateFields 按主键更新指定列，fields 的键为列名或字段名
+// 启用乐观锁时版本号自增，但不校验旧版本；记录不存在时返回 errors.ErrNotFound
+func (r *Repository[T]) UpdateFields(ctx context.Context, id interface{}, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	values := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		values[k] = v
+	}
+	if r.version != nil {
+		values[r.version.DBName] = gorm.Expr(r.db.Statement.Quote(r.version.DBName)+" + ?", 1)
+	}
+
+	result := r.session(ctx).Model(new(T)).Where(r.byID(id)).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notFoundOr(ctx, id)
+	}
+	return nil
+}
+
+// Delete 按主键删除，模型包含 gorm.DeletedAt 时为软删除
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	result := r.session(ctx).Where(r.byID(id)).Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// ForceDelete 按主键物理删除，忽略软删除
+func (r *Repository[T]) ForceDelete(ctx context.Context, id interface{}) error {
+	result := r.session(ctx).Unscoped().Where(r.byID(id)).Delete(new(T))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// Restore 恢复已软删除的记录，模型不支持软删除时返回 errors.ErrInvalidParameter
+func (r *Repository[T]) Restore(ctx context.Context, id interface{}) error {
+	if r.deletedAt == nil {
+		return errors.Wrap(errors.ErrInvalidParameter, "repository: model "+r.schema.Name+" does not support soft delete")
+	}
+
+	result := r.session(ctx).Unscoped().Model(new(T)).
+		Where(r.byID(id)).
+		Where(clause.Neq{Column: col(r.deletedAt.DBName), Value: nil}).
+		Update(r.deletedAt.DBName, nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// session 返回绑定上下文的新会话
+func (r *Repository[T]) session(ctx context.Context) *gorm.DB {
+	tx := r.db.WithContext(ctx)
+	if r.unscoped {
+		tx = tx.Unscoped()
+	}
+	return tx
+}
+
+// query 返回带查询条件的会话
+func (r *Repository[T]) query(ctx context.Context, filters []Filter) *gorm.DB {
+	tx := r.session(ctx).Model(new(T))
+	exprs := make([]clause.Expression, 0, len(filters))
+	for _, f := range filters {
+		if f != nil {
+			exprs = append(exprs, f)
+		}
+	}
+	if len(exprs) > 0 {
+		tx = tx.Clauses(clause.Where{Exprs: exprs})
+	}
+	return tx
+}
+
+// byID 返回主键条件
+func (r *Repository[T]) byID(id interface{}) Filter {
+	return clause.Eq{Column: col(r.pk.DBName), Value: id}
+}
+
+// notFoundOr 更新未影响任何行时区分记录不存在和值未变化（MySQL 默认返回实际变更行数）
+func (r *Repository[T]) notFoundOr(ctx context.Context, id interface{}) error {
+	exists, err := r.Exists(ctx, r.byID(id))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// bump 版本号加一
+func bump(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	default:
+		v.SetUint(v.Uint() + 1)
+	}
+}
+
+// translate 将 gorm.ErrRecordNotFound 转换为 errors.ErrNotFound
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.ErrNotFound
+	}
+	return err
+}
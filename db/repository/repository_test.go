@@ -0,0 +1,280 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db/repository"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+	"gorm.io/gorm"
+)
+
+type Article struct {
+	ID        uint `gorm:"primaryKey"`
+	Title     string
+	Score     int
+	Version   int
+	Summary   *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+func newTestRepo(t *testing.T) *repository.Repository[Article] {
+	t.Helper()
+
+	client, err := sqlite.NewClient(&sqlite.Config{})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := client.DB().AutoMigrate(&Article{}); err != nil {
+		t.Fatalf("AutoMigrate() returned error: %v", err)
+	}
+
+	repo, err := repository.New[Article](client)
+	if err != nil {
+		t.Fatalf("New() returned error: %v", err)
+	}
+	return repo
+}
+
+func seed(t *testing.T, repo *repository.Repository[Article], scores ...int) {
+	t.Helper()
+
+	for i, score := range scores {
+		a := &Article{Title: string(rune('a' + i)), Score: score}
+		if err := repo.Create(context.Background(), a); err != nil {
+			t.Fatalf("Create() returned error: %v", err)
+		}
+	}
+}
+
+func TestCRUD(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	a := &Article{Title: "hello"}
+	if err := repo.Create(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.Get(ctx, a.ID)
+	if err != nil || got.Title != "hello" {
+		t.Fatalf("Get() = %+v, %v", got, err)
+	}
+
+	if _, err := repo.Get(ctx, 999); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	if err := repo.UpdateFields(ctx, a.ID, map[string]interface{}{"title": "renamed"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ = repo.Get(ctx, a.ID)
+	if got.Title != "renamed" || got.Version != 1 {
+		t.Errorf("after UpdateFields: %+v", got)
+	}
+	if err := repo.UpdateFields(ctx, 999, map[string]interface{}{"title": "x"}); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("UpdateFields(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestOptimisticLock(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	a := &Article{Title: "v0"}
+	if err := repo.Create(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := repo.Get(ctx, a.ID)
+	second, _ := repo.Get(ctx, a.ID)
+
+	first.Title = "first"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("Update() returned error: %v", err)
+	}
+	if first.Version != 1 {
+		t.Errorf("version = %d, want 1", first.Version)
+	}
+
+	second.Title = "second"
+	if err := repo.Update(ctx, second); !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("stale Update() error = %v, want ErrVersionConflict", err)
+	}
+	if second.Version != 0 {
+		t.Errorf("version after conflict = %d, want unchanged 0", second.Version)
+	}
+
+	got, _ := repo.Get(ctx, a.ID)
+	if got.Title != "first" || got.Version != 1 {
+		t.Errorf("stored = %+v", got)
+	}
+}
+
+func TestSoftDelete(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	seed(t, repo, 1, 2)
+
+	if err := repo.Delete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, 1); !errors.Is(err, errors.ErrNotFound) {
+		t.Errorf("second Delete() error = %v, want ErrNotFound", err)
+	}
+	if n, _ := repo.Count(ctx); n != 1 {
+		t.Errorf("Count() = %d, want 1", n)
+	}
+	if n, _ := repo.WithTrashed().Count(ctx); n != 2 {
+		t.Errorf("WithTrashed().Count() = %d, want 2", n)
+	}
+
+	if err := repo.Restore(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Get(ctx, 1); err != nil {
+		t.Errorf("Get() after Restore error = %v", err)
+	}
+
+	if err := repo.ForceDelete(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := repo.WithTrashed().Count(ctx); n != 1 {
+		t.Errorf("WithTrashed().Count() after ForceDelete = %d, want 1", n)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	seed(t, repo, 10, 20, 30, 40)
+
+	tests := []struct {
+		name    string
+		filters []repository.Filter
+		want    int
+	}{
+		{"eq", []repository.Filter{repository.Eq("score", 20)}, 1},
+		{"range", []repository.Filter{repository.Gt("score", 10), repository.Lte("score", 30)}, 2},
+		{"between", []repository.Filter{repository.Between("score", 20, 40)}, 3},
+		{"in", []repository.Filter{repository.In("title", "a", "d")}, 2},
+		{"or", []repository.Filter{repository.Gte("score", 20), repository.Or(repository.Eq("title", "b"), repository.Eq("title", "d"))}, 2},
+		{"not", []repository.Filter{repository.Not(repository.Eq("score", 10))}, 3},
+		{"like", []repository.Filter{repository.Like("title", "%c%")}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := repo.Find(ctx, tt.filters...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != tt.want {
+				t.Errorf("Find() returned %d items, want %d", len(items), tt.want)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	seed(t, repo, 5, 3, 9, 1, 7)
+
+	page, err := repo.List(ctx, repository.PageRequest{
+		Page:    2,
+		Size:    2,
+		OrderBy: []repository.Order{{Column: "score", Desc: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 5 || page.Pages() != 3 || len(page.Items) != 2 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+	if page.Items[0].Score != 5 || page.Items[1].Score != 3 {
+		t.Errorf("page 2 scores = %d, %d, want 5, 3", page.Items[0].Score, page.Items[1].Score)
+	}
+
+	if _, err := repo.List(ctx, repository.PageRequest{OrderBy: []repository.Order{{Column: "score; DROP TABLE article"}}}); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("unknown order column error = %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+	seed(t, repo, 5, 3, 5, 1, 7, 5)
+
+	for _, tt := range []struct {
+		name string
+		req  repository.CursorRequest
+		want []uint
+	}{
+		{"by primary key", repository.CursorRequest{Limit: 4}, []uint{1, 2, 3, 4, 5, 6}},
+		{"by score desc", repository.CursorRequest{Limit: 2, OrderBy: "score", Desc: true}, []uint{5, 6, 3, 1, 2, 4}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var ids []uint
+			req := tt.req
+			for pages := 0; pages < 10; pages++ {
+				page, err := repo.Scan(ctx, req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, item := range page.Items {
+					ids = append(ids, item.ID)
+				}
+				if !page.HasMore() {
+					break
+				}
+				req.Cursor = page.NextCursor
+			}
+			if len(ids) != len(tt.want) {
+				t.Fatalf("ids = %v, want %v", ids, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("ids = %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := repo.Scan(ctx, repository.CursorRequest{Cursor: "not-a-cursor"}); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("invalid cursor error = %v", err)
+	}
+	for _, column := range []string{"summary", "deleted_at"} {
+		if _, err := repo.Scan(ctx, repository.CursorRequest{OrderBy: column}); !errors.Is(err, errors.ErrInvalidParameter) {
+			t.Errorf("nullable order column %s error = %v", column, err)
+		}
+	}
+	if _, err := repo.Scan(ctx, repository.CursorRequest{OrderBy: "created_at"}); err != nil {
+		t.Errorf("non-null time column error = %v", err)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	repo := newTestRepo(t)
+	ctx := context.Background()
+
+	boom := errors.New("boom")
+	err := repo.Transaction(ctx, func(tx *repository.Repository[Article]) error {
+		if err := tx.Create(ctx, &Article{Title: "tx"}); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("Transaction() error = %v", err)
+	}
+	if n, _ := repo.Count(ctx); n != 0 {
+		t.Errorf("Count() after rollback = %d, want 0", n)
+	}
+}
@@ -0,0 +1,93 @@
+package sharding
+
+import "strings"
+
+// rewriteTables 为语句中的逻辑表名追加后缀
+// 字符串字面量和注释中的内容保持不变，反引号、双引号包围的标识符同样会被改写；
+// 与逻辑表名相同的列名或别名也会被改写，因此逻辑表名不应与列名、别名重名
+func rewriteTables(query string, tables map[string]struct{}, suffix string) string {
+	if len(tables) == 0 || suffix == "" {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			j := skipQuoted(query, i, '\'')
+			b.WriteString(query[i:j])
+			i = j
+		case c == '`' || c == '"':
+			j := skipQuoted(query, i, c)
+			if j-i < 2 || query[j-1] != c {
+				// 未闭合的引号，原样输出
+				b.WriteString(query[i:j])
+			} else if _, ok := tables[query[i+1:j-1]]; ok {
+				b.WriteString(query[i : j-1])
+				b.WriteString(suffix)
+				b.WriteByte(c)
+			} else {
+				b.WriteString(query[i:j])
+			}
+			i = j
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#':
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				j = len(query) - i
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				j = len(query) - i
+			} else {
+				j += 4
+			}
+			b.WriteString(query[i : i+j])
+			i += j
+		case isIdentChar(c):
+			j := i
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			word := query[i:j]
+			b.WriteString(word)
+			if _, ok := tables[word]; ok {
+				b.WriteString(suffix)
+			}
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
+
+// skipQuoted 返回以 quote 开始的引用内容之后的位置，支持反斜杠转义和引号重复转义
+func skipQuoted(s string, start int, quote byte) int {
+	for i := start + 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '\'' {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+// isIdentChar 判断是否为未加引号的标识符字符
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
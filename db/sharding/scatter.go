@@ -0,0 +1,63 @@
+package sharding
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// Gather 并发地在每个分片上依次查询其全部槽位，用 scan 读取每一行，按槽位顺序合并结果
+// 同一时刻每个分片只占用一个连接；合并结果不做排序、去重或聚合，需要时由调用方处理
+// 任一槽位查询失败时返回合并的错误
+func Gather[T any](ctx context.Context, r *Router, query string, scan func(row db.Row) (T, error), args ...interface{}) ([]T, error) {
+	results := make([][]T, r.Slots())
+	errs := make([]error, len(r.shards))
+
+	var wg sync.WaitGroup
+	for i := range r.shards {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			for slot := shard * r.perShard; slot < (shard+1)*r.perShard; slot++ {
+				items, err := gatherSlot(ctx, r, target{client: r.shards[shard], slot: slot}, query, scan, args)
+				if err != nil {
+					errs[shard] = errors.Wrapf(err, "sharding: shard %d slot %d", shard, slot)
+					return
+				}
+				results[slot] = items
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	var merged []T
+	for _, items := range results {
+		merged = append(merged, items...)
+	}
+	return merged, nil
+}
+
+// gatherSlot 在单个槽位上执行查询并读取全部行
+func gatherSlot[T any](ctx context.Context, r *Router, t target, query string, scan func(row db.Row) (T, error), args []interface{}) ([]T, error) {
+	rows, err := t.client.Query(ctx, r.Rewrite(query, t.slot), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []T
+	for rows.Next() {
+		item, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
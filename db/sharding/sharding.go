@@ -0,0 +1,365 @@
+// Package sharding 提供多库分片路由，将水平拆分的表按分片键路由到多个 SQL 客户端
+//
+// 分片键通过 WithKey 放入 ctx，Router 实现 db.SQLClient，每次调用时按分片策略选出分片槽位：
+//
+//	router, _ := sharding.New([]db.SQLClient{m0, m1}, sharding.WithTables(4, "orders"))
+//	ctx = sharding.WithKey(ctx, userID)
+//	err := router.Exec(ctx, "INSERT INTO orders (user_id, amount) VALUES (?, ?)", userID, amount)
+//
+// 配置分表后共有 分片数 × 每分片表数 个槽位，槽位 i 位于第 i / 每分片表数 个分片，
+// 语句中的逻辑表名改写为带槽位后缀的物理表名（默认 "_%d"，如 orders_5）。
+// 不带分片键的查询可通过 Gather 在全部分片上并发执行并合并结果。
+// 分片客户端默认由调用方管理，Close 不关闭分片，配置 WithCloseShards 后由 Router 一并关闭。
+package sharding
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// 确保 Router 实现 db 包中的 SQL 客户端相关接口
+var (
+	_ db.SQLClient         = (*Router)(nil)
+	_ db.TxOptionsBeginner = (*Router)(nil)
+	_ db.RetryClassifier   = (*Router)(nil)
+	_ db.DialectProvider   = (*Router)(nil)
+
+	_ db.SavepointBeginner = (*shardTx)(nil)
+)
+
+// DefaultSuffixFormat 默认物理表名后缀格式
+const DefaultSuffixFormat = "_%d"
+
+// ErrNoShardKey ctx 中没有分片键
+var ErrNoShardKey = errors.New("sharding: no shard key in context")
+
+// keyContextKey ctx 中保存分片键的键
+type keyContextKey struct{}
+
+// WithKey 返回携带分片键的 ctx
+func WithKey(ctx context.Context, key interface{}) context.Context {
+	return context.WithValue(ctx, keyContextKey{}, key)
+}
+
+// KeyFrom 返回 ctx 中的分片键
+func KeyFrom(ctx context.Context) (interface{}, bool) {
+	key := ctx.Value(keyContextKey{})
+	return key, key != nil
+}
+
+// Router 分片路由
+type Router struct {
+	shards   []db.SQLClient
+	strategy Strategy
+	perShard int
+	tables   map[string]struct{}
+	suffix   string
+	owns     bool // Close 时是否关闭分片客户端
+}
+
+// Option 分片路由配置选项函数
+type Option func(*Router)
+
+// WithStrategy 设置分片策略，默认 Mod()
+func WithStrategy(s Strategy) Option {
+	return func(r *Router) {
+		r.strategy = s
+	}
+}
+
+// WithTables 设置每个分片上的分表数和需要改写的逻辑表名
+// perShard 为 1 时每个分片只有一张物理表，表名后缀即分片序号
+func WithTables(perShard int, tables ...string) Option {
+	return func(r *Router) {
+		r.perShard = perShard
+		for _, t := range tables {
+			r.tables[t] = struct{}{}
+		}
+	}
+}
+
+// WithSuffixFormat 设置物理表名后缀格式，参数为槽位序号，默认 "_%d"
+func WithSuffixFormat(format string) Option {
+	return func(r *Router) {
+		r.suffix = format
+	}
+}
+
+// WithCloseShards 设置 Close 时一并关闭全部分片客户端，默认由创建分片的调用方关闭
+func WithCloseShards() Option {
+	return func(r *Router) {
+		r.owns = true
+	}
+}
+
+// New 创建分片路由，shards 按分片序号排列
+func New(shards []db.SQLClient, opts ...Option) (*Router, error) {
+	if len(shards) == 0 {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "sharding: at least one shard is required")
+	}
+	for i, s := range shards {
+		if s == nil {
+			return nil, errors.Wrap(errors.ErrNilClient, fmt.Sprintf("sharding: shard %d", i))
+		}
+	}
+
+	r := &Router{
+		shards:   append([]db.SQLClient(nil), shards...),
+		strategy: Mod(),
+		perShard: 1,
+		tables:   make(map[string]struct{}),
+		suffix:   DefaultSuffixFormat,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.strategy == nil {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "sharding: strategy is required")
+	}
+	if r.perShard < 1 {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "sharding: tables per shard must be positive")
+	}
+	return r, nil
+}
+
+// Slots 返回槽位总数
+func (r *Router) Slots() int {
+	return len(r.shards) * r.perShard
+}
+
+// Shards 返回全部分片客户端
+func (r *Router) Shards() []db.SQLClient {
+	return append([]db.SQLClient(nil), r.shards...)
+}
+
+// Locate 返回分片键对应的分片序号和槽位序号
+func (r *Router) Locate(key interface{}) (shard, slot int, err error) {
+	slot, err = r.strategy.Slot(key, r.Slots())
+	if err != nil {
+		return 0, 0, err
+	}
+	if slot < 0 || slot >= r.Slots() {
+		return 0, 0, errors.Wrap(errors.ErrInvalidParameter,
+			fmt.Sprintf("sharding: strategy returned slot %d out of [0, %d)", slot, r.Slots()))
+	}
+	return slot / r.perShard, slot, nil
+}
+
+// Rewrite 将语句中的逻辑表名改写为指定槽位的物理表名
+func (r *Router) Rewrite(query string, slot int) string {
+	return rewriteTables(query, r.tables, fmt.Sprintf(r.suffix, slot))
+}
+
+// target 路由结果
+type target struct {
+	client db.SQLClient
+	slot   int
+}
+
+// route 按 ctx 中的分片键选出目标
+func (r *Router) route(ctx context.Context) (target, error) {
+	key, ok := KeyFrom(ctx)
+	if !ok {
+		return target{}, ErrNoShardKey
+	}
+	shard, slot, err := r.Locate(key)
+	if err != nil {
+		return target{}, err
+	}
+	return target{client: r.shards[shard], slot: slot}, nil
+}
+
+// targets 返回全部槽位，按槽位序号排列
+func (r *Router) targets() []target {
+	ts := make([]target, 0, r.Slots())
+	for slot := 0; slot < r.Slots(); slot++ {
+		ts = append(ts, target{client: r.shards[slot/r.perShard], slot: slot})
+	}
+	return ts
+}
+
+// Exec 在 ctx 中分片键对应的分片上执行语句
+func (r *Router) Exec(ctx context.Context, query string, args ...interface{}) error {
+	t, err := r.route(ctx)
+	if err != nil {
+		return err
+	}
+	return t.client.Exec(ctx, r.Rewrite(query, t.slot), args...)
+}
+
+// QueryRow 在 ctx 中分片键对应的分片上查询单行
+func (r *Router) QueryRow(ctx context.Context, query string, args ...interface{}) db.Row {
+	t, err := r.route(ctx)
+	if err != nil {
+		return errRow{err}
+	}
+	return t.client.QueryRow(ctx, r.Rewrite(query, t.slot), args...)
+}
+
+// Query 在 ctx 中分片键对应的分片上查询多行
+func (r *Router) Query(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
+	t, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return t.client.Query(ctx, r.Rewrite(query, t.slot), args...)
+}
+
+// Begin 在 ctx 中分片键对应的分片上开始事务，事务内的语句均按该槽位改写表名
+func (r *Router) Begin(ctx context.Context) (db.Tx, error) {
+	t, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &shardTx{tx: tx, router: r, slot: t.slot}, nil
+}
+
+// BeginTx 在 ctx 中分片键对应的分片上以指定选项开始事务
+func (r *Router) BeginTx(ctx context.Context, opts *sql.TxOptions) (db.Tx, error) {
+	t, err := r.route(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ob, ok := t.client.(db.TxOptionsBeginner)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "sharding: shard does not support transaction options")
+	}
+	tx, err := ob.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &shardTx{tx: tx, router: r, slot: t.slot}, nil
+}
+
+// IsRetryable 任一分片判断错误可重试时返回 true
+func (r *Router) IsRetryable(err error) bool {
+	for _, s := range r.shards {
+		if rc, ok := s.(db.RetryClassifier); ok && rc.IsRetryable(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping 检查全部分片的连接，合并返回失败分片的错误
+func (r *Router) Ping(ctx context.Context) error {
+	var errs []error
+	for i, s := range r.shards {
+		if err := s.Ping(ctx); err != nil {
+			errs = append(errs, errors.Wrapf(err, "shard %d", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// IsConnected 全部分片均已连接时返回 true
+func (r *Router) IsConnected() bool {
+	for _, s := range r.shards {
+		if !s.IsConnected() {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats 返回全部分片主库连接池统计之和，各分片的统计可通过 Shards() 分别获取
+func (r *Router) Stats() db.Stats {
+	var (
+		total sql.DBStats
+		found bool
+	)
+	for _, s := range r.shards {
+		st := s.Stats().SQL
+		if st == nil {
+			continue
+		}
+		found = true
+		total.MaxOpenConnections += st.MaxOpenConnections
+		total.OpenConnections += st.OpenConnections
+		total.InUse += st.InUse
+		total.Idle += st.Idle
+		total.WaitCount += st.WaitCount
+		total.WaitDuration += st.WaitDuration
+		total.MaxIdleClosed += st.MaxIdleClosed
+		total.MaxIdleTimeClosed += st.MaxIdleTimeClosed
+		total.MaxLifetimeClosed += st.MaxLifetimeClosed
+	}
+	if !found {
+		return db.Stats{}
+	}
+	return db.Stats{SQL: &total}
+}
+
+// Close 配置 WithCloseShards 时关闭全部分片并合并返回关闭错误，否则不做处理
+func (r *Router) Close() error {
+	if !r.owns {
+		return nil
+	}
+	var errs []error
+	for i, s := range r.shards {
+		if err := s.Close(); err != nil {
+			errs = append(errs, errors.Wrapf(err, "shard %d", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dialect 返回第一个分片的 SQL 方言
+func (r *Router) Dialect() db.Dialect {
+	if dp, ok := r.shards[0].(db.DialectProvider); ok {
+		return dp.Dialect()
+	}
+	return ""
+}
+
+// shardTx 固定在某个槽位上的事务
+type shardTx struct {
+	tx     db.Tx
+	router *Router
+	slot   int
+}
+
+func (t *shardTx) Commit() error   { return t.tx.Commit() }
+func (t *shardTx) Rollback() error { return t.tx.Rollback() }
+
+func (t *shardTx) Begin(ctx context.Context) (db.Tx, error) {
+	sb, ok := t.tx.(db.SavepointBeginner)
+	if !ok {
+		return nil, errors.Wrapf(errors.ErrInvalidParameter, "sharding: %T does not support nested transactions", t.tx)
+	}
+	tx, err := sb.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &shardTx{tx: tx, router: t.router, slot: t.slot}, nil
+}
+
+func (t *shardTx) Exec(ctx context.Context, query string, args ...interface{}) error {
+	return t.tx.Exec(ctx, t.router.Rewrite(query, t.slot), args...)
+}
+
+func (t *shardTx) QueryRow(ctx context.Context, query string, args ...interface{}) db.Row {
+	return t.tx.QueryRow(ctx, t.router.Rewrite(query, t.slot), args...)
+}
+
+func (t *shardTx) Query(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
+	return t.tx.Query(ctx, t.router.Rewrite(query, t.slot), args...)
+}
+
+// errRow 只返回错误的 db.Row
+type errRow struct {
+	err error
+}
+
+func (r errRow) Scan(dest ...interface{}) error {
+	return r.err
+}
@@ -0,0 +1,203 @@
+package sharding
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// newTestRouter 创建 2 个分片、每分片 2 张 orders 表的路由
+func newTestRouter(t *testing.T) (*Router, []*sqlite.Client) {
+	t.Helper()
+
+	ctx := context.Background()
+	clients := make([]*sqlite.Client, 2)
+	shards := make([]db.SQLClient, 2)
+	for i := range clients {
+		c, err := sqlite.NewClient(&sqlite.Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		for slot := i * 2; slot < i*2+2; slot++ {
+			if err := c.Exec(ctx, fmt.Sprintf("CREATE TABLE orders_%d (user_id INTEGER, amount INTEGER)", slot)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		clients[i], shards[i] = c, c
+	}
+
+	r, err := New(shards, WithTables(2, "orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, clients
+}
+
+func TestRoutedExecAndQuery(t *testing.T) {
+	r, clients := newTestRouter(t)
+	ctx := context.Background()
+
+	for user := 1; user <= 8; user++ {
+		uctx := WithKey(ctx, user)
+		if err := r.Exec(uctx, "INSERT INTO orders (user_id, amount) VALUES (?, ?)", user, user*10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 用户 5 位于槽位 1，即分片 0 的 orders_1
+	var amount int
+	if err := clients[0].QueryRow(ctx, "SELECT amount FROM orders_1 WHERE user_id = 5").Scan(&amount); err != nil || amount != 50 {
+		t.Fatalf("orders_1 amount = %d, %v", amount, err)
+	}
+	if err := r.QueryRow(WithKey(ctx, 5), "SELECT amount FROM `orders` WHERE user_id = ?", 5).Scan(&amount); err != nil || amount != 50 {
+		t.Fatalf("routed QueryRow() = %d, %v", amount, err)
+	}
+
+	if err := r.Exec(ctx, "DELETE FROM orders"); !errors.Is(err, ErrNoShardKey) {
+		t.Errorf("Exec() without key = %v, want ErrNoShardKey", err)
+	}
+
+	err := db.WithTx(WithKey(ctx, 3), r, func(tx db.Tx) error {
+		return tx.Exec(ctx, "UPDATE orders SET amount = amount + 1 WHERE user_id = 3")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := clients[1].QueryRow(ctx, "SELECT amount FROM orders_3 WHERE user_id = 3").Scan(&amount); err != nil || amount != 31 {
+		t.Fatalf("orders_3 amount = %d, %v", amount, err)
+	}
+}
+
+func TestScatterGather(t *testing.T) {
+	r, _ := newTestRouter(t)
+	ctx := context.Background()
+
+	for user := 1; user <= 8; user++ {
+		if err := r.Exec(WithKey(ctx, user), "INSERT INTO orders (user_id, amount) VALUES (?, ?)", user, user); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, err := Gather(ctx, r, "SELECT user_id FROM orders WHERE amount > ?", func(row db.Row) (int, error) {
+		var u int
+		return u, row.Scan(&u)
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 槽位顺序：orders_0(4, 8)、orders_1(5)、orders_2(6)、orders_3(3, 7)
+	if fmt.Sprint(users) != "[4 8 5 6 3 7]" {
+		t.Errorf("Gather() = %v", users)
+	}
+
+	sums, err := Gather(ctx, r, "SELECT COALESCE(SUM(amount), 0) FROM orders", func(row db.Row) (int, error) {
+		var n int
+		return n, row.Scan(&n)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, n := range sums {
+		total += n
+	}
+	if len(sums) != 4 || total != 36 {
+		t.Errorf("Gather() = %v", sums)
+	}
+
+	if _, err := Gather(ctx, r, "SELECT * FROM missing", func(row db.Row) (int, error) { return 0, nil }); err == nil {
+		t.Error("expected error for missing table")
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	mod := Mod()
+	if slot, _ := mod.Slot(-7, 4); slot != 3 {
+		t.Errorf("Mod(-7) = %d", slot)
+	}
+	a, _ := mod.Slot("user-1", 8)
+	b, _ := mod.Slot("user-1", 8)
+	if a != b {
+		t.Error("Mod() on strings must be deterministic")
+	}
+
+	rng := Range(100, 200)
+	for key, want := range map[int64]int{-1: 0, 99: 0, 100: 1, 199: 1, 200: 2, 1 << 40: 2} {
+		if slot, err := rng.Slot(key, 3); err != nil || slot != want {
+			t.Errorf("Range(%d) = %d, %v, want %d", key, slot, err, want)
+		}
+	}
+	if _, err := rng.Slot(1, 4); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("Range() bound mismatch error = %v", err)
+	}
+	if _, err := rng.Slot("x", 3); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("Range() string key error = %v", err)
+	}
+
+	// 一致性哈希在槽位数从 8 增加到 9 时只迁移少量键
+	ch := ConsistentHash(0)
+	moved, counts := 0, make([]int, 8)
+	for i := 0; i < 10000; i++ {
+		before, _ := ch.Slot(i, 8)
+		after, _ := ch.Slot(i, 9)
+		counts[before]++
+		if before != after {
+			moved++
+		}
+	}
+	if moved > 2000 {
+		t.Errorf("consistent hash moved %d of 10000 keys", moved)
+	}
+	sort.Ints(counts)
+	if counts[0] < 600 {
+		t.Errorf("consistent hash is unbalanced: %v", counts)
+	}
+}
+
+func TestRewriteTables(t *testing.T) {
+	tables := map[string]struct{}{"orders": {}}
+	tests := []struct{ in, want string }{
+		{"SELECT * FROM orders WHERE id = 1", "SELECT * FROM orders_3 WHERE id = 1"},
+		{"SELECT o.id FROM `orders` o JOIN orders_item i ON orders.id = i.oid", "SELECT o.id FROM `orders_3` o JOIN orders_item i ON orders_3.id = i.oid"},
+		{`SELECT 'orders', "orders" FROM orders -- orders`, `SELECT 'orders', "orders_3" FROM orders_3 -- orders`},
+		{`SELECT 'it''s orders\' orders' FROM shop.orders /* orders */`, `SELECT 'it''s orders\' orders' FROM shop.orders_3 /* orders */`},
+		{"SELECT `unterminated", "SELECT `unterminated"},
+	}
+	for _, tt := range tests {
+		if got := rewriteTables(tt.in, tables, "_3"); got != tt.want {
+			t.Errorf("rewriteTables(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCloseOwnership(t *testing.T) {
+	ctx := context.Background()
+	r, clients := newTestRouter(t)
+
+	// 默认不关闭调用方创建的分片
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := clients[0].Ping(ctx); err != nil {
+		t.Errorf("shard closed by Close() without WithCloseShards: %v", err)
+	}
+
+	owned, err := New(r.Shards(), WithCloseShards())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := owned.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range clients {
+		if err := c.Ping(ctx); !errors.Is(err, errors.ErrAlreadyClosed) {
+			t.Errorf("shard %d Ping() = %v, want ErrAlreadyClosed", i, err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package sharding
+
+import (
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// Strategy 分片策略，将分片键映射到 [0, n) 范围内的分片槽位
+type Strategy interface {
+	Slot(key interface{}, n int) (int, error)
+}
+
+// StrategyFunc 函数形式的 Strategy
+type StrategyFunc func(key interface{}, n int) (int, error)
+
+// Slot 调用 f(key, n)
+func (f StrategyFunc) Slot(key interface{}, n int) (int, error) {
+	return f(key, n)
+}
+
+// Mod 取模分片：整数键直接取模（负数取绝对值），其他键取 FNV-1a 哈希后取模
+func Mod() Strategy {
+	return StrategyFunc(func(key interface{}, n int) (int, error) {
+		if i, ok := toInt64(key); ok {
+			if i < 0 {
+				i = -i
+			}
+			return int(uint64(i) % uint64(n)), nil
+		}
+		s, err := keyString(key)
+		if err != nil {
+			return 0, err
+		}
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(s))
+		return int(h.Sum64() % uint64(n)), nil
+	})
+}
+
+// Range 范围分片，bounds 为升序的分界值，键只能是整数
+// 槽位 0 存放小于 bounds[0] 的键，槽位 i 存放 [bounds[i-1], bounds[i]) 的键，
+// 最后一个槽位存放不小于最后一个分界值的键，因此 bounds 的个数必须为槽位数减一
+func Range(bounds ...int64) Strategy {
+	return StrategyFunc(func(key interface{}, n int) (int, error) {
+		if len(bounds) != n-1 {
+			return 0, errors.Wrap(errors.ErrInvalidParameter,
+				fmt.Sprintf("sharding: range strategy has %d bounds for %d slots", len(bounds), n))
+		}
+		i, ok := toInt64(key)
+		if !ok {
+			return 0, errors.Wrap(errors.ErrInvalidParameter, fmt.Sprintf("sharding: range key must be an integer, got %T", key))
+		}
+		return sort.Search(len(bounds), func(j int) bool { return i < bounds[j] }), nil
+	})
+}
+
+// DefaultVirtualNodes 一致性哈希每个槽位默认的虚拟节点数
+const DefaultVirtualNodes = 160
+
+// ConsistentHash 一致性哈希分片，每个槽位在哈希环上有 virtualNodes 个虚拟节点，
+// 不大于 0 时使用默认值 160；槽位数变化时只有少量键会迁移
+func ConsistentHash(virtualNodes int) Strategy {
+	if virtualNodes <= 0 {
+		virtualNodes = DefaultVirtualNodes
+	}
+	ch := &consistentHash{virtualNodes: virtualNodes, rings: make(map[int]*ring)}
+	return StrategyFunc(ch.slot)
+}
+
+// ring 哈希环
+type ring struct {
+	hashes []uint32
+	slots  []int
+}
+
+// consistentHash 按槽位数缓存哈希环
+type consistentHash struct {
+	virtualNodes int
+	mu           sync.Mutex
+	rings        map[int]*ring
+}
+
+func (c *consistentHash) slot(key interface{}, n int) (int, error) {
+	s, err := keyString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	r := c.ring(n)
+	h := crc32.ChecksumIEEE([]byte(s))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.slots[i], nil
+}
+
+// ring 返回 n 个槽位的哈希环，首次使用时构建
+func (c *consistentHash) ring(n int) *ring {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if r, ok := c.rings[n]; ok {
+		return r
+	}
+
+	type node struct {
+		hash uint32
+		slot int
+	}
+	nodes := make([]node, 0, n*c.virtualNodes)
+	for slot := 0; slot < n; slot++ {
+		for v := 0; v < c.virtualNodes; v++ {
+			nodes = append(nodes, node{crc32.ChecksumIEEE([]byte(strconv.Itoa(slot) + "#" + strconv.Itoa(v))), slot})
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].hash < nodes[j].hash })
+
+	r := &ring{hashes: make([]uint32, len(nodes)), slots: make([]int, len(nodes))}
+	for i, nd := range nodes {
+		r.hashes[i], r.slots[i] = nd.hash, nd.slot
+	}
+	c.rings[n] = r
+	return r
+}
+
+// toInt64 将整数类型的键转换为 int64
+func toInt64(key interface{}) (int64, bool) {
+	switch v := key.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// keyString 将键转换为字符串，用于哈希
+func keyString(key interface{}) (string, error) {
+	if i, ok := toInt64(key); ok {
+		return strconv.FormatInt(i, 10), nil
+	}
+	switch v := key.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case nil:
+		return "", errors.Wrap(errors.ErrInvalidParameter, "sharding: shard key is nil")
+	default:
+		return "", errors.Wrap(errors.ErrInvalidParameter, fmt.Sprintf("sharding: unsupported shard key type %T", key))
+	}
+}
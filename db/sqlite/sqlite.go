@@ -0,0 +1,348 @@
+// Package sqlite 提供基于纯 Go 驱动的 SQLite 客户端，适用于单元测试和单机部署
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/internal/sqlcore"
+	"github.com/hyperits/gosuite/errors"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+// 确保 Client 实现 db 包中的 SQL 客户端相关接口
+var (
+	_ db.SQLClient         = (*Client)(nil)
+	_ db.TxOptionsBeginner = (*Client)(nil)
+	_ db.RetryClassifier   = (*Client)(nil)
+	_ db.DialectProvider   = (*Client)(nil)
+)
+
+// MemoryPath 内存数据库路径
+const MemoryPath = ":memory:"
+
+// Config SQLite 数据库配置
+type Config struct {
+	Path        string        // 数据库文件路径或 "file:" URI（可带查询参数），为空或 ":memory:" 表示内存数据库
+	BusyTimeout time.Duration // 数据库被锁定时的等待时间，默认 5 秒
+	ForeignKeys bool          // 是否启用外键约束
+	JournalMode string        // 日志模式，如 "WAL"、"DELETE"，默认使用 SQLite 默认值
+
+	// 连接池配置（内存数据库固定为单连接，忽略以下配置）
+	// 单连接下事务持有连接直到结束，期间客户端上的 Exec/Query 和后台健康检查都会阻塞等待，
+	// 需要并发访问时请使用文件数据库
+	MaxOpenConns    int           // 最大打开连接数，默认 25
+	MaxIdleConns    int           // 最大空闲连接数，默认 10
+	ConnMaxLifetime time.Duration // 连接最大生命周期，默认 5 分钟
+	ConnMaxIdleTime time.Duration // 空闲连接最大生命周期，默认 5 分钟
+
+	// 健康检查配置
+	HealthCheckInterval time.Duration // 后台健康检查间隔，默认 10 秒
+	HealthCheckTimeout  time.Duration // 单次健康检查超时，默认 3 秒
+
+	// Hooks 包围 Exec/Query/QueryRow/Begin 及事务内操作的钩子，创建后可通过 AddHook 追加
+	Hooks []db.Hook
+
+	// gorm 配置
+	NamingStrategy         schema.Namer         // 命名策略，默认单数表名
+	PrepareStmt            bool                 // 缓存 gorm 的预编译语句
+	SkipDefaultTransaction bool                 // 跳过 gorm 写操作的默认事务
+	DryRun                 bool                 // 只生成 SQL 不执行，仅影响通过 DB() 进行的 gorm 操作
+	Logger                 gormlogger.Interface // gorm 日志，默认通过 gormlog 写入 gosuite logger
+}
+
+// Validate 验证配置是否有效
+func (c *Config) Validate() error {
+	if c.BusyTimeout < 0 {
+		return errors.New("sqlite: busy timeout must not be negative")
+	}
+	switch strings.ToUpper(c.JournalMode) {
+	case "", "DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF":
+	default:
+		return errors.New("sqlite: unknown journal mode " + c.JournalMode)
+	}
+	return nil
+}
+
+// IsMemory 判断是否为内存数据库
+func (c *Config) IsMemory() bool {
+	return c.Path == "" || c.Path == MemoryPath
+}
+
+// Client SQLite 数据库客户端
+type Client struct {
+	db      *gorm.DB
+	sqlDB   *sql.DB
+	conf    *Config
+	watcher *db.Watcher
+	hooks   *sqlcore.Hooks
+	closed  bool
+	mu      sync.RWMutex
+}
+
+// NewClient 创建一个新的 SQLite 客户端实例
+func NewClient(conf *Config) (*Client, error) {
+	if conf == nil {
+		return nil, errors.ErrNilConfig
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	gormDB, err := connect(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlite.connect")
+	}
+
+	return &Client{
+		db:    gormDB,
+		sqlDB: sqlDB,
+		conf:  conf,
+		hooks: sqlcore.NewHooks("sqlite", conf.Hooks...),
+		watcher: db.NewWatcher("sqlite", sqlDB.PingContext,
+			db.WithCheckInterval(conf.HealthCheckInterval),
+			db.WithCheckTimeout(conf.HealthCheckTimeout)),
+	}, nil
+}
+
+// DB 返回底层的 gorm 数据库连接
+func (c *Client) DB() *gorm.DB {
+	return c.db
+}
+
+// GetConfig 返回 SQLite 配置信息
+func (c *Client) GetConfig() *Config {
+	return c.conf
+}
+
+// Close 关闭数据库连接，内存数据库的数据随之释放
+func (c *Client) Close() error {
+	c.watcher.Stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return errors.ErrAlreadyClosed
+	}
+
+	c.closed = true
+	return c.sqlDB.Close()
+}
+
+// Ping 测试数据库连接
+func (c *Client) Ping(ctx context.Context) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return errors.ErrAlreadyClosed
+	}
+
+	return c.sqlDB.PingContext(ctx)
+}
+
+// IsConnected 返回后台健康检查缓存的连接状态
+func (c *Client) IsConnected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return false
+	}
+
+	return c.watcher.Connected()
+}
+
+// AddHook 追加操作钩子
+func (c *Client) AddHook(hook db.Hook) {
+	c.hooks.Add(hook)
+}
+
+// Watcher 返回后台健康检查器，可用于订阅连接状态变化
+func (c *Client) Watcher() *db.Watcher {
+	return c.watcher
+}
+
+// Stats 返回连接池统计
+func (c *Client) Stats() db.Stats {
+	stats := c.sqlDB.Stats()
+	return db.Stats{SQL: &stats}
+}
+
+// Exec 执行 SQL 语句
+func (c *Client) Exec(ctx context.Context, query string, args ...interface{}) error {
+	sqlDB, err := c.conn()
+	if err != nil {
+		return err
+	}
+	return sqlcore.Exec(ctx, sqlDB, c.hooks, "sqlite", query, args...)
+}
+
+// QueryRow 查询单行，客户端已关闭时 Scan 返回 errors.ErrAlreadyClosed
+func (c *Client) QueryRow(ctx context.Context, query string, args ...interface{}) db.Row {
+	sqlDB, err := c.conn()
+	if err != nil {
+		return sqlcore.ErrRow{Err: err}
+	}
+	return sqlcore.QueryRow(ctx, sqlDB, c.hooks, query, args...)
+}
+
+// Query 查询多行，调用方需关闭返回的 Rows
+func (c *Client) Query(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
+	sqlDB, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	return sqlcore.Query(ctx, sqlDB, c.hooks, "sqlite", query, args...)
+}
+
+// Begin 开始事务
+// 内存数据库为单连接，事务未结束前在客户端（而非事务）上执行的语句会阻塞
+func (c *Client) Begin(ctx context.Context) (db.Tx, error) {
+	sqlDB, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	return sqlcore.Begin(ctx, sqlDB, c.hooks, "sqlite", nil)
+}
+
+// BeginTx 以指定隔离级别和只读属性开始事务
+func (c *Client) BeginTx(ctx context.Context, opts *sql.TxOptions) (db.Tx, error) {
+	sqlDB, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+	return sqlcore.Begin(ctx, sqlDB, c.hooks, "sqlite", opts)
+}
+
+// IsRetryable 判断事务错误是否可重试（SQLITE_BUSY）
+func (c *Client) IsRetryable(err error) bool {
+	var coder interface{ Code() int }
+	return errors.As(err, &coder) && coder.Code()&0xff == 5
+}
+
+// Dialect 返回 SQL 方言
+func (c *Client) Dialect() db.Dialect {
+	return db.DialectSQLite
+}
+
+// conn 返回底层连接池，客户端已关闭时返回 errors.ErrAlreadyClosed
+func (c *Client) conn() (*sql.DB, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.closed {
+		return nil, errors.ErrAlreadyClosed
+	}
+	return c.sqlDB, nil
+}
+
+// connect 打开 SQLite 数据库并返回数据库连接
+func connect(conf *Config) (*gorm.DB, error) {
+	gormDB, err := gorm.Open(sqlite.Open(buildDSN(conf)), conf.gormOptions().Config())
+	if err != nil {
+		return nil, fmt.Errorf("connect sqlite failed: %w", err)
+	}
+
+	// 配置连接池
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, fmt.Errorf("get underlying sql.DB failed: %w", err)
+	}
+
+	configurePool(sqlDB, conf)
+
+	return gormDB, nil
+}
+
+// gormOptions 返回 gorm 配置项
+func (c *Config) gormOptions() sqlcore.GormOptions {
+	return sqlcore.GormOptions{
+		NamingStrategy:         c.NamingStrategy,
+		PrepareStmt:            c.PrepareStmt,
+		SkipDefaultTransaction: c.SkipDefaultTransaction,
+		DryRun:                 c.DryRun,
+		Logger:                 c.Logger,
+	}
+}
+
+// buildDSN 构建 DSN，通过 _pragma 参数在每个连接上设置 PRAGMA
+func buildDSN(conf *Config) string {
+	path := conf.Path
+	if conf.IsMemory() {
+		path = MemoryPath
+	}
+
+	busyTimeout := conf.BusyTimeout
+	if busyTimeout == 0 {
+		busyTimeout = 5 * time.Second
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()))
+	if conf.ForeignKeys {
+		params.Add("_pragma", "foreign_keys(1)")
+	}
+	if conf.JournalMode != "" {
+		params.Add("_pragma", "journal_mode("+strings.ToUpper(conf.JournalMode)+")")
+	}
+
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + params.Encode()
+}
+
+// configurePool 设置连接池参数（使用默认值或配置值）
+// 内存数据库的每个连接都是独立的数据库，因此固定使用一个永不过期的连接，
+// 事务进行中其他操作（包括健康检查的 Ping）需等待事务结束
+func configurePool(sqlDB *sql.DB, conf *Config) {
+	if conf.IsMemory() {
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+		return
+	}
+
+	maxOpenConns := conf.MaxOpenConns
+	if maxOpenConns <= 0 {
+		maxOpenConns = 25
+	}
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+
+	maxIdleConns := conf.MaxIdleConns
+	if maxIdleConns <= 0 {
+		maxIdleConns = 10
+	}
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+
+	connMaxLifetime := conf.ConnMaxLifetime
+	if connMaxLifetime <= 0 {
+		connMaxLifetime = 5 * time.Minute
+	}
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+
+	connMaxIdleTime := conf.ConnMaxIdleTime
+	if connMaxIdleTime <= 0 {
+		connMaxIdleTime = 5 * time.Minute
+	}
+	sqlDB.SetConnMaxIdleTime(connMaxIdleTime)
+}
@@ -0,0 +1,164 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/db/sqlite"
+	"github.com/hyperits/gosuite/errors"
+)
+
+func newTestClient(t *testing.T) *sqlite.Client {
+	t.Helper()
+
+	client, err := sqlite.NewClient(&sqlite.Config{ForeignKeys: true})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	if err := client.Exec(context.Background(), "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("create table failed: %v", err)
+	}
+	return client
+}
+
+func countUsers(t *testing.T, client db.SQLClient) int {
+	t.Helper()
+
+	var n int
+	if err := client.QueryRow(context.Background(), "SELECT COUNT(*) FROM users").Scan(&n); err != nil {
+		t.Fatalf("count users failed: %v", err)
+	}
+	return n
+}
+
+func TestExecAndQuery(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"alice", "bob"} {
+		if err := client.Exec(ctx, "INSERT INTO users (name) VALUES (?)", name); err != nil {
+			t.Fatalf("Exec() returned error: %v", err)
+		}
+	}
+
+	rows, err := client.Query(ctx, "SELECT name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query() returned error: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("Scan() returned error: %v", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("Rows.Err() returned error: %v", err)
+	}
+	if len(names) != 2 || names[0] != "alice" || names[1] != "bob" {
+		t.Fatalf("Query() returned %v, want [alice bob]", names)
+	}
+}
+
+func TestWithTxSavepoint(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		if err := tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "outer"); err != nil {
+			return err
+		}
+		_ = db.WithTx(ctx, tx, func(inner db.Tx) error {
+			if err := inner.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "inner"); err != nil {
+				return err
+			}
+			return errors.New("rollback inner")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTx() returned error: %v", err)
+	}
+	if n := countUsers(t, client); n != 1 {
+		t.Fatalf("users = %d, want 1", n)
+	}
+
+	err = db.WithTx(ctx, client, func(tx db.Tx) error {
+		if err := tx.Exec(ctx, "INSERT INTO users (name) VALUES (?)", "rolled back"); err != nil {
+			return err
+		}
+		return errors.New("rollback outer")
+	})
+	if err == nil {
+		t.Fatal("WithTx() should return fn error")
+	}
+	if n := countUsers(t, client); n != 1 {
+		t.Fatalf("users = %d, want 1", n)
+	}
+}
+
+func TestClosedClient(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	if err := client.Close(); err != nil {
+		t.Fatalf("Close() returned error: %v", err)
+	}
+	if client.IsConnected() {
+		t.Error("IsConnected() = true after Close()")
+	}
+	if err := client.Exec(ctx, "SELECT 1"); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Exec() error = %v, want ErrAlreadyClosed", err)
+	}
+	var n int
+	if err := client.QueryRow(ctx, "SELECT 1").Scan(&n); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("QueryRow().Scan() error = %v, want ErrAlreadyClosed", err)
+	}
+	if _, err := client.Begin(ctx); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Begin() error = %v, want ErrAlreadyClosed", err)
+	}
+	if err := client.Close(); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("second Close() error = %v, want ErrAlreadyClosed", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	client := newTestClient(t)
+
+	stats := client.Stats()
+	if stats.SQL == nil {
+		t.Fatal("expected SQL stats")
+	}
+	if stats.SQL.MaxOpenConnections != 1 {
+		t.Errorf("MaxOpenConnections = %d, want 1 for memory database", stats.SQL.MaxOpenConnections)
+	}
+	if stats.Redis != nil || stats.Replicas != nil {
+		t.Errorf("unexpected non-SQL stats: %+v", stats)
+	}
+}
+
+func TestURIPathWithQuery(t *testing.T) {
+	path := "file:" + filepath.Join(t.TempDir(), "uri.db") + "?mode=rwc"
+	client, err := sqlite.NewClient(&sqlite.Config{Path: path, ForeignKeys: true, BusyTimeout: 1234 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewClient() returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+
+	ctx := context.Background()
+	var fk, busy int
+	if err := client.QueryRow(ctx, "PRAGMA foreign_keys").Scan(&fk); err != nil || fk != 1 {
+		t.Errorf("foreign_keys = %d, %v, want 1", fk, err)
+	}
+	if err := client.QueryRow(ctx, "PRAGMA busy_timeout").Scan(&busy); err != nil || busy != 1234 {
+		t.Errorf("busy_timeout = %d, %v, want 1234", busy, err)
+	}
+}
@@ -0,0 +1,20 @@
+package db
+
+import "database/sql"
+
+// Stats 客户端连接池统计
+type Stats struct {
+	SQL      *sql.DBStats           // SQL 客户端主库连接池，非 SQL 客户端为 nil
+	Replicas map[string]sql.DBStats // 只读副本连接池，按副本标识（host:port）索引，未配置副本时为 nil
+	Redis    *RedisPoolStats        // Redis 连接池，非 Redis 客户端为 nil
+}
+
+// RedisPoolStats Redis 连接池统计，集群模式下为全部节点之和
+type RedisPoolStats struct {
+	Hits       uint32 // 从池中取到空闲连接的次数
+	Misses     uint32 // 池中无空闲连接、需新建连接的次数
+	Timeouts   uint32 // 等待连接超时的次数
+	TotalConns uint32 // 当前连接总数
+	IdleConns  uint32 // 当前空闲连接数
+	StaleConns uint32 // 因失效被移出连接池的连接数
+}
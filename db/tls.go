@@ -0,0 +1,56 @@
+package db
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// TLSConfig SQL 客户端 TLS 配置
+type TLSConfig struct {
+	CAFile             string      // CA 证书文件（PEM），为空则使用系统根证书
+	CertFile           string      // 客户端证书文件（PEM），双向认证时使用
+	KeyFile            string      // 客户端私钥文件（PEM），双向认证时使用
+	ServerName         string      // 校验的服务器名称，默认使用连接地址的主机名
+	InsecureSkipVerify bool        // 是否跳过服务端证书校验，仅用于测试
+	Config             *tls.Config // 自定义 tls.Config，上述文件配置叠加在其副本上
+}
+
+// Build 构建 *tls.Config
+func (c *TLSConfig) Build() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.Config != nil {
+		cfg = c.Config.Clone()
+	}
+
+	if c.ServerName != "" {
+		cfg.ServerName = c.ServerName
+	}
+	if c.InsecureSkipVerify {
+		cfg.InsecureSkipVerify = true
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "db.tls: read ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("db.tls: no certificate found in ca file " + c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "db.tls: load key pair")
+		}
+		cfg.Certificates = append(cfg.Certificates, cert)
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// TxBeginner 可开启顶层事务的对象，SQLClient 均满足
+type TxBeginner interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// SavepointBeginner 可基于保存点开启嵌套事务的 Tx
+// MySQL、PostgreSQL、SQLite 和分片路由返回的 Tx 均实现此接口
+type SavepointBeginner interface {
+	Begin(ctx context.Context) (Tx, error)
+}
+
+// TxOptionsBeginner 支持指定隔离级别和只读属性开启事务
+type TxOptionsBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
+}
+
+// RetryClassifier 判断事务错误是否可通过重试解决
+// 如 MySQL 死锁（1213）、PostgreSQL 序列化失败（40001）
+type RetryClassifier interface {
+	IsRetryable(err error) bool
+}
+
+// 事务重试默认配置
+const (
+	DefaultTxRetryBackoff    = 10 * time.Millisecond // 首次重试等待时间，之后每次翻倍
+	DefaultTxMaxRetryBackoff = time.Second           // 单次重试最大等待时间
+)
+
+// TxOptions 事务配置
+type TxOptions struct {
+	Isolation    sql.IsolationLevel // 隔离级别，默认使用数据库默认级别
+	ReadOnly     bool               // 是否只读
+	MaxRetries   int                // 可重试错误的最大重试次数，默认 0 不重试
+	RetryBackoff time.Duration      // 首次重试等待时间，默认 10 毫秒
+	RetryIf      func(error) bool   // 自定义可重试错误判断，默认使用 RetryClassifier
+}
+
+// TxOption 事务配置选项函数
+type TxOption func(*TxOptions)
+
+// WithIsolation 设置事务隔离级别
+func WithIsolation(level sql.IsolationLevel) TxOption {
+	return func(opts *TxOptions) {
+		opts.Isolation = level
+	}
+}
+
+// WithReadOnly 设置只读事务
+func WithReadOnly() TxOption {
+	return func(opts *TxOptions) {
+		opts.ReadOnly = true
+	}
+}
+
+// WithRetry 设置可重试错误（死锁、序列化失败）的最大重试次数
+func WithRetry(maxRetries int) TxOption {
+	return func(opts *TxOptions) {
+		opts.MaxRetries = maxRetries
+	}
+}
+
+// WithRetryBackoff 设置首次重试等待时间
+func WithRetryBackoff(backoff time.Duration) TxOption {
+	return func(opts *TxOptions) {
+		opts.RetryBackoff = backoff
+	}
+}
+
+// WithRetryIf 设置自定义可重试错误判断
+func WithRetryIf(fn func(error) bool) TxOption {
+	return func(opts *TxOptions) {
+		opts.RetryIf = fn
+	}
+}
+
+// WithTx 在事务中执行 fn
+// fn 返回 nil 时提交事务，返回错误或 panic 时回滚（panic 会在回滚后重新抛出）
+// b 为 TxBeginner（如 SQLClient）时开启顶层事务；
+// b 为 Tx 时（如在 fn 内传入 tx）基于保存点嵌套执行，要求其实现 SavepointBeginner，
+// 隔离级别和重试配置仅对顶层事务生效
+// 顶层事务遇到可重试错误时按指数退避重新执行 fn，因此 fn 应当可重复执行
+func WithTx(ctx context.Context, b Execer, fn func(tx Tx) error, opts ...TxOption) error {
+	if b == nil {
+		return errors.ErrNilClient
+	}
+
+	o := &TxOptions{RetryBackoff: DefaultTxRetryBackoff}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if _, nested := b.(Tx); nested {
+		sb, ok := b.(SavepointBeginner)
+		if !ok {
+			return errors.Wrapf(errors.ErrInvalidParameter, "db.with_tx: %T does not support nested transactions", b)
+		}
+		return runTx(ctx, sb, fn, nil)
+	}
+
+	tb, ok := b.(TxBeginner)
+	if !ok {
+		return errors.Wrapf(errors.ErrInvalidParameter, "db.with_tx: %T cannot begin transactions", b)
+	}
+
+	var txOpts *sql.TxOptions
+	if o.Isolation != sql.LevelDefault || o.ReadOnly {
+		txOpts = &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+	}
+
+	backoff := o.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := runTx(ctx, tb, fn, txOpts)
+		if err == nil || attempt >= o.MaxRetries || !isRetryable(b, o, err) {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > DefaultTxMaxRetryBackoff {
+			backoff = DefaultTxMaxRetryBackoff
+		}
+	}
+}
+
+// runTx 开启一次事务并执行 fn
+func runTx(ctx context.Context, b TxBeginner, fn func(tx Tx) error, txOpts *sql.TxOptions) (err error) {
+	var tx Tx
+	if txOpts != nil {
+		ob, ok := b.(TxOptionsBeginner)
+		if !ok {
+			return errors.Wrap(errors.ErrInvalidParameter, "db.with_tx: isolation level and read-only are not supported")
+		}
+		tx, err = ob.BeginTx(ctx, txOpts)
+	} else {
+		tx, err = b.Begin(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// isRetryable 判断错误是否可重试
+func isRetryable(b Execer, o *TxOptions, err error) bool {
+	if o.RetryIf != nil {
+		return o.RetryIf(err)
+	}
+	if rc, ok := b.(RetryClassifier); ok {
+		return rc.IsRetryable(err)
+	}
+	return false
+}
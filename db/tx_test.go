@@ -0,0 +1,179 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+var errRetryable = errors.New("serialization failure")
+
+// fakeTx 记录提交和回滚次数的事务
+type fakeTx struct {
+	client    *fakeClient
+	nested    bool
+	committed bool
+	rolled    bool
+}
+
+func (t *fakeTx) Commit() error {
+	t.committed = true
+	if !t.nested {
+		t.client.commits++
+	}
+	return nil
+}
+
+func (t *fakeTx) Rollback() error {
+	t.rolled = true
+	if !t.nested {
+		t.client.rollbacks++
+	}
+	return nil
+}
+
+func (t *fakeTx) Begin(ctx context.Context) (db.Tx, error) {
+	t.client.savepoints++
+	return &fakeTx{client: t.client, nested: true}, nil
+}
+
+func (t *fakeTx) Exec(ctx context.Context, query string, args ...interface{}) error {
+	return nil
+}
+
+func (t *fakeTx) QueryRow(ctx context.Context, query string, args ...interface{}) db.Row {
+	return nil
+}
+
+func (t *fakeTx) Query(ctx context.Context, query string, args ...interface{}) (db.Rows, error) {
+	return nil, nil
+}
+
+// flatTx 不支持保存点的事务
+type flatTx struct{ fakeTx }
+
+func (t *flatTx) Begin() {}
+
+// fakeClient 实现 TxBeginner、TxOptionsBeginner 和 RetryClassifier
+type fakeClient struct {
+	begins     int
+	commits    int
+	rollbacks  int
+	savepoints int
+	lastOpts   *sql.TxOptions
+}
+
+func (c *fakeClient) Begin(ctx context.Context) (db.Tx, error) {
+	c.begins++
+	return &fakeTx{client: c}, nil
+}
+
+func (c *fakeClient) BeginTx(ctx context.Context, opts *sql.TxOptions) (db.Tx, error) {
+	c.lastOpts = opts
+	return c.Begin(ctx)
+}
+
+func (c *fakeClient) Exec(ctx context.Context, query string, args ...interface{}) error {
+	return nil
+}
+
+func (c *fakeClient) IsRetryable(err error) bool {
+	return errors.Is(err, errRetryable)
+}
+
+func TestWithTxCommitAndRollback(t *testing.T) {
+	client := &fakeClient{}
+	ctx := context.Background()
+
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error { return nil }); err != nil {
+		t.Fatalf("WithTx() returned error: %v", err)
+	}
+	if client.commits != 1 || client.rollbacks != 0 {
+		t.Fatalf("commits=%d rollbacks=%d, want 1 and 0", client.commits, client.rollbacks)
+	}
+
+	wantErr := errors.New("boom")
+	if err := db.WithTx(ctx, client, func(tx db.Tx) error { return wantErr }); !errors.Is(err, wantErr) {
+		t.Fatalf("WithTx() error = %v, want %v", err, wantErr)
+	}
+	if client.rollbacks != 1 {
+		t.Fatalf("rollbacks=%d, want 1", client.rollbacks)
+	}
+}
+
+func TestWithTxRollbackOnPanic(t *testing.T) {
+	client := &fakeClient{}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("WithTx() should re-panic")
+		}
+		if client.rollbacks != 1 {
+			t.Fatalf("rollbacks=%d, want 1", client.rollbacks)
+		}
+	}()
+
+	_ = db.WithTx(context.Background(), client, func(tx db.Tx) error {
+		panic("boom")
+	})
+}
+
+func TestWithTxNestedUnsupported(t *testing.T) {
+	var tx db.Tx = &flatTx{}
+	err := db.WithTx(context.Background(), tx, func(inner db.Tx) error { return nil })
+	if !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Fatalf("WithTx() error = %v, want ErrInvalidParameter", err)
+	}
+}
+
+func TestWithTxNested(t *testing.T) {
+	client := &fakeClient{}
+	ctx := context.Background()
+
+	err := db.WithTx(ctx, client, func(tx db.Tx) error {
+		_ = db.WithTx(ctx, tx, func(inner db.Tx) error {
+			return errors.New("inner failed")
+		})
+		return db.WithTx(ctx, tx, func(inner db.Tx) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("WithTx() returned error: %v", err)
+	}
+	if client.begins != 1 || client.savepoints != 2 || client.commits != 1 {
+		t.Fatalf("begins=%d savepoints=%d commits=%d, want 1, 2, 1", client.begins, client.savepoints, client.commits)
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	client := &fakeClient{}
+	calls := 0
+
+	err := db.WithTx(context.Background(), client, func(tx db.Tx) error {
+		calls++
+		if calls < 3 {
+			return errRetryable
+		}
+		return nil
+	}, db.WithRetry(3), db.WithRetryBackoff(0), db.WithIsolation(sql.LevelSerializable))
+	if err != nil {
+		t.Fatalf("WithTx() returned error: %v", err)
+	}
+	if calls != 3 || client.rollbacks != 2 || client.commits != 1 {
+		t.Fatalf("calls=%d rollbacks=%d commits=%d, want 3, 2, 1", calls, client.rollbacks, client.commits)
+	}
+	if client.lastOpts == nil || client.lastOpts.Isolation != sql.LevelSerializable {
+		t.Fatalf("isolation level not passed to BeginTx: %+v", client.lastOpts)
+	}
+
+	calls = 0
+	err = db.WithTx(context.Background(), client, func(tx db.Tx) error {
+		calls++
+		return errRetryable
+	}, db.WithRetry(1), db.WithRetryBackoff(0))
+	if !errors.Is(err, errRetryable) || calls != 2 {
+		t.Fatalf("WithTx() error = %v, calls = %d, want retryable error after 2 calls", err, calls)
+	}
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperits/gosuite/logger"
+)
+
+// 健康检查默认配置
+const (
+	DefaultHealthCheckInterval = 10 * time.Second
+	DefaultHealthCheckTimeout  = 3 * time.Second
+)
+
+// stateChangeBuffer 订阅通道的缓冲大小，订阅方消费不及时时丢弃事件
+const stateChangeBuffer = 16
+
+// State 连接状态
+type State string
+
+const (
+	// StateConnected 已连接
+	StateConnected State = "connected"
+	// StateDisconnected 连接断开
+	StateDisconnected State = "disconnected"
+)
+
+// StateChange 连接状态变化事件
+type StateChange struct {
+	From State     // 变化前状态
+	To   State     // 变化后状态
+	Err  error     // 断开时的健康检查错误，恢复时为 nil
+	At   time.Time // 发生时间
+}
+
+// Watcher 后台健康检查器
+// 按间隔执行带超时的 Ping 并缓存结果，连接状态变化时通知回调和订阅方
+type Watcher struct {
+	name           string
+	ping           func(ctx context.Context) error
+	interval       time.Duration
+	timeout        time.Duration
+	reconnectAfter time.Duration
+	reconnect      func(ctx context.Context) error
+
+	connected atomic.Bool
+	checkMu   sync.Mutex
+	downSince time.Time
+
+	mu        sync.Mutex
+	callbacks []func(StateChange)
+	subs      map[chan StateChange]struct{}
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// WatcherOption 健康检查器配置选项函数
+type WatcherOption func(*Watcher)
+
+// WithCheckInterval 设置健康检查间隔，默认 10 秒
+func WithCheckInterval(interval time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		if interval > 0 {
+			w.interval = interval
+		}
+	}
+}
+
+// WithCheckTimeout 设置单次健康检查超时，默认 3 秒
+func WithCheckTimeout(timeout time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		if timeout > 0 {
+			w.timeout = timeout
+		}
+	}
+}
+
+// WithReconnect 设置重连函数，连接持续断开超过 after 后调用，之后每隔 after 重试一次
+func WithReconnect(after time.Duration, fn func(ctx context.Context) error) WatcherOption {
+	return func(w *Watcher) {
+		w.reconnectAfter = after
+		w.reconnect = fn
+	}
+}
+
+// NewWatcher 创建健康检查器并启动后台检查，初始状态为已连接
+// name 用于日志，如 "mysql"
+func NewWatcher(name string, ping func(ctx context.Context) error, opts ...WatcherOption) *Watcher {
+	w := &Watcher{
+		name:     name,
+		ping:     ping,
+		interval: DefaultHealthCheckInterval,
+		timeout:  DefaultHealthCheckTimeout,
+		subs:     make(map[chan StateChange]struct{}),
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.connected.Store(true)
+
+	w.wg.Add(1)
+	go w.loop()
+
+	return w
+}
+
+// Connected 返回最近一次健康检查的结果
+func (w *Watcher) Connected() bool {
+	return w.connected.Load()
+}
+
+// State 返回当前连接状态
+func (w *Watcher) State() State {
+	if w.connected.Load() {
+		return StateConnected
+	}
+	return StateDisconnected
+}
+
+// OnStateChange 注册状态变化回调，回调在检查协程中同步执行，不应阻塞
+func (w *Watcher) OnStateChange(fn func(StateChange)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.callbacks = append(w.callbacks, fn)
+}
+
+// Subscribe 订阅状态变化事件，返回事件通道和取消订阅函数
+// 通道缓冲已满时丢弃新事件，取消订阅后通道被关闭
+func (w *Watcher) Subscribe() (<-chan StateChange, func()) {
+	ch := make(chan StateChange, stateChangeBuffer)
+
+	w.mu.Lock()
+	w.subs[ch] = struct{}{}
+	w.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			if _, ok := w.subs[ch]; ok {
+				delete(w.subs, ch)
+				close(ch)
+			}
+		})
+	}
+	return ch, cancel
+}
+
+// Check 立即执行一次健康检查并更新状态
+func (w *Watcher) Check(ctx context.Context) error {
+	w.checkMu.Lock()
+	defer w.checkMu.Unlock()
+
+	err := w.probe(ctx)
+	if err != nil && w.reconnect != nil {
+		if w.downSince.IsZero() {
+			w.downSince = time.Now()
+		} else if time.Since(w.downSince) >= w.reconnectAfter {
+			logger.Warnf("%s connection down since %v, reconnecting", w.name, w.downSince.Format(time.RFC3339))
+			// 无论成败都重新计时，避免每次检查都重建连接
+			w.downSince = time.Now()
+			if rerr := w.reconnect(ctx); rerr != nil {
+				logger.Errorf("%s reconnect failed: %v", w.name, rerr)
+			} else {
+				err = w.probe(ctx)
+			}
+		}
+	}
+	if err == nil {
+		w.downSince = time.Time{}
+	}
+
+	w.update(err)
+	return err
+}
+
+// Stop 停止后台检查并关闭全部订阅通道，可重复调用
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		w.wg.Wait()
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for ch := range w.subs {
+			delete(w.subs, ch)
+			close(ch)
+		}
+	})
+}
+
+// loop 定期执行健康检查
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			_ = w.Check(context.Background())
+		}
+	}
+}
+
+// probe 执行一次带超时的 Ping
+func (w *Watcher) probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+	return w.ping(ctx)
+}
+
+// update 更新连接状态，状态变化时记录日志并通知
+func (w *Watcher) update(err error) {
+	connected := err == nil
+	if w.connected.Swap(connected) == connected {
+		return
+	}
+
+	change := StateChange{From: StateConnected, To: StateDisconnected, Err: err, At: time.Now()}
+	if connected {
+		change.From, change.To = StateDisconnected, StateConnected
+		logger.Infof("%s connection recovered", w.name)
+	} else {
+		logger.Warnf("%s connection lost: %v", w.name, err)
+	}
+
+	w.mu.Lock()
+	callbacks := append([]func(StateChange){}, w.callbacks...)
+	for ch := range w.subs {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+	w.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(change)
+	}
+}
@@ -0,0 +1,124 @@
+package db_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/db"
+	"github.com/hyperits/gosuite/errors"
+)
+
+// fakePinger 可切换健康状态的 Ping
+type fakePinger struct {
+	mu  sync.Mutex
+	err error
+}
+
+func (p *fakePinger) set(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func (p *fakePinger) ping(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+func TestWatcherStateChanges(t *testing.T) {
+	p := &fakePinger{}
+	w := db.NewWatcher("test", p.ping, db.WithCheckInterval(time.Hour))
+	defer w.Stop()
+
+	var changes []db.StateChange
+	w.OnStateChange(func(c db.StateChange) { changes = append(changes, c) })
+	events, cancel := w.Subscribe()
+	defer cancel()
+
+	if !w.Connected() || w.State() != db.StateConnected {
+		t.Fatal("expected initial state connected")
+	}
+
+	down := errors.New("connection refused")
+	p.set(down)
+	if err := w.Check(context.Background()); err != down {
+		t.Fatalf("Check() = %v, want %v", err, down)
+	}
+	_ = w.Check(context.Background())
+	if w.Connected() {
+		t.Fatal("expected disconnected")
+	}
+
+	p.set(nil)
+	_ = w.Check(context.Background())
+	if !w.Connected() {
+		t.Fatal("expected reconnected")
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 state changes, got %d", len(changes))
+	}
+	if changes[0].To != db.StateDisconnected || changes[0].Err != down {
+		t.Errorf("unexpected first change %+v", changes[0])
+	}
+	if changes[1].From != db.StateDisconnected || changes[1].To != db.StateConnected {
+		t.Errorf("unexpected second change %+v", changes[1])
+	}
+
+	if got := (<-events).To; got != db.StateDisconnected {
+		t.Errorf("first event = %s", got)
+	}
+	if got := (<-events).To; got != db.StateConnected {
+		t.Errorf("second event = %s", got)
+	}
+}
+
+func TestWatcherReconnect(t *testing.T) {
+	p := &fakePinger{}
+	var reconnects atomic.Int32
+	w := db.NewWatcher("test", p.ping,
+		db.WithCheckInterval(time.Hour),
+		db.WithReconnect(0, func(ctx context.Context) error {
+			reconnects.Add(1)
+			p.set(nil)
+			return nil
+		}))
+	defer w.Stop()
+
+	p.set(errors.New("broken pipe"))
+	// 第一次失败只记录断开时间
+	if err := w.Check(context.Background()); err == nil {
+		t.Fatal("expected first check to fail")
+	}
+	// 超过断开时长后重建连接并重新检查
+	if err := w.Check(context.Background()); err != nil {
+		t.Fatalf("expected reconnect to recover, got %v", err)
+	}
+	if reconnects.Load() != 1 || !w.Connected() {
+		t.Fatalf("reconnects = %d, connected = %v", reconnects.Load(), w.Connected())
+	}
+}
+
+func TestWatcherBackgroundLoop(t *testing.T) {
+	p := &fakePinger{err: errors.New("down")}
+	w := db.NewWatcher("test", p.ping, db.WithCheckInterval(5*time.Millisecond))
+
+	deadline := time.Now().Add(time.Second)
+	for w.Connected() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if w.Connected() {
+		t.Fatal("expected background check to mark disconnected")
+	}
+
+	events, _ := w.Subscribe()
+	w.Stop()
+	w.Stop()
+	if _, ok := <-events; ok {
+		t.Error("expected subscription closed after Stop")
+	}
+}
@@ -0,0 +1,145 @@
+// Package eventbus 提供基于主题的发布订阅
+//
+// 订阅支持 Redis 风格的通配符（* 匹配任意字符，包括 "."；? 匹配单个字符；[abc] 匹配字符集）。
+// 每个订阅者有独立的 goroutine 和有界缓冲区，缓冲区满时按 Overflow 策略阻塞或丢弃，
+// 慢订阅者不会影响其他订阅者。Redis 实现基于 PUBLISH/PSUBSCRIBE，跨进程广播、不持久化；
+// 内存实现接口相同，适用于单进程应用和测试：
+//
+//	bus, _ := eventbus.NewRedis(redisClient)
+//	eventbus.Subscribe(ctx, bus, "order.*", func(ctx context.Context, topic string, o Order) error {
+//		return nil
+//	})
+//	eventbus.Publish(ctx, bus, "order.created", Order{ID: 1})
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+)
+
+// 订阅默认配置
+const (
+	DefaultBufferSize = 256
+)
+
+// Codec 事件负载的序列化方式，cache.JSON、cache.Msgpack 和 cache.Gob 均满足
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// JSON 使用 encoding/json 的默认编码
+var JSON Codec = jsonCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// Event 事件，Payload 在订阅者之间共享，处理函数不能修改
+type Event struct {
+	Topic   string
+	Payload []byte
+
+	codec Codec
+}
+
+// Decode 按事件总线的编码将负载解码到 v
+func (e *Event) Decode(v interface{}) error {
+	codec := e.codec
+	if codec == nil {
+		codec = JSON
+	}
+	return codec.Unmarshal(e.Payload, v)
+}
+
+// Handler 事件处理函数，返回的错误仅记录日志
+type Handler func(ctx context.Context, e *Event) error
+
+// Bus 事件总线
+type Bus interface {
+	// Publish 发布事件到主题
+	Publish(ctx context.Context, topic string, payload []byte) error
+
+	// Subscribe 订阅匹配 pattern 的主题，处理函数在订阅者自己的 goroutine 中依次调用
+	Subscribe(ctx context.Context, pattern string, h Handler, opts ...SubscribeOption) (*Subscription, error)
+
+	// Codec 返回负载编码，供 Publish 和 Subscribe 泛型函数使用
+	Codec() Codec
+
+	// Close 取消所有订阅并释放资源，与 Unsubscribe 一样不能在处理函数中同步调用
+	Close() error
+}
+
+// Overflow 订阅者缓冲区满时的处理策略
+type Overflow int
+
+const (
+	// Block 阻塞发布方直到缓冲区有空间，不丢失事件
+	// Redis 实现中会阻塞所有订阅者的接收，积压过多时 Redis 可能断开连接
+	Block Overflow = iota
+
+	// DropNewest 丢弃新到达的事件
+	DropNewest
+
+	// DropOldest 丢弃缓冲区中最早的事件
+	DropOldest
+)
+
+// SubscribeOption 订阅配置选项函数
+type SubscribeOption func(*subscribeOptions)
+
+// subscribeOptions 订阅配置
+type subscribeOptions struct {
+	bufferSize int
+	overflow   Overflow
+}
+
+// WithBufferSize 设置订阅者缓冲区大小，默认 256
+func WithBufferSize(n int) SubscribeOption {
+	return func(o *subscribeOptions) {
+		if n > 0 {
+			o.bufferSize = n
+		}
+	}
+}
+
+// WithOverflow 设置缓冲区满时的处理策略，默认 Block
+func WithOverflow(policy Overflow) SubscribeOption {
+	return func(o *subscribeOptions) {
+		o.overflow = policy
+	}
+}
+
+// newSubscribeOptions 返回应用选项后的订阅配置
+func newSubscribeOptions(opts []SubscribeOption) subscribeOptions {
+	o := subscribeOptions{bufferSize: DefaultBufferSize, overflow: Block}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Publish 按事件总线的编码序列化 v 并发布
+func Publish[T any](ctx context.Context, b Bus, topic string, v T) error {
+	data, err := b.Codec().Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "eventbus: encode "+topic)
+	}
+	return b.Publish(ctx, topic, data)
+}
+
+// Subscribe 订阅并将负载解码为 T，解码失败的事件记录日志后跳过
+func Subscribe[T any](ctx context.Context, b Bus, pattern string, fn func(ctx context.Context, topic string, v T) error, opts ...SubscribeOption) (*Subscription, error) {
+	return b.Subscribe(ctx, pattern, func(ctx context.Context, e *Event) error {
+		var v T
+		if err := e.Decode(&v); err != nil {
+			logger.Warnf("eventbus: skipping undecodable event on %s: %v", e.Topic, err)
+			return nil
+		}
+		return fn(ctx, e.Topic, v)
+	}, opts...)
+}
@@ -0,0 +1,367 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/internal/redistest"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, topic string
+		want           bool
+	}{
+		{"order.created", "order.created", true},
+		{"order.created", "order.updated", false},
+		{"order.*", "order.created", true},
+		{"order.*", "order.item.added", true},
+		{"order.*", "order", false},
+		{"*", "", true},
+		{"*.created", "user.created", true},
+		{"user.?", "user.1", true},
+		{"user.?", "user.12", false},
+		{"user.[0-9]", "user.7", true},
+		{"user.[0-9]", "user.a", false},
+		{"user.[^0-9]", "user.a", true},
+		{"user.[abc]x", "user.bx", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a**b", "ab", true},
+	}
+	for _, c := range cases {
+		if got := match(c.pattern, c.topic); got != c.want {
+			t.Errorf("match(%q, %q) = %v, want %v", c.pattern, c.topic, got, c.want)
+		}
+	}
+}
+
+type order struct {
+	ID int `json:"id"`
+}
+
+func TestMemoryPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	bus := NewMemory()
+	defer bus.Close()
+
+	var (
+		mu     sync.Mutex
+		topics []string
+		ids    []int
+	)
+	sub, err := Subscribe(ctx, bus, "order.*", func(ctx context.Context, topic string, o order) error {
+		mu.Lock()
+		defer mu.Unlock()
+		topics = append(topics, topic)
+		ids = append(ids, o.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	for i, topic := range []string{"order.created", "user.created", "order.paid"} {
+		if err := Publish(ctx, bus, topic, order{ID: i}); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	// 取消订阅会等待已缓冲的事件处理完毕
+	if err := sub.Unsubscribe(); err != nil {
+		t.Fatalf("Unsubscribe: %v", err)
+	}
+	if err := Publish(ctx, bus, "order.late", order{ID: 9}); err != nil {
+		t.Fatalf("Publish after unsubscribe: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(topics) != 2 || topics[0] != "order.created" || topics[1] != "order.paid" {
+		t.Errorf("topics = %v", topics)
+	}
+	if len(ids) != 2 || ids[0] != 0 || ids[1] != 2 {
+		t.Errorf("ids = %v", ids)
+	}
+}
+
+// blockingHandler 在 release 关闭前阻塞的处理函数
+func blockingHandler(started chan<- struct{}, release <-chan struct{}, got *[]string, mu *sync.Mutex) Handler {
+	var once sync.Once
+	return func(ctx context.Context, e *Event) error {
+		once.Do(func() { close(started) })
+		<-release
+		mu.Lock()
+		*got = append(*got, string(e.Payload))
+		mu.Unlock()
+		return nil
+	}
+}
+
+func TestMemoryOverflow(t *testing.T) {
+	cases := []struct {
+		policy  Overflow
+		want    []string
+		dropped uint64
+	}{
+		// 第 1 个事件被处理函数取走，缓冲区容量 2
+		{DropNewest, []string{"1", "2", "3"}, 2},
+		{DropOldest, []string{"1", "4", "5"}, 2},
+	}
+	for _, c := range cases {
+		bus := NewMemory()
+		started, release := make(chan struct{}), make(chan struct{})
+		var (
+			mu  sync.Mutex
+			got []string
+		)
+		sub, _ := bus.Subscribe(context.Background(), "t", blockingHandler(started, release, &got, &mu),
+			WithBufferSize(2), WithOverflow(c.policy))
+
+		bus.Publish(context.Background(), "t", []byte("1"))
+		<-started
+		for _, p := range []string{"2", "3", "4", "5"} {
+			bus.Publish(context.Background(), "t", []byte(p))
+		}
+		close(release)
+		sub.Unsubscribe()
+
+		if len(got) != len(c.want) {
+			t.Fatalf("policy %d: got %v, want %v", c.policy, got, c.want)
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("policy %d: got %v, want %v", c.policy, got, c.want)
+				break
+			}
+		}
+		if sub.Dropped() != c.dropped {
+			t.Errorf("policy %d: dropped = %d, want %d", c.policy, sub.Dropped(), c.dropped)
+		}
+		bus.Close()
+	}
+}
+
+func TestMemoryBlock(t *testing.T) {
+	bus := NewMemory()
+	defer bus.Close()
+
+	started, release := make(chan struct{}), make(chan struct{})
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	bus.Subscribe(context.Background(), "t", blockingHandler(started, release, &got, &mu), WithBufferSize(1))
+
+	bus.Publish(context.Background(), "t", []byte("1"))
+	<-started
+	bus.Publish(context.Background(), "t", []byte("2"))
+
+	// 缓冲区已满，发布阻塞直到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bus.Publish(ctx, "t", []byte("3")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Publish on full buffer err = %v", err)
+	}
+	close(release)
+}
+
+func TestMemoryClose(t *testing.T) {
+	bus := NewMemory()
+	handled := make(chan struct{}, 1)
+	bus.Subscribe(context.Background(), "t", func(ctx context.Context, e *Event) error {
+		panic("boom")
+	})
+	bus.Subscribe(context.Background(), "t", func(ctx context.Context, e *Event) error {
+		handled <- struct{}{}
+		return errors.New("ignored")
+	})
+	bus.Publish(context.Background(), "t", nil)
+
+	if err := bus.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case <-handled:
+	default:
+		t.Error("buffered event not handled before Close returned")
+	}
+	if err := bus.Publish(context.Background(), "t", nil); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Publish after Close err = %v", err)
+	}
+	if _, err := bus.Subscribe(context.Background(), "t", func(context.Context, *Event) error { return nil }); !errors.Is(err, errors.ErrAlreadyClosed) {
+		t.Errorf("Subscribe after Close err = %v", err)
+	}
+}
+
+// waitFor 等待条件成立
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestRedisPublishSubscribe(t *testing.T) {
+	ctx := context.Background()
+	client, mr := redistest.Run(t)
+	bus, _ := NewRedis(client, WithPrefix("app:"))
+	defer bus.Close()
+
+	got := make(chan string, 10)
+	handler := func(ctx context.Context, topic string, o order) error {
+		got <- topic
+		return nil
+	}
+	sub1, err := Subscribe(ctx, bus, "order.*", handler)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	sub2, _ := Subscribe(ctx, bus, "order.*", handler)
+	waitFor(t, "psubscribe", func() bool { return mr.PubSubNumPat() == 1 })
+
+	Publish(ctx, bus, "order.created", order{ID: 1})
+	Publish(ctx, bus, "user.created", order{ID: 2})
+	for i := 0; i < 2; i++ {
+		select {
+		case topic := <-got:
+			if topic != "order.created" {
+				t.Errorf("topic = %s", topic)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("event not delivered")
+		}
+	}
+
+	// 最后一个订阅者取消后发送 PUNSUBSCRIBE
+	sub1.Unsubscribe()
+	if n := mr.PubSubNumPat(); n != 1 {
+		t.Errorf("patterns after first unsubscribe = %d, want 1", n)
+	}
+	sub2.Unsubscribe()
+	waitFor(t, "punsubscribe", func() bool { return mr.PubSubNumPat() == 0 })
+}
+
+func TestRedisConcurrentSubscribe(t *testing.T) {
+	ctx := context.Background()
+	client, mr := redistest.Run(t)
+	bus, _ := NewRedis(client)
+	defer bus.Close()
+
+	// 并发订阅和取消同一模式，最终订阅连接上的模式与订阅者一致
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sub, err := bus.Subscribe(ctx, "t", func(context.Context, *Event) error { return nil })
+			if err != nil {
+				t.Errorf("Subscribe: %v", err)
+				return
+			}
+			sub.Unsubscribe()
+		}()
+	}
+	wg.Wait()
+	waitFor(t, "punsubscribe", func() bool { return mr.PubSubNumPat() == 0 })
+
+	got := make(chan string, 1)
+	if _, err := bus.Subscribe(ctx, "t", func(ctx context.Context, e *Event) error {
+		got <- e.Topic
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	waitFor(t, "psubscribe", func() bool { return mr.PubSubNumPat() == 1 })
+	bus.Publish(ctx, "t", nil)
+	select {
+	case <-got:
+	case <-time.After(3 * time.Second):
+		t.Fatal("event not delivered")
+	}
+}
+
+func TestRedisSubscribeError(t *testing.T) {
+	client, mr := redistest.Run(t)
+	bus, _ := NewRedis(client)
+	defer bus.Close()
+
+	mr.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if _, err := bus.Subscribe(ctx, "t", func(context.Context, *Event) error { return nil }); err == nil {
+		t.Fatal("Subscribe with redis down should fail")
+	}
+}
+
+func TestRedisResubscribe(t *testing.T) {
+	ctx := context.Background()
+	client, mr := redistest.Run(t)
+	bus, _ := NewRedis(client)
+	defer bus.Close()
+
+	got := make(chan string, 10)
+	if _, err := bus.Subscribe(ctx, "a", func(ctx context.Context, e *Event) error {
+		got <- e.Topic
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	// 重建底层客户端，旧客户端关闭导致订阅连接关闭
+	bus.mu.RLock()
+	oldPubSub := bus.pubsub
+	bus.mu.RUnlock()
+	client.Reconnect(mr.Addr())
+	waitFor(t, "resubscribe", func() bool {
+		bus.mu.RLock()
+		defer bus.mu.RUnlock()
+		return bus.pubsub != oldPubSub && mr.PubSubNumPat() == 1
+	})
+
+	// 新的订阅连接上可继续增加模式
+	if _, err := bus.Subscribe(ctx, "b", func(ctx context.Context, e *Event) error {
+		got <- e.Topic
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe after resubscribe: %v", err)
+	}
+	waitFor(t, "psubscribe", func() bool { return mr.PubSubNumPat() == 2 })
+	for _, topic := range []string{"a", "b"} {
+		bus.Publish(ctx, topic, nil)
+		select {
+		case got := <-got:
+			if got != topic {
+				t.Errorf("topic = %s, want %s", got, topic)
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("%s not delivered after resubscribe", topic)
+		}
+	}
+}
+
+func TestUnsubscribeFromHandler(t *testing.T) {
+	bus := NewMemory()
+	defer bus.Close()
+
+	var sub *Subscription
+	ready := make(chan struct{})
+	handled := make(chan struct{})
+	sub, _ = bus.Subscribe(context.Background(), "t", func(ctx context.Context, e *Event) error {
+		<-ready
+		go sub.Unsubscribe()
+		close(handled)
+		return nil
+	})
+	close(ready)
+	bus.Publish(context.Background(), "t", nil)
+	<-handled
+	// 处理函数返回后订阅退出，此处的同步调用不会阻塞
+	sub.Unsubscribe()
+}
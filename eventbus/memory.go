@@ -0,0 +1,110 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// 确保 Memory 实现 Bus 接口
+var _ Bus = (*Memory)(nil)
+
+// MemoryOption 内存事件总线配置选项函数
+type MemoryOption func(*Memory)
+
+// WithMemoryCodec 设置负载编码，默认 JSON
+func WithMemoryCodec(codec Codec) MemoryOption {
+	return func(m *Memory) {
+		m.codec = codec
+	}
+}
+
+// Memory 进程内事件总线
+type Memory struct {
+	codec Codec
+
+	mu     sync.RWMutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewMemory 创建进程内事件总线
+func NewMemory(opts ...MemoryOption) *Memory {
+	m := &Memory{codec: JSON, subs: make(map[*Subscription]struct{})}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Publish 将事件投递给所有匹配的订阅者
+// 订阅者使用 Block 策略且缓冲区已满时阻塞，直到有空间或 ctx 取消
+func (m *Memory) Publish(ctx context.Context, topic string, payload []byte) error {
+	m.mu.RLock()
+	if m.closed {
+		m.mu.RUnlock()
+		return errors.ErrAlreadyClosed
+	}
+	var targets []*Subscription
+	for s := range m.subs {
+		if match(s.pattern, topic) {
+			targets = append(targets, s)
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, s := range targets {
+		if err := s.deliver(ctx, &Event{Topic: topic, Payload: payload, codec: m.codec}); err != nil {
+			return errors.Wrap(err, "eventbus: publish "+topic)
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅匹配 pattern 的主题
+func (m *Memory) Subscribe(ctx context.Context, pattern string, h Handler, opts ...SubscribeOption) (*Subscription, error) {
+	if pattern == "" || h == nil {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "eventbus: pattern and handler are required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return nil, errors.ErrAlreadyClosed
+	}
+	s := newSubscription(pattern, h, newSubscribeOptions(opts))
+	s.detach = func() {
+		m.mu.Lock()
+		delete(m.subs, s)
+		m.mu.Unlock()
+	}
+	m.subs[s] = struct{}{}
+	return s, nil
+}
+
+// Codec 返回负载编码
+func (m *Memory) Codec() Codec {
+	return m.codec
+}
+
+// Close 取消所有订阅，等待已缓冲的事件处理完毕
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	subs := make([]*Subscription, 0, len(m.subs))
+	for s := range m.subs {
+		subs = append(subs, s)
+	}
+	m.mu.Unlock()
+
+	for _, s := range subs {
+		_ = s.Unsubscribe()
+	}
+	return nil
+}
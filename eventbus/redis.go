@@ -0,0 +1,313 @@
+package eventbus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// 确保 Redis 实现 Bus 接口
+var _ Bus = (*Redis)(nil)
+
+// Redis 事件总线默认配置
+const (
+	DefaultPrefix = "events:"
+
+	resubscribeDelay = time.Second // 订阅连接关闭后重新订阅的等待时间
+)
+
+// RedisOption Redis 事件总线配置选项函数
+type RedisOption func(*Redis)
+
+// WithPrefix 设置频道名前缀，默认 "events:"，频道名为 prefix + topic
+func WithPrefix(prefix string) RedisOption {
+	return func(r *Redis) {
+		r.prefix = prefix
+	}
+}
+
+// WithRedisCodec 设置负载编码，默认 JSON
+func WithRedisCodec(codec Codec) RedisOption {
+	return func(r *Redis) {
+		r.codec = codec
+	}
+}
+
+// Redis 基于 Redis PUBLISH/PSUBSCRIBE 的事件总线
+//
+// 所有订阅共用一个 PSUBSCRIBE 连接，相同模式只订阅一次。go-redis 在连接断开后自动重新订阅；
+// gosuite redis.Client 重建底层客户端导致订阅关闭时，使用新客户端重新订阅全部模式。
+// Redis pub/sub 不持久化，断线期间发布的事件会丢失。
+type Redis struct {
+	client gredis.UniversalClientProvider
+	prefix string
+	codec  Codec
+
+	mu       sync.RWMutex
+	patterns map[string][]*Subscription // 带前缀的模式 -> 订阅
+	pubsub   *redis.PubSub
+	closed   bool
+
+	subMu  sync.Mutex          // 串行化订阅连接上的 PSUBSCRIBE/PUNSUBSCRIBE
+	active map[string]struct{} // 已在订阅连接上订阅的模式，由 subMu 保护
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedis 创建基于 Redis 的事件总线，首次订阅时建立订阅连接
+func NewRedis(client gredis.UniversalClientProvider, opts ...RedisOption) (*Redis, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Redis{
+		client:   client,
+		prefix:   DefaultPrefix,
+		codec:    JSON,
+		patterns: make(map[string][]*Subscription),
+		active:   make(map[string]struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r, nil
+}
+
+// Publish 以 PUBLISH 发布事件
+func (r *Redis) Publish(ctx context.Context, topic string, payload []byte) error {
+	r.mu.RLock()
+	closed := r.closed
+	r.mu.RUnlock()
+	if closed {
+		return errors.ErrAlreadyClosed
+	}
+
+	if err := r.client.UniversalClient().Publish(ctx, r.prefix+topic, payload).Err(); err != nil {
+		return errors.Wrap(err, "eventbus: publish "+topic)
+	}
+	return nil
+}
+
+// Subscribe 订阅匹配 pattern 的主题，模式首次出现时发送 PSUBSCRIBE
+func (r *Redis) Subscribe(ctx context.Context, pattern string, h Handler, opts ...SubscribeOption) (*Subscription, error) {
+	if pattern == "" || h == nil {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "eventbus: pattern and handler are required")
+	}
+	full := r.prefix + pattern
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, errors.ErrAlreadyClosed
+	}
+	if r.pubsub == nil {
+		// 不带模式创建 PubSub 不发送命令，模式由 sync 在锁外订阅
+		r.pubsub = r.client.UniversalClient().PSubscribe(r.ctx)
+		go r.run()
+	}
+	s := newSubscription(pattern, h, newSubscribeOptions(opts))
+	s.detach = func() { r.detach(full, s) }
+	r.patterns[full] = append(r.patterns[full], s)
+	r.mu.Unlock()
+
+	if err := r.sync(ctx, full); err != nil {
+		r.detach(full, s)
+		return nil, errors.Wrap(err, "eventbus: psubscribe "+pattern)
+	}
+	return s, nil
+}
+
+// Codec 返回负载编码
+func (r *Redis) Codec() Codec {
+	return r.codec
+}
+
+// Close 关闭订阅连接并取消所有订阅，不关闭底层 Redis 客户端
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	var subs []*Subscription
+	for _, list := range r.patterns {
+		subs = append(subs, list...)
+	}
+	pubsub := r.pubsub
+	r.mu.Unlock()
+
+	r.cancel()
+	if pubsub != nil {
+		_ = pubsub.Close()
+		<-r.done
+	}
+	for _, s := range subs {
+		_ = s.Unsubscribe()
+	}
+	return nil
+}
+
+// detach 移除订阅，模式没有订阅者时发送 PUNSUBSCRIBE
+func (r *Redis) detach(full string, s *Subscription) {
+	r.mu.Lock()
+	list := r.patterns[full]
+	for i, sub := range list {
+		if sub == s {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) > 0 {
+		r.patterns[full] = list
+	} else {
+		delete(r.patterns, full)
+	}
+	r.mu.Unlock()
+
+	if err := r.sync(r.ctx, full); err != nil {
+		logger.Warnf("eventbus: punsubscribe %s failed: %v", full, err)
+	}
+}
+
+// sync 使订阅连接上模式 full 的订阅状态与当前订阅者一致
+// 网络调用不持有 mu，subMu 保证同一时刻只有一个 goroutine 修改订阅连接上的模式
+func (r *Redis) sync(ctx context.Context, full string) error {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	r.mu.RLock()
+	_, want := r.patterns[full]
+	pubsub, closed := r.pubsub, r.closed
+	r.mu.RUnlock()
+	if closed || pubsub == nil {
+		return nil
+	}
+
+	// go-redis 无论命令是否成功都会记录模式，断线重连后重新订阅，因此 active 与之保持一致
+	_, have := r.active[full]
+	switch {
+	case want && !have:
+		r.active[full] = struct{}{}
+		return pubsub.PSubscribe(ctx, full)
+	case !want && have:
+		delete(r.active, full)
+		return pubsub.PUnsubscribe(ctx, full)
+	}
+	return nil
+}
+
+// run 接收订阅消息并分发，订阅连接关闭时用当前客户端重新订阅
+func (r *Redis) run() {
+	defer close(r.done)
+
+	for {
+		r.mu.RLock()
+		pubsub := r.pubsub
+		r.mu.RUnlock()
+
+		r.consume(pubsub.Channel())
+		if r.ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-time.After(resubscribeDelay):
+		}
+
+		ok := r.resubscribe()
+		_ = pubsub.Close()
+		if !ok {
+			return
+		}
+	}
+}
+
+// resubscribe 用当前客户端重新订阅全部模式，事件总线已关闭时返回 false
+// 网络调用不持有 mu；重新订阅期间新增或移除的模式由等待 subMu 的 sync 在新连接上补齐
+func (r *Redis) resubscribe() bool {
+	r.subMu.Lock()
+	defer r.subMu.Unlock()
+
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return false
+	}
+	pubsub := r.client.UniversalClient().PSubscribe(r.ctx)
+	r.pubsub = pubsub
+	patterns := r.patternSet()
+	r.mu.Unlock()
+
+	r.active = patterns
+	if len(patterns) > 0 {
+		if err := pubsub.PSubscribe(r.ctx, keys(patterns)...); err != nil {
+			// 连接仍不可用时由 go-redis 在后台重连并重新订阅
+			logger.Warnf("eventbus: resubscribe failed: %v", err)
+		}
+	}
+	logger.Warnf("eventbus: subscription closed, resubscribed %d patterns", len(patterns))
+	return true
+}
+
+// patternSet 返回当前订阅的模式集合，调用方须持有锁
+func (r *Redis) patternSet() map[string]struct{} {
+	set := make(map[string]struct{}, len(r.patterns))
+	for p := range r.patterns {
+		set[p] = struct{}{}
+	}
+	return set
+}
+
+// keys 返回集合中的元素
+func keys(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	return out
+}
+
+// consume 分发消息直到通道关闭或事件总线关闭
+func (r *Redis) consume(ch <-chan *redis.Message) {
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			r.dispatch(msg)
+		}
+	}
+}
+
+// dispatch 将消息投递给模式对应的订阅者
+func (r *Redis) dispatch(msg *redis.Message) {
+	r.mu.RLock()
+	subs := append([]*Subscription(nil), r.patterns[msg.Pattern]...)
+	r.mu.RUnlock()
+
+	e := &Event{
+		Topic:   strings.TrimPrefix(msg.Channel, r.prefix),
+		Payload: []byte(msg.Payload),
+		codec:   r.codec,
+	}
+	for _, s := range subs {
+		_ = s.deliver(r.ctx, e)
+	}
+}
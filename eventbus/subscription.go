@@ -0,0 +1,225 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hyperits/gosuite/logger"
+)
+
+// Subscription 订阅，拥有独立的处理 goroutine 和有界缓冲区
+type Subscription struct {
+	pattern  string
+	handler  Handler
+	overflow Overflow
+	buf      chan *Event
+	dropped  atomic.Uint64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	quit   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+	detach func() // 从事件总线移除订阅
+}
+
+// newSubscription 创建订阅并启动处理 goroutine
+func newSubscription(pattern string, h Handler, o subscribeOptions) *Subscription {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Subscription{
+		pattern:  pattern,
+		handler:  h,
+		overflow: o.overflow,
+		buf:      make(chan *Event, o.bufferSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Pattern 返回订阅的主题模式
+func (s *Subscription) Pattern() string {
+	return s.pattern
+}
+
+// Dropped 返回因缓冲区满而丢弃的事件数
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe 取消订阅，等待已缓冲的事件处理完毕后返回，可重复调用
+//
+// 不能在本订阅的处理函数中同步调用，否则等待自身退出会死锁；
+// 需要在处理函数中取消订阅时使用 go sub.Unsubscribe()，处理函数返回后订阅即退出。
+func (s *Subscription) Unsubscribe() error {
+	s.once.Do(func() {
+		if s.detach != nil {
+			s.detach()
+		}
+		close(s.quit)
+	})
+	<-s.done
+	return nil
+}
+
+// deliver 按溢出策略将事件放入缓冲区，仅 Block 策略在 ctx 取消时返回错误
+func (s *Subscription) deliver(ctx context.Context, e *Event) error {
+	switch s.overflow {
+	case DropNewest:
+		select {
+		case s.buf <- e:
+		case <-s.quit:
+		default:
+			s.dropped.Add(1)
+		}
+		return nil
+	case DropOldest:
+		for {
+			select {
+			case s.buf <- e:
+				return nil
+			case <-s.quit:
+				return nil
+			default:
+			}
+			select {
+			case <-s.buf:
+				s.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		select {
+		case s.buf <- e:
+			return nil
+		case <-s.quit:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// run 依次处理缓冲区中的事件，取消订阅后处理完剩余事件再退出
+func (s *Subscription) run() {
+	defer close(s.done)
+	defer s.cancel()
+
+	for {
+		select {
+		case e := <-s.buf:
+			s.handle(e)
+		case <-s.quit:
+			for {
+				select {
+				case e := <-s.buf:
+					s.handle(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle 调用处理函数，错误和 panic 只记录日志
+func (s *Subscription) handle(e *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Errorf("eventbus: handler for %s panicked on %s: %v", s.pattern, e.Topic, r)
+		}
+	}()
+	if err := s.handler(s.ctx, e); err != nil {
+		logger.Warnf("eventbus: handler for %s failed on %s: %v", s.pattern, e.Topic, err)
+	}
+}
+
+// match 按 Redis PSUBSCRIBE 的规则匹配主题
+// * 匹配任意字符序列，? 匹配单个字符，[abc]、[^a]、[a-z] 匹配字符集，\ 转义
+func match(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if match(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			ok, rest := matchClass(pattern[1:], s[0])
+			if !ok {
+				return false
+			}
+			s = s[1:]
+			pattern = rest
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+// matchClass 匹配 [...] 字符集，pattern 为 [ 之后的部分，返回是否匹配和 ] 之后的部分
+func matchClass(pattern string, c byte) (bool, string) {
+	negate := len(pattern) > 0 && pattern[0] == '^'
+	if negate {
+		pattern = pattern[1:]
+	}
+	matched := false
+	for len(pattern) > 0 && pattern[0] != ']' {
+		switch {
+		case pattern[0] == '\\' && len(pattern) > 1:
+			if pattern[1] == c {
+				matched = true
+			}
+			pattern = pattern[2:]
+		case len(pattern) > 2 && pattern[1] == '-' && pattern[2] != ']':
+			lo, hi := pattern[0], pattern[2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			pattern = pattern[3:]
+		default:
+			if pattern[0] == c {
+				matched = true
+			}
+			pattern = pattern[1:]
+		}
+	}
+	if len(pattern) > 0 {
+		pattern = pattern[1:] // 跳过 ]
+	}
+	return matched != negate, pattern
+}
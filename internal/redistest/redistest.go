@@ -0,0 +1,51 @@
+// Package redistest 提供连接 miniredis 的测试客户端，供 lock、ratelimit、queue、eventbus 等包的测试共用
+package redistest
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// 确保 Client 实现 UniversalClientProvider 接口
+var _ gredis.UniversalClientProvider = (*Client)(nil)
+
+// Client 连接 miniredis 的测试客户端，可替换底层客户端以模拟 db/redis.Client 重建连接
+type Client struct {
+	rc atomic.Pointer[redis.Client]
+}
+
+// Run 启动 miniredis 并返回连接它的客户端，测试结束时关闭两者
+func Run(t testing.TB) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return Connect(t, mr.Addr()), mr
+}
+
+// Connect 创建连接 addr 的客户端，测试结束时关闭
+func Connect(t testing.TB, addr string) *Client {
+	t.Helper()
+	c := &Client{}
+	c.rc.Store(newRedisClient(addr))
+	t.Cleanup(func() { c.rc.Load().Close() })
+	return c
+}
+
+// UniversalClient 返回当前的底层客户端
+func (c *Client) UniversalClient() redis.UniversalClient {
+	return c.rc.Load()
+}
+
+// Reconnect 换用连接 addr 的新底层客户端并关闭旧客户端，旧客户端上的订阅随之关闭
+func (c *Client) Reconnect(addr string) {
+	old := c.rc.Swap(newRedisClient(addr))
+	old.Close()
+}
+
+// newRedisClient 关闭命令重试，实例停止后命令立即失败
+func newRedisClient(addr string) *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: addr, MaxRetries: -1})
+}
@@ -0,0 +1,445 @@
+// Package lock 提供基于 Redis 的分布式锁
+//
+// 锁以随机 token 标识持有者，只有持有者能释放或续期（Lua 脚本比较后删除/续期），
+// 不会误释放其他持有者的锁。默认启用看门狗，在持有期间每 ttl/3 自动续期，
+// 进程退出后锁在 ttl 后自动过期。传入多个相互独立的 Redis 实例时使用 Redlock 算法，
+// 在多数实例上加锁成功才视为获得锁。
+//
+//	locker := lock.New(redisClient)
+//	l, err := locker.Lock(ctx, "order:1001", 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	defer l.Unlock(context.Background())
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	mrand "math/rand"
+	"sync"
+	"time"
+
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// 锁的默认配置
+const (
+	DefaultPrefix      = "lock:"
+	DefaultMinBackoff  = 10 * time.Millisecond  // 阻塞获取时首次重试等待时间
+	DefaultMaxBackoff  = 500 * time.Millisecond // 阻塞获取时单次重试最大等待时间
+	DefaultNodeTimeout = 100 * time.Millisecond // Redlock 模式下单个实例的操作超时
+
+	// clockDriftFactor Redlock 时钟漂移系数
+	clockDriftFactor = 0.01
+)
+
+var (
+	// ErrNotAcquired 锁已被其他持有者占用
+	ErrNotAcquired = errors.New("lock: not acquired")
+
+	// ErrNotHeld 锁已过期或已被其他持有者获得
+	ErrNotHeld = errors.New("lock: not held")
+)
+
+// unlockScript token 一致时删除锁
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendScript token 一致时重设过期时间
+var extendScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Option 锁配置选项函数
+type Option func(*Locker)
+
+// WithPrefix 设置锁键前缀，默认 "lock:"
+func WithPrefix(prefix string) Option {
+	return func(l *Locker) {
+		l.prefix = prefix
+	}
+}
+
+// WithBackoff 设置阻塞获取时的重试等待时间，每次翻倍直到 max
+func WithBackoff(min, max time.Duration) Option {
+	return func(l *Locker) {
+		l.minBackoff, l.maxBackoff = min, max
+	}
+}
+
+// WithoutWatchdog 关闭自动续期，锁在 ttl 后过期，需要时调用 Refresh 手动续期
+func WithoutWatchdog() Option {
+	return func(l *Locker) {
+		l.watchdog = false
+	}
+}
+
+// WithNodeTimeout 设置 Redlock 模式下单个实例的操作超时，默认 100ms
+func WithNodeTimeout(d time.Duration) Option {
+	return func(l *Locker) {
+		l.nodeTimeout = d
+	}
+}
+
+// Locker 分布式锁管理器
+type Locker struct {
+	nodes       []gredis.UniversalClientProvider
+	quorum      int
+	prefix      string
+	minBackoff  time.Duration
+	maxBackoff  time.Duration
+	nodeTimeout time.Duration
+	watchdog    bool
+}
+
+// New 创建基于单个 Redis 的锁管理器
+func New(client gredis.UniversalClientProvider, opts ...Option) *Locker {
+	return NewRedlock([]gredis.UniversalClientProvider{client}, opts...)
+}
+
+// NewRedlock 创建基于多个相互独立 Redis 实例的 Redlock 锁管理器
+// 在超过半数的实例上加锁成功且剩余有效期大于 0 时获得锁
+func NewRedlock(clients []gredis.UniversalClientProvider, opts ...Option) *Locker {
+	l := &Locker{
+		nodes:      append([]gredis.UniversalClientProvider(nil), clients...),
+		quorum:     len(clients)/2 + 1,
+		prefix:     DefaultPrefix,
+		minBackoff: DefaultMinBackoff,
+		maxBackoff: DefaultMaxBackoff,
+		watchdog:   true,
+	}
+	if len(clients) > 1 {
+		l.nodeTimeout = DefaultNodeTimeout
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if l.minBackoff <= 0 {
+		l.minBackoff = DefaultMinBackoff
+	}
+	if l.maxBackoff < l.minBackoff {
+		l.maxBackoff = l.minBackoff
+	}
+	return l
+}
+
+// TryLock 尝试获取锁，锁已被占用时立即返回 ErrNotAcquired
+func (l *Locker) TryLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	if len(l.nodes) == 0 {
+		return nil, errors.ErrNilClient
+	}
+	if key == "" || ttl <= 0 {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "lock: key and positive ttl are required")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	lk := &Lock{locker: l, key: l.prefix + key, token: token, ttl: ttl}
+	start := time.Now()
+	ok, err := lk.acquire(ctx)
+	if err != nil || !ok {
+		_, _ = lk.release(context.Background())
+		if err == nil {
+			err = ErrNotAcquired
+		}
+		return nil, err
+	}
+
+	validity := ttl - time.Since(start) - l.drift(ttl)
+	if validity <= 0 {
+		_, _ = lk.release(context.Background())
+		return nil, ErrNotAcquired
+	}
+	lk.validUntil = start.Add(ttl - l.drift(ttl))
+
+	lk.lost = make(chan struct{})
+	if l.watchdog {
+		lk.stop = make(chan struct{})
+		lk.done = make(chan struct{})
+		go lk.watch()
+	}
+	return lk, nil
+}
+
+// Lock 阻塞获取锁，锁被占用时按退避间隔重试，直到获得锁或 ctx 结束
+func (l *Locker) Lock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	backoff := l.minBackoff
+	for {
+		lk, err := l.TryLock(ctx, key, ttl)
+		if err == nil || !errors.Is(err, ErrNotAcquired) {
+			return lk, err
+		}
+
+		// 加入随机抖动，避免多个竞争者同时重试
+		wait := backoff/2 + time.Duration(mrand.Int63n(int64(backoff/2)+1))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Join(ErrNotAcquired, ctx.Err())
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > l.maxBackoff {
+			backoff = l.maxBackoff
+		}
+	}
+}
+
+// Do 持有锁执行 fn，结束后释放锁
+// 看门狗续期失败导致锁丢失时取消传给 fn 的 ctx
+func (l *Locker) Do(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) error) error {
+	lk, err := l.Lock(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+
+	fnCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-lk.Lost():
+			cancel()
+		case <-fnCtx.Done():
+		}
+	}()
+
+	err = fn(fnCtx)
+	if unlockErr := lk.Unlock(context.Background()); unlockErr != nil && err == nil {
+		err = unlockErr
+	}
+	return err
+}
+
+// drift 返回 Redlock 的时钟漂移补偿
+func (l *Locker) drift(ttl time.Duration) time.Duration {
+	return time.Duration(math.Round(float64(ttl)*clockDriftFactor)) + 2*time.Millisecond
+}
+
+// each 在全部实例上并发执行 fn，返回成功的实例数和错误
+func (l *Locker) each(ctx context.Context, fn func(ctx context.Context, rc redis.UniversalClient) (bool, error)) (int, error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		ok   int
+		errs []error
+	)
+	for _, node := range l.nodes {
+		wg.Add(1)
+		go func(node gredis.UniversalClientProvider) {
+			defer wg.Done()
+
+			nodeCtx := ctx
+			if l.nodeTimeout > 0 {
+				var cancel context.CancelFunc
+				nodeCtx, cancel = context.WithTimeout(ctx, l.nodeTimeout)
+				defer cancel()
+			}
+			success, err := fn(nodeCtx, node.UniversalClient())
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+			} else if success {
+				ok++
+			}
+		}(node)
+	}
+	wg.Wait()
+	return ok, errors.Join(errs...)
+}
+
+// Lock 已获得的锁
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+	ttl    time.Duration
+
+	mu         sync.Mutex
+	validUntil time.Time
+	released   bool
+
+	stop     chan struct{} // 通知看门狗退出，未启用看门狗时为 nil
+	done     chan struct{} // 看门狗已退出
+	lost     chan struct{} // 锁丢失时关闭
+	lostOnce sync.Once
+}
+
+// Key 返回锁键（含前缀）
+func (lk *Lock) Key() string {
+	return lk.key
+}
+
+// Token 返回持有者 token
+func (lk *Lock) Token() string {
+	return lk.token
+}
+
+// ValidUntil 返回锁的有效期截止时间，看门狗续期后顺延
+func (lk *Lock) ValidUntil() time.Time {
+	lk.mu.Lock()
+	defer lk.mu.Unlock()
+
+	return lk.validUntil
+}
+
+// Lost 返回在看门狗续期失败、锁丢失时关闭的通道
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Refresh 将锁的过期时间重设为 ttl，锁已丢失时返回 ErrNotHeld
+func (lk *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.Wrap(errors.ErrInvalidParameter, "lock: ttl must be positive")
+	}
+
+	lk.mu.Lock()
+	released := lk.released
+	lk.mu.Unlock()
+	if released {
+		return ErrNotHeld
+	}
+
+	start := time.Now()
+	ok, err := lk.locker.each(ctx, func(ctx context.Context, rc redis.UniversalClient) (bool, error) {
+		n, err := extendScript.Run(ctx, rc, []string{lk.key}, lk.token, ttl.Milliseconds()).Int64()
+		return n == 1, err
+	})
+	if ok < lk.locker.quorum {
+		if err != nil {
+			return errors.Wrap(err, "lock: refresh")
+		}
+		return ErrNotHeld
+	}
+
+	lk.mu.Lock()
+	lk.validUntil = start.Add(ttl - lk.locker.drift(ttl))
+	lk.mu.Unlock()
+	return nil
+}
+
+// Unlock 释放锁并停止看门狗，锁已过期或被其他持有者获得时返回 ErrNotHeld
+func (lk *Lock) Unlock(ctx context.Context) error {
+	lk.mu.Lock()
+	if lk.released {
+		lk.mu.Unlock()
+		return ErrNotHeld
+	}
+	lk.released = true
+	// 与 released 在同一临界区内通知看门狗退出，看门狗因 released 续期失败时能看到 stop 已关闭，不会误报锁丢失
+	if lk.stop != nil {
+		close(lk.stop)
+	}
+	lk.mu.Unlock()
+
+	if lk.done != nil {
+		<-lk.done
+	}
+
+	ok, err := lk.release(ctx)
+	if ok < lk.locker.quorum {
+		if err != nil {
+			return errors.Wrap(err, "lock: unlock")
+		}
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// acquire 在各实例上加锁，成功的实例数达到法定数量时返回 true
+func (lk *Lock) acquire(ctx context.Context) (bool, error) {
+	ok, err := lk.locker.each(ctx, func(ctx context.Context, rc redis.UniversalClient) (bool, error) {
+		return rc.SetNX(ctx, lk.key, lk.token, lk.ttl).Result()
+	})
+	if ok >= lk.locker.quorum {
+		return true, nil
+	}
+	// 多实例时个别实例不可用按未获得锁处理，可重试；单实例时直接返回错误
+	if err != nil && len(lk.locker.nodes) == 1 {
+		return false, errors.Wrap(err, "lock: acquire")
+	}
+	if err != nil {
+		return false, errors.Join(ErrNotAcquired, err)
+	}
+	return false, nil
+}
+
+// release 在全部实例上释放锁，返回释放成功的实例数
+func (lk *Lock) release(ctx context.Context) (int, error) {
+	return lk.locker.each(ctx, func(ctx context.Context, rc redis.UniversalClient) (bool, error) {
+		n, err := unlockScript.Run(ctx, rc, []string{lk.key}, lk.token).Int64()
+		return n == 1, err
+	})
+}
+
+// watch 看门狗，每 ttl/3 续期一次，超过有效期仍未续期成功时标记锁丢失
+func (lk *Lock) watch() {
+	defer close(lk.done)
+
+	interval := lk.ttl / 3
+	if interval <= 0 {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := lk.Refresh(ctx, lk.ttl)
+		cancel()
+
+		if err == nil {
+			continue
+		}
+		select {
+		case <-lk.stop:
+			// 续期期间锁已被释放
+			return
+		default:
+		}
+		if errors.Is(err, ErrNotHeld) || time.Now().After(lk.ValidUntil()) {
+			logger.Warnf("lock: %s lost: %v", lk.key, err)
+			lk.markLost()
+			return
+		}
+	}
+}
+
+// markLost 标记锁丢失
+func (lk *Lock) markLost() {
+	lk.lostOnce.Do(func() { close(lk.lost) })
+}
+
+// newToken 生成随机持有者 token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "lock: generate token")
+	}
+	return hex.EncodeToString(b), nil
+}
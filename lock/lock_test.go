@@ -0,0 +1,197 @@
+package lock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/internal/redistest"
+)
+
+func TestTryLockAndUnlock(t *testing.T) {
+	ctx := context.Background()
+	node, mr := redistest.Run(t)
+	locker := New(node, WithoutWatchdog())
+
+	lk, err := locker.TryLock(ctx, "order:1", 10*time.Second)
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	if v, _ := mr.Get("lock:order:1"); v != lk.Token() {
+		t.Errorf("stored token = %q, want %q", v, lk.Token())
+	}
+	if ttl := mr.TTL("lock:order:1"); ttl != 10*time.Second {
+		t.Errorf("ttl = %v", ttl)
+	}
+	if _, err := locker.TryLock(ctx, "order:1", time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Errorf("second TryLock err = %v", err)
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if mr.Exists("lock:order:1") {
+		t.Error("lock key not deleted")
+	}
+	if err := lk.Unlock(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("second Unlock err = %v", err)
+	}
+}
+
+func TestUnlockChecksToken(t *testing.T) {
+	ctx := context.Background()
+	node, mr := redistest.Run(t)
+	locker := New(node, WithoutWatchdog())
+
+	lk, err := locker.TryLock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	// 锁过期后被其他持有者获得
+	mr.FastForward(time.Second)
+	other, err := locker.TryLock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("TryLock after expiry: %v", err)
+	}
+
+	if err := lk.Refresh(ctx, time.Second); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Refresh by stale holder err = %v", err)
+	}
+	if err := lk.Unlock(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Unlock by stale holder err = %v", err)
+	}
+	if v, _ := mr.Get("lock:k"); v != other.Token() {
+		t.Errorf("stale holder removed the new lock, value = %q", v)
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ctx := context.Background()
+	node, mr := redistest.Run(t)
+	locker := New(node, WithoutWatchdog())
+
+	lk, err := locker.TryLock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	before := lk.ValidUntil()
+	if err := lk.Refresh(ctx, time.Minute); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if ttl := mr.TTL("lock:k"); ttl != time.Minute {
+		t.Errorf("ttl after Refresh = %v", ttl)
+	}
+	if !lk.ValidUntil().After(before) {
+		t.Error("ValidUntil not extended")
+	}
+	if err := lk.Refresh(ctx, 0); !errors.Is(err, errors.ErrInvalidParameter) {
+		t.Errorf("Refresh(0) err = %v", err)
+	}
+	lk.Unlock(ctx)
+	if err := lk.Refresh(ctx, time.Second); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Refresh after Unlock err = %v", err)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.Background()
+	node, mr := redistest.Run(t)
+	locker := New(node)
+
+	lk, err := locker.TryLock(ctx, "k", 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("TryLock: %v", err)
+	}
+	// 看门狗每 ttl/3 续期，将过期时间重设为 ttl
+	mr.SetTTL("lock:k", time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for mr.TTL("lock:k") != 150*time.Millisecond {
+		if time.Now().After(deadline) {
+			t.Fatalf("watchdog did not refresh, ttl = %v", mr.TTL("lock:k"))
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 锁被其他持有者占用后标记丢失
+	mr.Set("lock:k", "someone-else")
+	select {
+	case <-lk.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("Lost not closed after lock was taken over")
+	}
+	if err := lk.Unlock(ctx); !errors.Is(err, ErrNotHeld) {
+		t.Errorf("Unlock after lost err = %v", err)
+	}
+}
+
+func TestUnlockDoesNotReportLost(t *testing.T) {
+	ctx := context.Background()
+	node, _ := redistest.Run(t)
+	locker := New(node)
+
+	// ttl 很短，看门狗频繁续期，与 Unlock 竞争
+	for i := 0; i < 50; i++ {
+		lk, err := locker.TryLock(ctx, "k", 15*time.Millisecond)
+		if err != nil {
+			t.Fatalf("TryLock: %v", err)
+		}
+		time.Sleep(time.Duration(i%6) * time.Millisecond)
+		lk.Unlock(ctx)
+		select {
+		case <-lk.Lost():
+			t.Fatalf("iteration %d: Unlock reported the lock as lost", i)
+		default:
+		}
+	}
+}
+
+func TestRedlockQuorum(t *testing.T) {
+	ctx := context.Background()
+	var (
+		nodes []gredis.UniversalClientProvider
+		mrs   []*miniredis.Miniredis
+	)
+	for i := 0; i < 3; i++ {
+		node, mr := redistest.Run(t)
+		nodes = append(nodes, node)
+		mrs = append(mrs, mr)
+	}
+	locker := NewRedlock(nodes, WithoutWatchdog(), WithNodeTimeout(time.Second))
+
+	// 一个实例被其他持有者占用，多数实例成功仍获得锁
+	mrs[0].Set("lock:k", "other")
+	lk, err := locker.TryLock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("TryLock with 2/3 nodes: %v", err)
+	}
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if v, _ := mrs[0].Get("lock:k"); v != "other" || mrs[1].Exists("lock:k") || mrs[2].Exists("lock:k") {
+		t.Error("Unlock touched foreign lock or left own keys")
+	}
+
+	// 两个实例被占用时未获得锁，已加锁的实例被回滚
+	mrs[1].Set("lock:k", "other")
+	if _, err := locker.TryLock(ctx, "k", time.Second); !errors.Is(err, ErrNotAcquired) {
+		t.Fatalf("TryLock with 1/3 nodes err = %v", err)
+	}
+	if mrs[2].Exists("lock:k") {
+		t.Error("partial lock not rolled back")
+	}
+
+	// 一个实例不可用时仍可获得锁
+	mrs[0].Del("lock:k")
+	mrs[1].Del("lock:k")
+	mrs[2].Close()
+	lk, err = locker.TryLock(ctx, "k", time.Second)
+	if err != nil {
+		t.Fatalf("TryLock with one node down: %v", err)
+	}
+	if err := lk.Unlock(ctx); err != nil {
+		t.Errorf("Unlock with one node down: %v", err)
+	}
+}
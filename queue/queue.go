@@ -0,0 +1,337 @@
+// Package queue 提供基于 Redis Streams 的可靠后台任务队列
+//
+// 任务以 JSON 信封写入 Stream，Worker 通过消费组（XREADGROUP）并发处理，处理成功后确认并删除；
+// 超过可见性超时仍未确认的任务（如 Worker 崩溃）由其他 Worker 通过 XAUTOCLAIM 认领，
+// 认领后不直接执行，而是计为一次失败并按退避重试：崩溃与处理卡住无法区分，若立即重新执行，
+// 导致进程崩溃的任务会让各个 Worker 依次崩溃。因此最大尝试次数为 1 的任务在一次 Worker 崩溃后
+// 即进入死信，需要容忍崩溃的任务应将最大尝试次数设为 2 以上。
+// 失败的任务按指数退避写入延迟队列（有序集合）等待重试，超过最大尝试次数后写入死信 Stream。
+// 同一队列的所有键使用相同的 hash tag，可用于 Redis Cluster。
+//
+//	q, _ := queue.New(redisClient, "emails")
+//	queue.Enqueue(ctx, q, "welcome", WelcomeEmail{UserID: 1}, queue.WithDelay(time.Minute))
+//
+//	w := queue.NewWorker(q, queue.WithConcurrency(20))
+//	queue.Handle(w, "welcome", func(ctx context.Context, e WelcomeEmail) error { ... })
+//	w.Run(ctx) // ctx 取消后停止拉取，等待进行中的任务完成
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// 队列默认配置
+const (
+	DefaultPrefix      = "queue:"
+	DefaultGroup       = "workers"
+	DefaultMaxAttempts = 10
+	DefaultDeadMaxLen  = 10000 // 死信 Stream 的近似最大长度
+
+	// jobField Stream 消息中保存任务信封的字段
+	jobField = "job"
+)
+
+// Job 任务信封
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempt     int             `json:"attempt"`                // 已失败的次数
+	MaxAttempts int             `json:"max_attempts,omitempty"` // 最大尝试次数，0 表示使用 Worker 配置
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	LastError   string          `json:"last_error,omitempty"`
+
+	streamID string
+}
+
+// Decode 将任务负载解码到 v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Option 队列配置选项函数
+type Option func(*Queue)
+
+// WithPrefix 设置队列键前缀，默认 "queue:"
+func WithPrefix(prefix string) Option {
+	return func(q *Queue) {
+		q.prefix = prefix
+	}
+}
+
+// WithGroup 设置消费组名，默认 "workers"
+func WithGroup(group string) Option {
+	return func(q *Queue) {
+		q.group = group
+	}
+}
+
+// WithDeadMaxLen 设置死信 Stream 的近似最大长度，0 表示不限制
+func WithDeadMaxLen(n int64) Option {
+	return func(q *Queue) {
+		q.deadMaxLen = n
+	}
+}
+
+// Queue 任务队列，负责入队和查询，处理任务使用 Worker
+type Queue struct {
+	client     gredis.UniversalClientProvider
+	name       string
+	prefix     string
+	group      string
+	deadMaxLen int64
+}
+
+// New 创建任务队列
+func New(client gredis.UniversalClientProvider, name string, opts ...Option) (*Queue, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+	if name == "" {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "queue: name is required")
+	}
+	q := &Queue{
+		client:     client,
+		name:       name,
+		prefix:     DefaultPrefix,
+		group:      DefaultGroup,
+		deadMaxLen: DefaultDeadMaxLen,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	return q, nil
+}
+
+// Name 返回队列名
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// EnqueueOption 入队选项函数
+type EnqueueOption func(*enqueueOptions)
+
+// enqueueOptions 入队选项
+type enqueueOptions struct {
+	processAt   time.Time
+	maxAttempts int
+	id          string
+}
+
+// WithDelay 延迟 d 后处理
+func WithDelay(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.processAt = time.Now().Add(d)
+	}
+}
+
+// WithProcessAt 在指定时间后处理
+func WithProcessAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.processAt = t
+	}
+}
+
+// WithMaxAttempts 设置任务的最大尝试次数，覆盖 Worker 配置
+// 超过可见性超时被重新认领也计为一次尝试
+func WithMaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = n
+	}
+}
+
+// WithJobID 指定任务 ID，默认随机生成
+func WithJobID(id string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.id = id
+	}
+}
+
+// Enqueue 将 payload 编码为 JSON 并入队，返回任务 ID
+func Enqueue[T any](ctx context.Context, q *Queue, jobType string, payload T, opts ...EnqueueOption) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", errors.Wrap(err, "queue: encode payload")
+	}
+	return q.EnqueueRaw(ctx, jobType, data, opts...)
+}
+
+// EnqueueRaw 将已编码的 JSON 负载入队，返回任务 ID
+func (q *Queue) EnqueueRaw(ctx context.Context, jobType string, payload json.RawMessage, opts ...EnqueueOption) (string, error) {
+	if jobType == "" {
+		return "", errors.Wrap(errors.ErrInvalidParameter, "queue: job type is required")
+	}
+	var o enqueueOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.id == "" {
+		var err error
+		if o.id, err = newJobID(); err != nil {
+			return "", err
+		}
+	}
+
+	job := &Job{
+		ID:          o.id,
+		Type:        jobType,
+		Payload:     payload,
+		MaxAttempts: o.maxAttempts,
+		EnqueuedAt:  time.Now(),
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		return "", errors.Wrap(err, "queue: encode job")
+	}
+
+	rc := q.client.UniversalClient()
+	if o.processAt.After(time.Now()) {
+		err = rc.ZAdd(ctx, q.delayedKey(), redis.Z{Score: float64(o.processAt.UnixMilli()), Member: string(data)}).Err()
+	} else {
+		err = rc.XAdd(ctx, &redis.XAddArgs{Stream: q.streamKey(), Values: []interface{}{jobField, data}}).Err()
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "queue %s: enqueue %s", q.name, jobType)
+	}
+	return job.ID, nil
+}
+
+// Stats 队列统计
+type Stats struct {
+	Ready   int64 // 待处理的任务数
+	Running int64 // 已被 Worker 读取、尚未确认的任务数
+	Delayed int64 // 延迟或等待重试的任务数
+	Dead    int64 // 死信任务数
+}
+
+// Stats 返回队列统计
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	rc := q.client.UniversalClient()
+
+	var (
+		length, delayed, dead *redis.IntCmd
+		pending               *redis.XPendingCmd
+	)
+	// 逐个检查命令错误，消费组尚未创建时 XPENDING 返回 NOGROUP，视为没有进行中的任务
+	_, _ = rc.Pipelined(ctx, func(p redis.Pipeliner) error {
+		length = p.XLen(ctx, q.streamKey())
+		pending = p.XPending(ctx, q.streamKey(), q.group)
+		delayed = p.ZCard(ctx, q.delayedKey())
+		dead = p.XLen(ctx, q.deadKey())
+		return nil
+	})
+	for _, cmd := range []redis.Cmder{length, delayed, dead} {
+		if err := cmd.Err(); err != nil {
+			return Stats{}, errors.Wrapf(err, "queue %s: stats", q.name)
+		}
+	}
+	if err := pending.Err(); err != nil && !isNoGroup(err) {
+		return Stats{}, errors.Wrapf(err, "queue %s: stats", q.name)
+	}
+
+	var running int64
+	if pending.Err() == nil {
+		running = pending.Val().Count
+	}
+	return Stats{
+		Ready:   length.Val() - running,
+		Running: running,
+		Delayed: delayed.Val(),
+		Dead:    dead.Val(),
+	}, nil
+}
+
+// DeadJobs 返回最近的 count 个死信任务，按时间倒序
+func (q *Queue) DeadJobs(ctx context.Context, count int64) ([]*Job, error) {
+	msgs, err := q.client.UniversalClient().XRevRangeN(ctx, q.deadKey(), "+", "-", count).Result()
+	if err != nil {
+		return nil, errors.Wrapf(err, "queue %s: read dead jobs", q.name)
+	}
+	jobs := make([]*Job, 0, len(msgs))
+	for _, msg := range msgs {
+		job, err := decodeJob(msg)
+		if err != nil {
+			logger.Warnf("queue %s: skipping dead message: %v", q.name, err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// RetryDead 将死信任务重新入队，重置失败次数
+func (q *Queue) RetryDead(ctx context.Context, jobs ...*Job) error {
+	rc := q.client.UniversalClient()
+	for _, job := range jobs {
+		retry := *job
+		retry.Attempt, retry.LastError = 0, ""
+		data, err := json.Marshal(&retry)
+		if err != nil {
+			return errors.Wrap(err, "queue: encode job")
+		}
+		_, err = rc.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.XAdd(ctx, &redis.XAddArgs{Stream: q.streamKey(), Values: []interface{}{jobField, data}})
+			if job.streamID != "" {
+				p.XDel(ctx, q.deadKey(), job.streamID)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrapf(err, "queue %s: retry dead job %s", q.name, job.ID)
+		}
+	}
+	return nil
+}
+
+// streamKey 返回任务 Stream 键，花括号内为 hash tag，保证同一队列的键位于同一槽位
+func (q *Queue) streamKey() string {
+	return q.prefix + "{" + q.name + "}:stream"
+}
+
+// delayedKey 返回延迟任务有序集合键
+func (q *Queue) delayedKey() string {
+	return q.prefix + "{" + q.name + "}:delayed"
+}
+
+// deadKey 返回死信 Stream 键
+func (q *Queue) deadKey() string {
+	return q.prefix + "{" + q.name + "}:dead"
+}
+
+// decodeJob 解析 Stream 消息中的任务信封
+func decodeJob(msg redis.XMessage) (*Job, error) {
+	raw, ok := msg.Values[jobField].(string)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrInvalidParameter, "queue: message "+msg.ID+" has no job field")
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, errors.Wrapf(err, "queue: decode message %s", msg.ID)
+	}
+	job.streamID = msg.ID
+	return &job, nil
+}
+
+// newJobID 生成随机任务 ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "queue: generate job id")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isNoGroup 判断错误是否为消费组不存在
+func isNoGroup(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
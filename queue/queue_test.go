@@ -0,0 +1,313 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/internal/redistest"
+)
+
+type email struct {
+	To string `json:"to"`
+}
+
+func newTestQueue(t *testing.T) *Queue {
+	t.Helper()
+	client, _ := redistest.Run(t)
+	q, err := New(client, "emails")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return q
+}
+
+// newTestWorker 创建轮询间隔和退避都很短的 Worker
+func newTestWorker(q *Queue, opts ...WorkerOption) *Worker {
+	opts = append([]WorkerOption{
+		WithConcurrency(2),
+		WithPollInterval(20 * time.Millisecond),
+		WithBackoff(10*time.Millisecond, 10*time.Millisecond),
+	}, opts...)
+	w := NewWorker(q, opts...)
+	w.block = 50 * time.Millisecond
+	return w
+}
+
+// startWorker 在后台运行 Worker，返回停止并等待 Run 返回的函数
+func startWorker(t *testing.T, w *Worker) func() error {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	stopped := false
+	stop := func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		cancel()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after cancel")
+			return nil
+		}
+	}
+	t.Cleanup(func() { stop() })
+	return stop
+}
+
+// waitStats 等待队列统计满足条件
+func waitStats(t *testing.T, q *Queue, what string, cond func(Stats) bool) Stats {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st, err := q.Stats(context.Background())
+		if err != nil {
+			t.Fatalf("Stats: %v", err)
+		}
+		if cond(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, stats = %+v", what, st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDelayedEnqueue(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	start := time.Now()
+	if _, err := Enqueue(ctx, q, "welcome", email{To: "a@example.com"}, WithDelay(200*time.Millisecond)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if st, _ := q.Stats(ctx); st.Delayed != 1 || st.Ready != 0 {
+		t.Fatalf("stats after delayed enqueue = %+v", st)
+	}
+
+	got := make(chan email, 1)
+	w := newTestWorker(q)
+	Handle(w, "welcome", func(ctx context.Context, e email) error {
+		got <- e
+		return nil
+	})
+	startWorker(t, w)
+
+	select {
+	case e := <-got:
+		if e.To != "a@example.com" {
+			t.Errorf("payload = %+v", e)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Errorf("delayed job processed after %v", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delayed job not processed")
+	}
+	waitStats(t, q, "ack", func(st Stats) bool { return st == Stats{} })
+}
+
+func TestRetryThenDead(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	var calls atomic.Int32
+	w := newTestWorker(q)
+	w.Handle("welcome", func(ctx context.Context, job *Job) error {
+		if int(calls.Add(1)) != job.Attempt+1 {
+			t.Errorf("call %d saw attempt %d", calls.Load(), job.Attempt)
+		}
+		return errors.New("smtp down")
+	})
+	if _, err := Enqueue(ctx, q, "welcome", email{}, WithMaxAttempts(3)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	startWorker(t, w)
+
+	waitStats(t, q, "dead job", func(st Stats) bool { return st.Dead == 1 })
+	if n := calls.Load(); n != 3 {
+		t.Errorf("handler calls = %d, want 3", n)
+	}
+	dead, err := q.DeadJobs(ctx, 10)
+	if err != nil || len(dead) != 1 {
+		t.Fatalf("DeadJobs = %v, %v", dead, err)
+	}
+	if dead[0].Attempt != 3 || dead[0].LastError != "smtp down" {
+		t.Errorf("dead job = %+v", dead[0])
+	}
+}
+
+func TestHandleDecodeFailure(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	var calls atomic.Int32
+	w := newTestWorker(q)
+	Handle(w, "welcome", func(ctx context.Context, e email) error {
+		calls.Add(1)
+		return nil
+	})
+	if _, err := q.EnqueueRaw(ctx, "welcome", json.RawMessage(`"not an object"`)); err != nil {
+		t.Fatalf("EnqueueRaw: %v", err)
+	}
+	startWorker(t, w)
+
+	// 解码失败不重试，直接进入死信
+	st := waitStats(t, q, "dead job", func(st Stats) bool { return st.Dead == 1 })
+	if st.Delayed != 0 || calls.Load() != 0 {
+		t.Errorf("stats = %+v, calls = %d", st, calls.Load())
+	}
+	dead, _ := q.DeadJobs(ctx, 1)
+	if len(dead) != 1 || dead[0].Attempt != 1 {
+		t.Fatalf("dead jobs = %+v", dead)
+	}
+}
+
+func TestRetryDead(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	var fail atomic.Bool
+	fail.Store(true)
+	attempts := make(chan int, 10)
+	w := newTestWorker(q)
+	w.Handle("welcome", func(ctx context.Context, job *Job) error {
+		if fail.Load() {
+			return errors.New("boom")
+		}
+		attempts <- job.Attempt
+		return nil
+	})
+	if _, err := Enqueue(ctx, q, "welcome", email{}, WithMaxAttempts(2)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	stop := startWorker(t, w)
+	waitStats(t, q, "dead job", func(st Stats) bool { return st.Dead == 1 })
+	stop()
+
+	dead, _ := q.DeadJobs(ctx, 10)
+	fail.Store(false)
+	if err := q.RetryDead(ctx, dead...); err != nil {
+		t.Fatalf("RetryDead: %v", err)
+	}
+	if st, _ := q.Stats(ctx); st.Dead != 0 || st.Ready != 1 {
+		t.Fatalf("stats after RetryDead = %+v", st)
+	}
+
+	startWorker(t, newTestWorkerWith(w))
+	select {
+	case attempt := <-attempts:
+		if attempt != 0 {
+			t.Errorf("retried job attempt = %d, want 0", attempt)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retried job not processed")
+	}
+}
+
+// newTestWorkerWith 创建与 w 使用相同处理函数的新 Worker
+func newTestWorkerWith(w *Worker) *Worker {
+	nw := newTestWorker(w.q)
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	for jobType, h := range w.handlers {
+		nw.Handle(jobType, h)
+	}
+	return nw
+}
+
+func TestReclaimCountsAsFailure(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+	rc := q.client.UniversalClient()
+
+	// 另一个消费者读取任务后崩溃，未确认
+	crashed := newTestWorker(q, WithConsumer("crashed"))
+	if err := crashed.ensureGroup(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Enqueue(ctx, q, "welcome", email{}, WithMaxAttempts(1)); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if msgs, err := crashed.read(ctx, 1); err != nil || len(msgs) != 1 {
+		t.Fatalf("read = %v, %v", msgs, err)
+	}
+
+	var calls atomic.Int32
+	w := newTestWorker(q, WithVisibilityTimeout(50*time.Millisecond))
+	w.Handle("welcome", func(ctx context.Context, job *Job) error {
+		calls.Add(1)
+		return nil
+	})
+	startWorker(t, w)
+
+	// MaxAttempts 为 1 时，一次认领即进入死信，处理函数不会执行
+	waitStats(t, q, "dead job", func(st Stats) bool { return st.Dead == 1 })
+	dead, _ := q.DeadJobs(ctx, 1)
+	if len(dead) != 1 || dead[0].LastError != errVisibilityTimeout.Error() || calls.Load() != 0 {
+		t.Errorf("dead = %+v, calls = %d", dead, calls.Load())
+	}
+	if n, _ := rc.XLen(ctx, q.streamKey()).Result(); n != 0 {
+		t.Errorf("stream length = %d", n)
+	}
+}
+
+func TestRunStopsOnCancel(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	w := newTestWorker(q)
+	w.Handle("slow", func(ctx context.Context, job *Job) error {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	})
+	if _, err := q.EnqueueRaw(ctx, "slow", json.RawMessage(`{}`)); err != nil {
+		t.Fatalf("EnqueueRaw: %v", err)
+	}
+	stop := startWorker(t, w)
+
+	<-started
+	// ctx 取消后等待进行中的任务完成并确认
+	if err := stop(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run err = %v", err)
+	}
+	if !finished.Load() {
+		t.Error("Run returned before in-flight job finished")
+	}
+	if st, _ := q.Stats(ctx); st != (Stats{}) {
+		t.Errorf("stats after stop = %+v", st)
+	}
+}
+
+func TestRunDrainTimeout(t *testing.T) {
+	ctx := context.Background()
+	q := newTestQueue(t)
+
+	started := make(chan struct{})
+	w := newTestWorker(q, WithDrainTimeout(50*time.Millisecond))
+	w.Handle("stuck", func(ctx context.Context, job *Job) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	q.EnqueueRaw(ctx, "stuck", json.RawMessage(`{}`))
+	stop := startWorker(t, w)
+
+	<-started
+	if err := stop(); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("Run err = %v", err)
+	}
+}
@@ -0,0 +1,500 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Worker 默认配置
+const (
+	DefaultConcurrency       = 10
+	DefaultVisibilityTimeout = 5 * time.Minute
+	DefaultPollInterval      = time.Second
+	DefaultBlockTimeout      = 2 * time.Second
+	DefaultBackoff           = time.Second
+	DefaultMaxBackoff        = 10 * time.Minute
+	DefaultDrainTimeout      = 30 * time.Second
+	DefaultPromoteBatch      = 100 // 每次转移到 Stream 的到期延迟任务数
+
+	// maxErrorLength 任务信封中保存的错误信息最大长度
+	maxErrorLength = 1024
+)
+
+var (
+	// ErrSkipRetry 处理函数返回包装此错误的错误时，任务不再重试，直接进入死信
+	ErrSkipRetry = errors.New("queue: skip retry")
+
+	// ErrDrainTimeout 关闭时进行中的任务未在排空超时内完成
+	ErrDrainTimeout = errors.New("queue: drain timeout")
+
+	// errVisibilityTimeout 任务超过可见性超时未确认，被重新认领
+	errVisibilityTimeout = errors.New("visibility timeout exceeded")
+)
+
+// promoteScript 将到期的延迟任务转移到 Stream
+// KEYS: delayed, stream; ARGV: now(ms), limit
+var promoteScript = redis.NewScript(`
+local due = redis.call("ZRANGEBYSCORE", KEYS[1], "-inf", ARGV[1], "LIMIT", 0, ARGV[2])
+for _, job in ipairs(due) do
+	redis.call("XADD", KEYS[2], "*", "job", job)
+	redis.call("ZREM", KEYS[1], job)
+end
+return #due
+`)
+
+// settleScript 仍由当前消费者持有时确认并删除消息，按 mode 写入延迟队列或死信
+// KEYS: stream, target; ARGV: group, id, consumer, mode(ack|retry|dead), job, score, dead maxlen
+// 返回 0 表示消息已被其他消费者认领，不做任何修改
+var settleScript = redis.NewScript(`
+local p = redis.call("XPENDING", KEYS[1], ARGV[1], ARGV[2], ARGV[2], 1)
+if #p == 0 or p[1][2] ~= ARGV[3] then
+	return 0
+end
+redis.call("XACK", KEYS[1], ARGV[1], ARGV[2])
+redis.call("XDEL", KEYS[1], ARGV[2])
+if ARGV[4] == "retry" then
+	redis.call("ZADD", KEYS[2], ARGV[6], ARGV[5])
+elseif ARGV[4] == "dead" then
+	if tonumber(ARGV[7]) > 0 then
+		redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[7], "*", "job", ARGV[5])
+	else
+		redis.call("XADD", KEYS[2], "*", "job", ARGV[5])
+	end
+end
+return 1
+`)
+
+// Handler 任务处理函数，返回 nil 表示成功
+// ctx 在可见性超时或关闭排空超时后取消
+type Handler func(ctx context.Context, job *Job) error
+
+// Handle 注册类型化的处理函数，负载按 JSON 解码为 T；解码失败的任务直接进入死信
+func Handle[T any](w *Worker, jobType string, fn func(ctx context.Context, payload T) error) {
+	w.Handle(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Decode(&payload); err != nil {
+			return fmt.Errorf("%w: decode payload: %v", ErrSkipRetry, err)
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// WorkerOption Worker 配置选项函数
+type WorkerOption func(*Worker)
+
+// WithConcurrency 设置并发处理的任务数，默认 10
+func WithConcurrency(n int) WorkerOption {
+	return func(w *Worker) {
+		if n > 0 {
+			w.concurrency = n
+		}
+	}
+}
+
+// WithConsumer 设置消费者名，默认由主机名和进程号生成，同一消费组内必须唯一
+func WithConsumer(name string) WorkerOption {
+	return func(w *Worker) {
+		if name != "" {
+			w.consumer = name
+		}
+	}
+}
+
+// WithVisibilityTimeout 设置可见性超时，默认 5 分钟
+// 任务处理超过此时间会取消 ctx，未确认的任务可被其他 Worker 认领
+func WithVisibilityTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.visibility = d
+		}
+	}
+}
+
+// WithPollInterval 设置转移延迟任务和认领超时任务的间隔，默认 1 秒
+func WithPollInterval(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.pollInterval = d
+		}
+	}
+}
+
+// WithBackoff 设置重试退避，第 n 次失败后等待 base*2^(n-1)，不超过 max，默认 1 秒和 10 分钟
+func WithBackoff(base, max time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if base > 0 {
+			w.backoff = base
+		}
+		if max > 0 {
+			w.maxBackoff = max
+		}
+	}
+}
+
+// WithDefaultMaxAttempts 设置未指定最大尝试次数的任务的默认值，默认 10，0 表示无限重试
+func WithDefaultMaxAttempts(n int) WorkerOption {
+	return func(w *Worker) {
+		if n >= 0 {
+			w.maxAttempts = n
+		}
+	}
+}
+
+// WithDrainTimeout 设置关闭时等待进行中任务完成的最长时间，默认 30 秒
+func WithDrainTimeout(d time.Duration) WorkerOption {
+	return func(w *Worker) {
+		if d > 0 {
+			w.drainTimeout = d
+		}
+	}
+}
+
+// Worker 任务处理池
+type Worker struct {
+	q            *Queue
+	concurrency  int
+	consumer     string
+	visibility   time.Duration
+	pollInterval time.Duration
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	maxAttempts  int
+	drainTimeout time.Duration
+	block        time.Duration // XREADGROUP 阻塞时间，也是 ctx 取消后停止拉取的最长延迟
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	claimStart string // XAUTOCLAIM 游标
+}
+
+// NewWorker 创建任务处理池
+func NewWorker(q *Queue, opts ...WorkerOption) *Worker {
+	host, _ := os.Hostname()
+	w := &Worker{
+		q:            q,
+		concurrency:  DefaultConcurrency,
+		consumer:     fmt.Sprintf("%s-%d", host, os.Getpid()),
+		visibility:   DefaultVisibilityTimeout,
+		pollInterval: DefaultPollInterval,
+		backoff:      DefaultBackoff,
+		maxBackoff:   DefaultMaxBackoff,
+		maxAttempts:  DefaultMaxAttempts,
+		drainTimeout: DefaultDrainTimeout,
+		block:        DefaultBlockTimeout,
+		handlers:     make(map[string]Handler),
+		claimStart:   "0-0",
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Handle 注册任务类型的处理函数，没有处理函数的任务按失败重试
+func (w *Worker) Handle(jobType string, h Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.handlers[jobType] = h
+}
+
+// Run 持续处理任务直到 ctx 取消
+// ctx 取消后停止拉取新任务，等待进行中的任务完成后返回 ctx.Err()；
+// 超过排空超时时取消任务的 ctx 并返回 ErrDrainTimeout，未确认的任务稍后由其他 Worker 认领
+func (w *Worker) Run(ctx context.Context) error {
+	if err := w.ensureGroup(ctx); err != nil {
+		return err
+	}
+
+	// 任务使用独立的 ctx，Run 的 ctx 取消后仍可完成并确认
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, w.concurrency)
+	)
+
+	promoteDone := make(chan struct{})
+	go func() {
+		defer close(promoteDone)
+		w.promoteLoop(ctx)
+	}()
+
+	lastClaim := time.Time{}
+	for ctx.Err() == nil {
+		// 至少有一个空闲槽位时再拉取
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			continue
+		}
+		free := 1
+	fill:
+		for free < w.concurrency {
+			select {
+			case sem <- struct{}{}:
+				free++
+			default:
+				break fill
+			}
+		}
+
+		var (
+			msgs      []redis.XMessage
+			reclaimed bool
+			err       error
+		)
+		if time.Since(lastClaim) >= w.pollInterval {
+			lastClaim = time.Now()
+			msgs, err = w.reclaim(ctx, free)
+			reclaimed = len(msgs) > 0
+		}
+		if err == nil && len(msgs) == 0 {
+			msgs, err = w.read(ctx, free)
+		}
+
+		// 归还未使用的槽位
+		for i := len(msgs); i < free; i++ {
+			<-sem
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Errorf("queue %s: fetch jobs failed: %v", w.q.name, err)
+				if isNoGroup(err) {
+					_ = w.ensureGroup(ctx)
+				}
+				select {
+				case <-ctx.Done():
+				case <-time.After(w.pollInterval):
+				}
+			}
+			continue
+		}
+
+		for _, msg := range msgs {
+			wg.Add(1)
+			go func(msg redis.XMessage) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				w.process(jobCtx, msg, reclaimed)
+			}(msg)
+		}
+	}
+	<-promoteDone
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return ctx.Err()
+	case <-time.After(w.drainTimeout):
+		cancelJobs()
+		logger.Warnf("queue %s: drain timeout, in-flight jobs will be reclaimed", w.q.name)
+		return ErrDrainTimeout
+	}
+}
+
+// ensureGroup 创建消费组，从 Stream 开头消费，已存在时忽略
+func (w *Worker) ensureGroup(ctx context.Context) error {
+	err := w.q.client.UniversalClient().XGroupCreateMkStream(ctx, w.q.streamKey(), w.q.group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return errors.Wrapf(err, "queue %s: create group", w.q.name)
+	}
+	return nil
+}
+
+// read 读取最多 count 个新任务，阻塞等待不超过 w.block
+func (w *Worker) read(ctx context.Context, count int) ([]redis.XMessage, error) {
+	streams, err := w.q.client.UniversalClient().XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    w.q.group,
+		Consumer: w.consumer,
+		Streams:  []string{w.q.streamKey(), ">"},
+		Count:    int64(count),
+		Block:    w.block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var msgs []redis.XMessage
+	for _, s := range streams {
+		msgs = append(msgs, s.Messages...)
+	}
+	return msgs, nil
+}
+
+// reclaim 认领超过可见性超时仍未确认的任务
+func (w *Worker) reclaim(ctx context.Context, count int) ([]redis.XMessage, error) {
+	msgs, next, err := w.q.client.UniversalClient().XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   w.q.streamKey(),
+		Group:    w.q.group,
+		MinIdle:  w.visibility,
+		Start:    w.claimStart,
+		Count:    int64(count),
+		Consumer: w.consumer,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	w.claimStart = next
+	return msgs, nil
+}
+
+// promoteLoop 定期将到期的延迟任务转移到 Stream
+func (w *Worker) promoteLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for ctx.Err() == nil {
+			n, err := promoteScript.Run(ctx, w.q.client.UniversalClient(),
+				[]string{w.q.delayedKey(), w.q.streamKey()}, time.Now().UnixMilli(), DefaultPromoteBatch).Int()
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Errorf("queue %s: promote delayed jobs failed: %v", w.q.name, err)
+				}
+				break
+			}
+			if n < DefaultPromoteBatch {
+				break
+			}
+		}
+	}
+}
+
+// process 处理单个任务并确认、重试或写入死信
+func (w *Worker) process(ctx context.Context, msg redis.XMessage, reclaimed bool) {
+	raw, ok := msg.Values[jobField].(string)
+	if !ok {
+		// 已删除的消息或非本队列格式，直接确认
+		w.settle(ctx, msg.ID, "ack", "", 0)
+		return
+	}
+	job, err := decodeJob(msg)
+	if err != nil {
+		logger.Errorf("queue %s: %v, moved to dead", w.q.name, err)
+		w.settle(ctx, msg.ID, "dead", raw, 0)
+		return
+	}
+
+	if reclaimed {
+		// 不直接执行，避免导致崩溃的任务在 Worker 间连续执行，见包文档
+		w.fail(ctx, job, errVisibilityTimeout)
+		return
+	}
+
+	w.mu.RLock()
+	h, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+	if !ok {
+		w.fail(ctx, job, fmt.Errorf("no handler for job type %q", job.Type))
+		return
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, w.visibility)
+	err = call(runCtx, h, job)
+	cancel()
+	if err != nil {
+		w.fail(ctx, job, err)
+		return
+	}
+	w.settle(ctx, job.streamID, "ack", "", 0)
+}
+
+// fail 记录失败，未超过最大尝试次数时按退避重试，否则写入死信
+func (w *Worker) fail(ctx context.Context, job *Job, cause error) {
+	job.Attempt++
+	job.LastError = truncate(cause.Error(), maxErrorLength)
+
+	maxAttempts := job.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = w.maxAttempts
+	}
+	dead := errors.Is(cause, ErrSkipRetry) || (maxAttempts > 0 && job.Attempt >= maxAttempts)
+
+	data, err := json.Marshal(job)
+	if err != nil {
+		logger.Errorf("queue %s: encode job %s failed: %v", w.q.name, job.ID, err)
+		return
+	}
+	if dead {
+		logger.Errorf("queue %s: job %s (%s) moved to dead after %d attempts: %v", w.q.name, job.ID, job.Type, job.Attempt, cause)
+		w.settle(ctx, job.streamID, "dead", string(data), 0)
+		return
+	}
+
+	delay := w.delay(job.Attempt)
+	logger.Warnf("queue %s: job %s (%s) failed (attempt %d), retry in %s: %v", w.q.name, job.ID, job.Type, job.Attempt, delay, cause)
+	w.settle(ctx, job.streamID, "retry", string(data), time.Now().Add(delay).UnixMilli())
+}
+
+// settle 执行确认脚本，消息已被其他消费者认领时放弃
+func (w *Worker) settle(ctx context.Context, id, mode, data string, score int64) {
+	target := w.q.delayedKey()
+	if mode == "dead" {
+		target = w.q.deadKey()
+	}
+	n, err := settleScript.Run(ctx, w.q.client.UniversalClient(), []string{w.q.streamKey(), target},
+		w.q.group, id, w.consumer, mode, data, score, w.q.deadMaxLen).Int()
+	if err != nil {
+		// 未确认的任务在可见性超时后被重新认领
+		logger.Errorf("queue %s: settle message %s (%s) failed: %v", w.q.name, id, mode, err)
+		return
+	}
+	if n == 0 {
+		logger.Warnf("queue %s: message %s was reclaimed by another consumer, result discarded", w.q.name, id)
+	}
+}
+
+// delay 返回第 attempts 次失败后的退避时间
+func (w *Worker) delay(attempts int) time.Duration {
+	d := w.backoff
+	for i := 1; i < attempts && d < w.maxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.maxBackoff {
+		d = w.maxBackoff
+	}
+	return d
+}
+
+// call 调用处理函数，panic 视为失败
+func call(ctx context.Context, h Handler, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, job)
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return strings.ToValidUTF8(s[:n], "")
+}
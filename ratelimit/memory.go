@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// 确保 Memory 实现 Limiter 接口
+var _ Limiter = (*Memory)(nil)
+
+// sweepEvery 每处理多少次请求清理一次过期状态
+const sweepEvery = 1024
+
+// memState 单个键的限流状态
+type memState struct {
+	count     int         // 固定窗口计数
+	windowEnd time.Time   // 固定窗口结束时间
+	log       []time.Time // 滑动窗口内的请求时间，升序
+	tat       time.Time   // GCRA 理论到达时间
+	expireAt  time.Time   // 状态过期时间，之后等同于不存在
+}
+
+// Memory 进程内限流器，配额仅在当前进程内生效
+type Memory struct {
+	limit Limit
+	now   func() time.Time
+
+	mu     sync.Mutex
+	states map[string]*memState
+	ops    int
+}
+
+// NewMemory 创建进程内限流器
+func NewMemory(limit Limit, opts ...Option) (*Memory, error) {
+	l, err := limit.normalize()
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	return &Memory{limit: l, now: o.now, states: make(map[string]*memState)}, nil
+}
+
+// Allow 消耗一次配额
+func (m *Memory) Allow(ctx context.Context, key string) (*Result, error) {
+	return m.AllowN(ctx, key, 1)
+}
+
+// AllowN 消耗 n 次配额
+func (m *Memory) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	if err := checkN(m.limit, n); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	s, ok := m.states[key]
+	if !ok || !now.Before(s.expireAt) {
+		s = &memState{}
+		m.states[key] = s
+	}
+
+	var res *Result
+	switch m.limit.Algorithm {
+	case FixedWindow:
+		res = m.fixedWindow(s, now, n)
+	case SlidingWindow:
+		res = m.slidingWindow(s, now, n)
+	default:
+		res = m.gcra(s, now, n)
+	}
+	res.Limit = m.limit
+	return res, nil
+}
+
+// Reset 清除键的限流状态
+func (m *Memory) Reset(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.states, key)
+	return nil
+}
+
+// fixedWindow 固定窗口
+func (m *Memory) fixedWindow(s *memState, now time.Time, n int) *Result {
+	if !now.Before(s.windowEnd) {
+		s.count = 0
+		s.windowEnd = now.Add(m.limit.Period)
+		s.expireAt = s.windowEnd
+	}
+	reset := s.windowEnd.Sub(now)
+
+	if s.count+n > m.limit.Rate {
+		return &Result{Remaining: m.limit.Rate - s.count, RetryAfter: reset, ResetAfter: reset}
+	}
+	s.count += n
+	return &Result{Allowed: true, Remaining: m.limit.Rate - s.count, ResetAfter: reset}
+}
+
+// slidingWindow 滑动窗口日志
+func (m *Memory) slidingWindow(s *memState, now time.Time, n int) *Result {
+	cutoff := now.Add(-m.limit.Period)
+	i := 0
+	for i < len(s.log) && !s.log[i].After(cutoff) {
+		i++
+	}
+	s.log = s.log[i:]
+
+	count := len(s.log)
+	if count+n > m.limit.Rate {
+		// 第 count+n-Rate 条记录过期后才能容纳 n 次请求
+		oldest := s.log[count+n-m.limit.Rate-1]
+		newest := s.log[count-1]
+		return &Result{
+			Remaining:  m.limit.Rate - count,
+			RetryAfter: oldest.Add(m.limit.Period).Sub(now),
+			ResetAfter: newest.Add(m.limit.Period).Sub(now),
+		}
+	}
+
+	for j := 0; j < n; j++ {
+		s.log = append(s.log, now)
+	}
+	s.expireAt = now.Add(m.limit.Period)
+	return &Result{Allowed: true, Remaining: m.limit.Rate - count - n, ResetAfter: m.limit.Period}
+}
+
+// gcra 通用信元速率算法
+func (m *Memory) gcra(s *memState, now time.Time, n int) *Result {
+	interval := float64(m.limit.Period) / float64(m.limit.Rate)
+	burstOffset := time.Duration(interval * float64(m.limit.Burst))
+
+	tat := s.tat
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(time.Duration(interval * float64(n)))
+	allowAt := newTat.Add(-burstOffset)
+
+	if diff := now.Sub(allowAt); diff < 0 {
+		return &Result{
+			Remaining:  int(math.Floor(float64(now.Sub(tat.Add(-burstOffset))) / interval)),
+			RetryAfter: -diff,
+			ResetAfter: tat.Sub(now),
+		}
+	}
+
+	s.tat = newTat
+	s.expireAt = newTat
+	return &Result{
+		Allowed:    true,
+		Remaining:  int(math.Floor(float64(now.Sub(allowAt)) / interval)),
+		ResetAfter: newTat.Sub(now),
+	}
+}
+
+// sweep 定期清理过期状态，避免键无限增长
+func (m *Memory) sweep(now time.Time) {
+	m.ops++
+	if m.ops < sweepEvery {
+		return
+	}
+	m.ops = 0
+	for key, s := range m.states {
+		if !now.Before(s.expireAt) {
+			delete(m.states, key)
+		}
+	}
+}
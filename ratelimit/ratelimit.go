@@ -0,0 +1,155 @@
+// Package ratelimit 提供按键限流，支持固定窗口、滑动窗口日志和 GCRA（令牌桶）三种算法
+//
+// Redis 实现通过 Lua 脚本原子执行，适用于多实例共享配额；内存实现接口相同，适用于单机和测试：
+//
+//	limiter, _ := ratelimit.NewRedis(redisClient, ratelimit.PerMinute(5))
+//	res, err := limiter.Allow(ctx, "sms:"+phone)
+//	if err == nil && !res.Allowed {
+//		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+//	}
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperits/gosuite/errors"
+)
+
+// DefaultPrefix Redis 限流键的默认前缀
+const DefaultPrefix = "ratelimit:"
+
+// Algorithm 限流算法
+type Algorithm string
+
+const (
+	// FixedWindow 固定窗口，每个周期最多 Rate 次，周期边界处可能出现两倍突发
+	FixedWindow Algorithm = "fixed_window"
+
+	// SlidingWindow 滑动窗口日志，任意 Period 时长内最多 Rate 次，每次请求记录一条时间戳
+	SlidingWindow Algorithm = "sliding_window"
+
+	// GCRA 通用信元速率算法，等价于容量为 Burst、每 Period/Rate 补充一个令牌的令牌桶
+	GCRA Algorithm = "gcra"
+)
+
+// Limit 限流规则
+type Limit struct {
+	Algorithm Algorithm     // 算法，默认 GCRA
+	Rate      int           // 每个周期允许的次数
+	Period    time.Duration // 周期
+	Burst     int           // 仅 GCRA，允许的突发次数（桶容量），默认等于 Rate
+}
+
+// PerSecond 返回每秒 rate 次的 GCRA 规则
+func PerSecond(rate int) Limit {
+	return Limit{Algorithm: GCRA, Rate: rate, Period: time.Second, Burst: rate}
+}
+
+// PerMinute 返回每分钟 rate 次的 GCRA 规则
+func PerMinute(rate int) Limit {
+	return Limit{Algorithm: GCRA, Rate: rate, Period: time.Minute, Burst: rate}
+}
+
+// PerHour 返回每小时 rate 次的 GCRA 规则
+func PerHour(rate int) Limit {
+	return Limit{Algorithm: GCRA, Rate: rate, Period: time.Hour, Burst: rate}
+}
+
+// normalize 校验规则并填充默认值
+func (l Limit) normalize() (Limit, error) {
+	if l.Algorithm == "" {
+		l.Algorithm = GCRA
+	}
+	switch l.Algorithm {
+	case FixedWindow, SlidingWindow, GCRA:
+	default:
+		return l, errors.Wrap(errors.ErrInvalidParameter, "ratelimit: unknown algorithm "+string(l.Algorithm))
+	}
+	if l.Rate <= 0 || l.Period <= 0 {
+		return l, errors.Wrap(errors.ErrInvalidParameter, "ratelimit: rate and period must be positive")
+	}
+	if l.Burst <= 0 {
+		l.Burst = l.Rate
+	}
+	return l, nil
+}
+
+// capacity 返回单次请求允许的最大数量
+func (l Limit) capacity() int {
+	if l.Algorithm == GCRA {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// String 返回规则描述，如 "gcra 10/1s burst 10"
+func (l Limit) String() string {
+	if l.Algorithm == GCRA {
+		return fmt.Sprintf("%s %d/%s burst %d", l.Algorithm, l.Rate, l.Period, l.Burst)
+	}
+	return fmt.Sprintf("%s %d/%s", l.Algorithm, l.Rate, l.Period)
+}
+
+// Result 限流结果
+type Result struct {
+	Limit      Limit         // 使用的规则
+	Allowed    bool          // 是否放行
+	Remaining  int           // 剩余可用次数
+	RetryAfter time.Duration // 被拒绝时距离下次可能放行的时间，放行时为 0
+	ResetAfter time.Duration // 距离配额完全恢复的时间
+}
+
+// Limiter 限流器
+type Limiter interface {
+	// Allow 消耗一次配额
+	Allow(ctx context.Context, key string) (*Result, error)
+
+	// AllowN 消耗 n 次配额，配额不足时不消耗；n 超过单次上限（Rate 或 Burst）时返回错误
+	AllowN(ctx context.Context, key string, n int) (*Result, error)
+
+	// Reset 清除键的限流状态
+	Reset(ctx context.Context, key string) error
+}
+
+// Option 限流器配置选项函数
+type Option func(*options)
+
+// options 限流器配置
+type options struct {
+	prefix string
+	now    func() time.Time
+}
+
+// WithPrefix 设置 Redis 限流键前缀，默认 "ratelimit:"
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.prefix = prefix
+	}
+}
+
+// WithClock 设置内存实现使用的时钟，便于测试；Redis 实现使用 Redis 服务器时间
+func WithClock(now func() time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// newOptions 返回应用选项后的配置
+func newOptions(opts []Option) options {
+	o := options{prefix: DefaultPrefix, now: time.Now}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// checkN 校验单次请求数量
+func checkN(l Limit, n int) error {
+	if n <= 0 || n > l.capacity() {
+		return errors.Wrap(errors.ErrInvalidParameter,
+			fmt.Sprintf("ratelimit: n must be in [1, %d], got %d", l.capacity(), n))
+	}
+	return nil
+}
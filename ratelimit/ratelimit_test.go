@@ -0,0 +1,305 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/hyperits/gosuite/internal/redistest"
+)
+
+// fakeClock 可手动推进的时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestMemory(t *testing.T, limit Limit) (*Memory, *fakeClock) {
+	t.Helper()
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	m, err := NewMemory(limit, WithClock(clock.now))
+	if err != nil {
+		t.Fatalf("NewMemory: %v", err)
+	}
+	return m, clock
+}
+
+func allow(t *testing.T, l Limiter, key string, n int) *Result {
+	t.Helper()
+	res, err := l.AllowN(context.Background(), key, n)
+	if err != nil {
+		t.Fatalf("AllowN(%q, %d): %v", key, n, err)
+	}
+	return res
+}
+
+func TestLimitNormalize(t *testing.T) {
+	l, err := Limit{Rate: 10, Period: time.Second}.normalize()
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	if l.Algorithm != GCRA || l.Burst != 10 {
+		t.Errorf("defaults = %s", l)
+	}
+
+	for _, bad := range []Limit{
+		{Rate: 0, Period: time.Second},
+		{Rate: 1, Period: 0},
+		{Algorithm: "leaky", Rate: 1, Period: time.Second},
+	} {
+		if _, err := NewMemory(bad); !errors.Is(err, errors.ErrInvalidParameter) {
+			t.Errorf("NewMemory(%+v) err = %v", bad, err)
+		}
+	}
+
+	m, _ := newTestMemory(t, PerSecond(5))
+	for _, n := range []int{0, 6} {
+		if _, err := m.AllowN(context.Background(), "k", n); !errors.Is(err, errors.ErrInvalidParameter) {
+			t.Errorf("AllowN(%d) err = %v", n, err)
+		}
+	}
+}
+
+func TestMemoryFixedWindow(t *testing.T) {
+	m, clock := newTestMemory(t, Limit{Algorithm: FixedWindow, Rate: 3, Period: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		res := allow(t, m, "k", 1)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d = %+v", i, res)
+		}
+	}
+
+	clock.advance(20 * time.Second)
+	res := allow(t, m, "k", 1)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 40*time.Second {
+		t.Fatalf("over limit = %+v", res)
+	}
+
+	// 其他键不受影响
+	if res := allow(t, m, "other", 3); !res.Allowed {
+		t.Fatalf("other key = %+v", res)
+	}
+
+	clock.advance(40 * time.Second)
+	if res := allow(t, m, "k", 2); !res.Allowed || res.Remaining != 1 || res.ResetAfter != time.Minute {
+		t.Fatalf("next window = %+v", res)
+	}
+}
+
+func TestMemorySlidingWindow(t *testing.T) {
+	m, clock := newTestMemory(t, Limit{Algorithm: SlidingWindow, Rate: 3, Period: time.Minute})
+
+	allow(t, m, "k", 1)
+	clock.advance(10 * time.Second)
+	allow(t, m, "k", 2)
+
+	clock.advance(10 * time.Second)
+	res := allow(t, m, "k", 1)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 40*time.Second || res.ResetAfter != 50*time.Second {
+		t.Fatalf("over limit = %+v", res)
+	}
+
+	// 两次请求需要等待第 2 条记录过期
+	res = allow(t, m, "k", 2)
+	if res.Allowed || res.RetryAfter != 50*time.Second {
+		t.Fatalf("n=2 = %+v", res)
+	}
+
+	clock.advance(40 * time.Second)
+	if res := allow(t, m, "k", 1); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after oldest expired = %+v", res)
+	}
+	if res := allow(t, m, "k", 1); res.Allowed {
+		t.Fatalf("window still full = %+v", res)
+	}
+}
+
+func TestMemoryGCRA(t *testing.T) {
+	// 每秒 10 个令牌，桶容量 5
+	m, clock := newTestMemory(t, Limit{Algorithm: GCRA, Rate: 10, Period: time.Second, Burst: 5})
+
+	res := allow(t, m, "k", 5)
+	if !res.Allowed || res.Remaining != 0 || res.ResetAfter != 500*time.Millisecond {
+		t.Fatalf("burst = %+v", res)
+	}
+
+	res = allow(t, m, "k", 1)
+	if res.Allowed || res.RetryAfter != 100*time.Millisecond || res.ResetAfter != 500*time.Millisecond {
+		t.Fatalf("over burst = %+v", res)
+	}
+
+	clock.advance(250 * time.Millisecond)
+	res = allow(t, m, "k", 1)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("after refill = %+v", res)
+	}
+
+	res = allow(t, m, "k", 3)
+	if res.Allowed || res.Remaining != 1 || res.RetryAfter != 150*time.Millisecond {
+		t.Fatalf("n=3 = %+v", res)
+	}
+
+	clock.advance(time.Second)
+	if res := allow(t, m, "k", 5); !res.Allowed {
+		t.Fatalf("after full refill = %+v", res)
+	}
+}
+
+func TestMemoryResetAndSweep(t *testing.T) {
+	m, clock := newTestMemory(t, Limit{Algorithm: FixedWindow, Rate: 1, Period: time.Second})
+
+	allow(t, m, "k", 1)
+	if res := allow(t, m, "k", 1); res.Allowed {
+		t.Fatalf("over limit = %+v", res)
+	}
+	if err := m.Reset(context.Background(), "k"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if res := allow(t, m, "k", 1); !res.Allowed {
+		t.Fatalf("after reset = %+v", res)
+	}
+
+	clock.advance(2 * time.Second)
+	for i := 0; i < sweepEvery; i++ {
+		allow(t, m, "fresh", 1)
+	}
+	m.mu.Lock()
+	_, ok := m.states["k"]
+	m.mu.Unlock()
+	if ok {
+		t.Error("expired state not swept")
+	}
+}
+
+// redisClock 同时推进 miniredis 的 TIME 和键过期时间
+type redisClock struct {
+	mr  *miniredis.Miniredis
+	now time.Time
+}
+
+func (c *redisClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.mr.SetTime(c.now)
+	c.mr.FastForward(d)
+}
+
+func newTestRedis(t *testing.T, limit Limit) (*Redis, *redisClock) {
+	t.Helper()
+	client, mr := redistest.Run(t)
+
+	clock := &redisClock{mr: mr, now: time.Unix(1700000000, 0)}
+	mr.SetTime(clock.now)
+	r, err := NewRedis(client, limit)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	return r, clock
+}
+
+// near 判断浮点换算后的时长是否在 1ms 误差内
+func near(got, want time.Duration) bool {
+	d := got - want
+	return d > -time.Millisecond && d < time.Millisecond
+}
+
+func TestRedisFixedWindow(t *testing.T) {
+	r, clock := newTestRedis(t, Limit{Algorithm: FixedWindow, Rate: 3, Period: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		res := allow(t, r, "k", 1)
+		if !res.Allowed || res.Remaining != 2-i {
+			t.Fatalf("request %d = %+v", i, res)
+		}
+	}
+
+	clock.advance(20 * time.Second)
+	res := allow(t, r, "k", 1)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 40*time.Second {
+		t.Fatalf("over limit = %+v", res)
+	}
+	if res := allow(t, r, "other", 3); !res.Allowed {
+		t.Fatalf("other key = %+v", res)
+	}
+
+	// 窗口到期后计数键过期，重新计数
+	clock.advance(40 * time.Second)
+	if res := allow(t, r, "k", 2); !res.Allowed || res.Remaining != 1 || res.ResetAfter != time.Minute {
+		t.Fatalf("next window = %+v", res)
+	}
+
+	if err := r.Reset(context.Background(), "k"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if res := allow(t, r, "k", 3); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after reset = %+v", res)
+	}
+}
+
+func TestRedisSlidingWindow(t *testing.T) {
+	r, clock := newTestRedis(t, Limit{Algorithm: SlidingWindow, Rate: 3, Period: time.Minute})
+
+	allow(t, r, "k", 1)
+	clock.advance(10 * time.Second)
+	allow(t, r, "k", 2)
+
+	clock.advance(10 * time.Second)
+	res := allow(t, r, "k", 1)
+	if res.Allowed || res.Remaining != 0 || res.RetryAfter != 40*time.Second || res.ResetAfter != 50*time.Second {
+		t.Fatalf("over limit = %+v", res)
+	}
+
+	// 两次请求需要等待第 2 条记录过期
+	res = allow(t, r, "k", 2)
+	if res.Allowed || res.RetryAfter != 50*time.Second {
+		t.Fatalf("n=2 = %+v", res)
+	}
+
+	clock.advance(40 * time.Second)
+	if res := allow(t, r, "k", 1); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after oldest expired = %+v", res)
+	}
+	if res := allow(t, r, "k", 1); res.Allowed {
+		t.Fatalf("window still full = %+v", res)
+	}
+
+	// 整个窗口无请求后记录全部过期
+	clock.advance(time.Minute)
+	if res := allow(t, r, "k", 3); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after window = %+v", res)
+	}
+}
+
+func TestRedisGCRA(t *testing.T) {
+	r, clock := newTestRedis(t, Limit{Algorithm: GCRA, Rate: 10, Period: time.Second, Burst: 5})
+
+	res := allow(t, r, "k", 5)
+	if !res.Allowed || res.Remaining != 0 || !near(res.ResetAfter, 500*time.Millisecond) {
+		t.Fatalf("burst = %+v", res)
+	}
+
+	res = allow(t, r, "k", 1)
+	if res.Allowed || !near(res.RetryAfter, 100*time.Millisecond) || !near(res.ResetAfter, 500*time.Millisecond) {
+		t.Fatalf("over burst = %+v", res)
+	}
+
+	clock.advance(250 * time.Millisecond)
+	res = allow(t, r, "k", 1)
+	if !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("after refill = %+v", res)
+	}
+
+	res = allow(t, r, "k", 3)
+	if res.Allowed || res.Remaining != 1 || !near(res.RetryAfter, 150*time.Millisecond) {
+		t.Fatalf("n=3 = %+v", res)
+	}
+
+	clock.advance(time.Second)
+	if res := allow(t, r, "k", 5); !res.Allowed {
+		t.Fatalf("after full refill = %+v", res)
+	}
+}
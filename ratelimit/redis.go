@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	gredis "github.com/hyperits/gosuite/db/redis"
+	"github.com/hyperits/gosuite/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// 确保 Redis 实现 Limiter 接口
+var _ Limiter = (*Redis)(nil)
+
+// fixedWindowScript 固定窗口
+// ARGV: rate, n, period(ms)
+// 返回 {allowed, remaining, retry_after(ms), reset_after(ms)}
+var fixedWindowScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local n = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+
+local count = tonumber(redis.call("GET", KEYS[1]) or "0")
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	count = 0
+	ttl = period
+end
+
+if count + n > rate then
+	return {0, rate - count, ttl, ttl}
+end
+
+count = redis.call("INCRBY", KEYS[1], n)
+if count == n then
+	redis.call("PEXPIRE", KEYS[1], period)
+end
+return {1, rate - count, 0, ttl}
+`)
+
+// slidingWindowScript 滑动窗口日志，时间取 Redis 服务器时间（微秒）
+// ARGV: rate, n, period(us), member 后缀
+// 返回 {allowed, remaining, retry_after(us), reset_after(us)}
+var slidingWindowScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local n = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - period)
+local count = redis.call("ZCARD", KEYS[1])
+
+if count + n > rate then
+	local idx = count + n - rate - 1
+	local oldest = redis.call("ZRANGE", KEYS[1], idx, idx, "WITHSCORES")
+	local newest = redis.call("ZRANGE", KEYS[1], -1, -1, "WITHSCORES")
+	return {0, rate - count, tonumber(oldest[2]) + period - now, tonumber(newest[2]) + period - now}
+end
+
+for i = 1, n do
+	redis.call("ZADD", KEYS[1], now, now .. ":" .. ARGV[4] .. ":" .. i)
+end
+redis.call("PEXPIRE", KEYS[1], math.ceil(period / 1000))
+return {1, rate - count - n, 0, period}
+`)
+
+// gcraScript 通用信元速率算法，时间取 Redis 服务器时间（秒，浮点数）
+// ARGV: rate, period(s), burst, n
+// 返回 {allowed, remaining, retry_after(s), reset_after(s)}，浮点数以字符串返回
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local rate = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local interval = period / rate
+local burst_offset = interval * burst
+
+local t = redis.call("TIME")
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or "0")
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval * n
+local allow_at = new_tat - burst_offset
+local diff = now - allow_at
+
+if diff < 0 then
+	local remaining = math.floor((now - (tat - burst_offset)) / interval)
+	return {0, remaining, tostring(-diff), tostring(tat - now)}
+end
+
+local reset_after = new_tat - now
+redis.call("SET", KEYS[1], string.format("%.6f", new_tat), "PX", math.ceil(reset_after * 1000))
+return {1, math.floor(diff / interval), "0", tostring(reset_after)}
+`)
+
+// Redis 基于 Redis 的限流器，多个进程共享配额
+type Redis struct {
+	client gredis.UniversalClientProvider
+	limit  Limit
+	prefix string
+}
+
+// NewRedis 创建基于 Redis 的限流器
+func NewRedis(client gredis.UniversalClientProvider, limit Limit, opts ...Option) (*Redis, error) {
+	if client == nil {
+		return nil, errors.ErrNilClient
+	}
+	l, err := limit.normalize()
+	if err != nil {
+		return nil, err
+	}
+	o := newOptions(opts)
+	return &Redis{client: client, limit: l, prefix: o.prefix}, nil
+}
+
+// Allow 消耗一次配额
+func (r *Redis) Allow(ctx context.Context, key string) (*Result, error) {
+	return r.AllowN(ctx, key, 1)
+}
+
+// AllowN 消耗 n 次配额
+func (r *Redis) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	if err := checkN(r.limit, n); err != nil {
+		return nil, err
+	}
+
+	rc := r.client.UniversalClient()
+	keys := []string{r.key(key)}
+
+	var (
+		values []interface{}
+		unit   time.Duration
+		err    error
+	)
+	switch r.limit.Algorithm {
+	case FixedWindow:
+		unit = time.Millisecond
+		values, err = fixedWindowScript.Run(ctx, rc, keys, r.limit.Rate, n, r.limit.Period.Milliseconds()).Slice()
+	case SlidingWindow:
+		var suffix string
+		if suffix, err = memberSuffix(); err != nil {
+			return nil, err
+		}
+		unit = time.Microsecond
+		values, err = slidingWindowScript.Run(ctx, rc, keys, r.limit.Rate, n, r.limit.Period.Microseconds(), suffix).Slice()
+	default:
+		unit = time.Second
+		values, err = gcraScript.Run(ctx, rc, keys, r.limit.Rate, r.limit.Period.Seconds(), r.limit.Burst, n).Slice()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "ratelimit.redis")
+	}
+	return parseResult(r.limit, values, unit)
+}
+
+// Reset 清除键的限流状态
+func (r *Redis) Reset(ctx context.Context, key string) error {
+	return r.client.UniversalClient().Del(ctx, r.key(key)).Err()
+}
+
+// key 返回带前缀和算法名的 Redis 键，不同算法的状态结构不同，不能共用
+func (r *Redis) key(key string) string {
+	return r.prefix + string(r.limit.Algorithm) + ":" + key
+}
+
+// parseResult 解析脚本返回的 {allowed, remaining, retry_after, reset_after}
+func parseResult(limit Limit, values []interface{}, unit time.Duration) (*Result, error) {
+	if len(values) != 4 {
+		return nil, fmt.Errorf("ratelimit.redis: unexpected script result %v", values)
+	}
+
+	nums := make([]float64, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case int64:
+			nums[i] = float64(v)
+		case string:
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "ratelimit.redis")
+			}
+			nums[i] = f
+		default:
+			return nil, fmt.Errorf("ratelimit.redis: unexpected script value %T", v)
+		}
+	}
+
+	return &Result{
+		Limit:      limit,
+		Allowed:    nums[0] == 1,
+		Remaining:  int(nums[1]),
+		RetryAfter: time.Duration(nums[2] * float64(unit)),
+		ResetAfter: time.Duration(nums[3] * float64(unit)),
+	}, nil
+}
+
+// memberSuffix 生成滑动窗口记录的随机后缀，避免同一微秒内的记录重复
+func memberSuffix() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "ratelimit: generate member")
+	}
+	return hex.EncodeToString(b), nil
+}